@@ -1,5 +1,7 @@
 package dto
 
+import "encoding/json"
+
 type Metadata struct {
 	Merchant       string `json:"merchant"`
 	PaymentGateway string `json:"payment_gateway"`
@@ -24,9 +26,9 @@ type CreatePaymentVirtualAccount struct {
 
 type CreatePaymentRequest struct {
 	// Identitas Transaksi (Mapping ke reference_no & description)
-	ReferenceNo string  `json:"reference_no" validate:"required"`
-	Amount      float64 `json:"amount" validate:"required,gt=0"`
-	Description string  `json:"description"`
+	ReferenceNo string      `json:"reference_no" validate:"required"`
+	Amount      json.Number `json:"amount" validate:"required,amount"` // in major units, decimals per currency
+	Description string      `json:"description"`
 
 	// Informasi Produk/Merchant (Mapping ke merchant_id & product metadata)
 	MerchantID string `json:"merchant_id" validate:"required,uuid"`
@@ -40,12 +42,12 @@ type CreatePaymentRequest struct {
 
 	// Informasi Pelanggan (Data Dinamis)
 	CustomerName  string `json:"customer_name" validate:"required"`
-	CustomerEmail string `json:"customer_email" validate:"email"`
+	CustomerEmail string `json:"customer_email" validate:"omitempty,email"`
 	CustomerPhone string `json:"customer_phone"`
 
 	// URL Notifikasi (Mapping ke callback_url)
 	CallbackUrl string `json:"callback_url" validate:"required,url"`
-	ReturnUrl   string `json:"return_url" validate:"url"`
+	ReturnUrl   string `json:"return_url" validate:"omitempty,url"`
 }
 
 func (r *CreatePaymentRequest) ToPayloadMap() map[string]interface{} {
@@ -80,7 +82,7 @@ func (r *CreatePaymentRequest) ToPayloadMap() map[string]interface{} {
 	// Given `PaymentHandler` binds `CreatePaymentRequest` and calls `ToPayloadMap`, and the map is sent to usecase.
 	// I will include all fields used in `ToPayloadMap` in `CreatePaymentRequest` to ensure it works.
 
-	if r.Amount != 0 {
+	if r.Amount != "" {
 		payload["request_amount"] = r.Amount
 	}
 	if r.ChannelCode != "" {
@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"worker-nicepay/domain/money"
+)
+
+// FeeScheduleRequest sets the fee contract of a merchant's channel in one
+// currency. Amounts are in major units of the currency, percentages are
+// decimal percents such as "1.5". The schedule applies from EffectiveFrom,
+// or from now when omitted.
+type FeeScheduleRequest struct {
+	ChannelCode   string           `json:"channel_code" validate:"required"`
+	Currency      string           `json:"currency" validate:"required,len=3,alpha,uppercase"`
+	FeeType       string           `json:"fee_type" validate:"required,oneof=PERCENTAGE FLAT TIERED"`
+	Percentage    json.Number      `json:"percentage" validate:"required_if=FeeType PERCENTAGE,omitempty,percentage"`
+	FlatAmount    json.Number      `json:"flat_amount" validate:"required_if=FeeType FLAT,omitempty,amount"`
+	Tiers         []FeeTierRequest `json:"tiers" validate:"required_if=FeeType TIERED,omitempty,dive"`
+	MinFee        json.Number      `json:"min_fee" validate:"omitempty,amount"`
+	MaxFee        json.Number      `json:"max_fee" validate:"omitempty,amount"`
+	TaxPercentage json.Number      `json:"tax_percentage" validate:"omitempty,percentage"`
+	EffectiveFrom *time.Time       `json:"effective_from"` // RFC 3339
+}
+
+// FeeTierRequest prices payments up to UpTo, or of any amount when UpTo is
+// omitted on the last tier.
+type FeeTierRequest struct {
+	UpTo       json.Number `json:"up_to" validate:"omitempty,amount"`
+	Percentage json.Number `json:"percentage" validate:"omitempty,percentage"`
+	FlatAmount json.Number `json:"flat_amount" validate:"omitempty,amount"`
+}
+
+func (r FeeScheduleRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if r.MinFee != "" && r.MaxFee != "" {
+		minFee, _ := money.ParseDecimal(r.MinFee.String())
+		maxFee, _ := money.ParseDecimal(r.MaxFee.String())
+		if minFee.Cmp(maxFee) > 0 {
+			return errors.New("MinFee must not exceed MaxFee")
+		}
+	}
+	return nil
+}
+
+// FeeScheduleResponse shows a fee schedule with amounts in major units of
+// its currency.
+type FeeScheduleResponse struct {
+	ID            string            `json:"id"`
+	MerchantID    string            `json:"merchant_id"`
+	ChannelCode   string            `json:"channel_code"`
+	Currency      string            `json:"currency"`
+	FeeType       string            `json:"fee_type"`
+	Percentage    *string           `json:"percentage,omitempty"`
+	FlatAmount    json.Number       `json:"flat_amount,omitempty"`
+	Tiers         []FeeTierResponse `json:"tiers,omitempty"`
+	MinFee        json.Number       `json:"min_fee,omitempty"`
+	MaxFee        json.Number       `json:"max_fee,omitempty"`
+	TaxPercentage *string           `json:"tax_percentage,omitempty"`
+	EffectiveFrom time.Time         `json:"effective_from"`
+	CreatedDate   *int64            `json:"created_date"`
+	UpdatedDate   *int64            `json:"updated_date"`
+	DeletedDate   *int64            `json:"deleted_date,omitempty"`
+	DataStatus    *string           `json:"data_status"`
+}
+
+type FeeTierResponse struct {
+	UpTo       json.Number `json:"up_to,omitempty"`
+	Percentage string      `json:"percentage,omitempty"`
+	FlatAmount json.Number `json:"flat_amount,omitempty"`
+}
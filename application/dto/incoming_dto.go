@@ -9,4 +9,5 @@ type IncomingRequest struct {
 	Event         string `json:"event"`
 	Email         string `json:"email"`
 	Currency      string `json:"currency"`
+	MerchantID    string `json:"merchant_id"`
 }
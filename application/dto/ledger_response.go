@@ -0,0 +1,15 @@
+package dto
+
+import "encoding/json"
+
+// LedgerBalanceResponse shows the balance of a ledger account on its normal
+// side, with amounts in major units of its currency.
+type LedgerBalanceResponse struct {
+	Account     string      `json:"account"`
+	AccountType string      `json:"account_type"`
+	MerchantID  *string     `json:"merchant_id,omitempty"`
+	Currency    string      `json:"currency"`
+	Debit       json.Number `json:"debit"`
+	Credit      json.Number `json:"credit"`
+	Balance     json.Number `json:"balance"`
+}
@@ -0,0 +1,41 @@
+package dto
+
+// CurrencyRequest creates or updates a currency. MinorUnit defaults to 2 and
+// only applies on create, since stored amounts are scaled by it.
+type CurrencyRequest struct {
+	Code      string `json:"code" validate:"required,len=3,alpha,uppercase"`
+	Name      string `json:"name" validate:"required"`
+	MinorUnit *int   `json:"minor_unit" validate:"omitempty,min=0,max=4"`
+}
+
+func (r CurrencyRequest) Validate() error {
+	return validateStruct(r)
+}
+
+type CountryRequest struct {
+	Code string `json:"code" validate:"required,len=2,alpha,uppercase"`
+	Name string `json:"name" validate:"required"`
+}
+
+func (r CountryRequest) Validate() error {
+	return validateStruct(r)
+}
+
+type PaymentMethodRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (r PaymentMethodRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// ProviderRequest creates or updates an e-wallet or VA provider. ProviderName
+// is the channel code the provider is looked up by.
+type ProviderRequest struct {
+	Name         string `json:"name" validate:"required"`
+	ProviderName string `json:"provider_name" validate:"required"`
+}
+
+func (r ProviderRequest) Validate() error {
+	return validateStruct(r)
+}
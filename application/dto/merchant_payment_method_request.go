@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"encoding/json"
+	"errors"
+
+	"worker-nicepay/domain/money"
+)
+
+// MerchantPaymentMethodRequest enables a channel for a merchant in one
+// currency. Amounts are in major units of the currency and omitted limits do
+// not apply. Active hours are HH:MM in Timezone and may wrap past midnight.
+type MerchantPaymentMethodRequest struct {
+	ChannelCode    string      `json:"channel_code" validate:"required"`
+	Currency       string      `json:"currency" validate:"required,len=3,alpha,uppercase"`
+	Enabled        *bool       `json:"enabled"`
+	MinAmount      json.Number `json:"min_amount" validate:"omitempty,amount"`
+	MaxAmount      json.Number `json:"max_amount" validate:"omitempty,amount"`
+	DailyAmountCap json.Number `json:"daily_amount_cap" validate:"omitempty,amount"`
+	DailyCountCap  *int64      `json:"daily_count_cap" validate:"omitempty,min=1"`
+	ActiveFrom     string      `json:"active_from" validate:"required_with=ActiveUntil,omitempty,datetime=15:04"`
+	ActiveUntil    string      `json:"active_until" validate:"required_with=ActiveFrom,omitempty,datetime=15:04"`
+	Timezone       string      `json:"timezone" validate:"omitempty,timezone"`
+}
+
+func (r MerchantPaymentMethodRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if r.MinAmount != "" && r.MaxAmount != "" {
+		minAmount, _ := money.ParseDecimal(r.MinAmount.String())
+		maxAmount, _ := money.ParseDecimal(r.MaxAmount.String())
+		if minAmount.Cmp(maxAmount) > 0 {
+			return errors.New("MinAmount must not exceed MaxAmount")
+		}
+	}
+	if r.ActiveFrom != "" && r.ActiveFrom == r.ActiveUntil {
+		return errors.New("ActiveFrom and ActiveUntil must differ")
+	}
+	return nil
+}
+
+// MerchantPaymentMethodResponse shows a merchant's channel with amounts in
+// major units of its currency.
+type MerchantPaymentMethodResponse struct {
+	ID             string      `json:"id"`
+	MerchantID     string      `json:"merchant_id"`
+	ChannelCode    string      `json:"channel_code"`
+	Currency       string      `json:"currency"`
+	Enabled        bool        `json:"enabled"`
+	MinAmount      json.Number `json:"min_amount,omitempty"`
+	MaxAmount      json.Number `json:"max_amount,omitempty"`
+	DailyAmountCap json.Number `json:"daily_amount_cap,omitempty"`
+	DailyCountCap  *int64      `json:"daily_count_cap,omitempty"`
+	ActiveFrom     *string     `json:"active_from,omitempty"`
+	ActiveUntil    *string     `json:"active_until,omitempty"`
+	Timezone       string      `json:"timezone"`
+	CreatedDate    *int64      `json:"created_date"`
+	UpdatedDate    *int64      `json:"updated_date"`
+	DeletedDate    *int64      `json:"deleted_date,omitempty"`
+	DataStatus     *string     `json:"data_status"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PaymentResponse shows a payment with amounts in major units of its
+// currency. The fee breakdown is omitted until it is calculated.
+type PaymentResponse struct {
+	ID                string      `json:"id"`
+	TransactionID     string      `json:"transaction_id"`
+	ReferenceNo       string      `json:"reference_no"`
+	MerchantID        string      `json:"merchant_id"`
+	Merchant          string      `json:"merchant,omitempty"`
+	ChannelCode       string      `json:"channel_code"`
+	Currency          string      `json:"currency"`
+	GrossAmount       json.Number `json:"gross_amount"`
+	FeeAmount         json.Number `json:"fee_amount,omitempty"`
+	FeeTaxAmount      json.Number `json:"fee_tax_amount,omitempty"`
+	NetAmount         json.Number `json:"net_amount,omitempty"`
+	FeeScheduleID     *string     `json:"fee_schedule_id,omitempty"`
+	FeeCalculatedDate *int64      `json:"fee_calculated_date,omitempty"`
+	Status            string      `json:"status"`
+	Description       string      `json:"description,omitempty"`
+	RedirectURL       string      `json:"redirect_url,omitempty"`
+	ExpiredAt         *time.Time  `json:"expired_at,omitempty"`
+	CreatedDate       *int64      `json:"created_date"`
+	UpdatedDate       *int64      `json:"updated_date"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ReconciliationRunResponse counts the items of a reconciliation run by
+// result.
+type ReconciliationRunResponse struct {
+	ID                string     `json:"id"`
+	FileName          string     `json:"file_name"`
+	Status            string     `json:"status"`
+	Mapping           string     `json:"mapping"`
+	PeriodFrom        *time.Time `json:"period_from,omitempty"`
+	PeriodTo          *time.Time `json:"period_to,omitempty"`
+	TotalRows         int        `json:"total_rows"`
+	Matched           int        `json:"matched"`
+	Mismatched        int        `json:"mismatched"`
+	MissingPayment    int        `json:"missing_payment"`
+	MissingSettlement int        `json:"missing_settlement"`
+	Invalid           int        `json:"invalid"`
+	Error             *string    `json:"error,omitempty"`
+	FinishedDate      *int64     `json:"finished_date"`
+	CreatedDate       *int64     `json:"created_date"`
+}
+
+// ReconciliationItemResponse shows a reconciled row with its amounts in
+// major units of the currency.
+type ReconciliationItemResponse struct {
+	LineNo           *int        `json:"line_no"`
+	TransactionID    *string     `json:"transaction_id"`
+	ReferenceNo      *string     `json:"reference_no"`
+	PaymentID        *string     `json:"payment_id"`
+	Currency         *string     `json:"currency"`
+	SettlementAmount json.Number `json:"settlement_amount,omitempty"`
+	PaymentAmount    json.Number `json:"payment_amount,omitempty"`
+	SettlementStatus *string     `json:"settlement_status"`
+	PaymentStatus    *string     `json:"payment_status"`
+	Result           string      `json:"result"`
+	Mismatches       []string    `json:"mismatches,omitempty"`
+	Detail           *string     `json:"detail"`
+}
+
+// ReconciliationReportResponse is a reconciliation run with a page of its
+// items.
+type ReconciliationReportResponse struct {
+	Run   ReconciliationRunResponse    `json:"run"`
+	Items []ReconciliationItemResponse `json:"items"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "encoding/json"
+
+// RefundRequest reports a refund Nicepay completed. Amount is in major units
+// of the payment currency; reporting the same RefundID again has no effect.
+type RefundRequest struct {
+	RefundID string      `json:"refund_id" validate:"required,max=100"`
+	Amount   json.Number `json:"amount" validate:"required,amount"`
+}
+
+func (r RefundRequest) Validate() error {
+	return validateStruct(r)
+}
+
+// RefundResponse shows a completed refund of a payment.
+type RefundResponse struct {
+	TransactionID string      `json:"transaction_id"`
+	RefundID      string      `json:"refund_id"`
+	Amount        json.Number `json:"amount"`
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScheduleOptions delays an async payment job. They are read from the same
+// body as CreatePaymentRequest; run_at wins over delay.
+type ScheduleOptions struct {
+	RunAt *time.Time `json:"run_at"` // RFC 3339, e.g. 2026-01-01T00:00:00+07:00
+	Delay string     `json:"delay"`  // Go duration, e.g. 30m or 24h
+}
+
+// ResolveRunAt returns when the job should run, or the zero time to run it now.
+func (o ScheduleOptions) ResolveRunAt(now time.Time, maxHorizon time.Duration) (time.Time, error) {
+	var runAt time.Time
+	switch {
+	case o.RunAt != nil:
+		runAt = *o.RunAt
+	case o.Delay != "":
+		delay, err := time.ParseDuration(o.Delay)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid delay: %w", err)
+		}
+		if delay < 0 {
+			return time.Time{}, errors.New("delay must not be negative")
+		}
+		runAt = now.Add(delay)
+	default:
+		return time.Time{}, nil
+	}
+
+	if !runAt.After(now) {
+		return time.Time{}, nil
+	}
+	if maxHorizon > 0 && runAt.Sub(now) > maxHorizon {
+		return time.Time{}, fmt.Errorf("run_at must be within %s", maxHorizon)
+	}
+	return runAt, nil
+}
@@ -0,0 +1,24 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+// StatementRequest asks for a merchant statement of the payments, fees and
+// refunds posted in [From, To), RFC 3339. Format is csv (default) or xlsx.
+type StatementRequest struct {
+	From   time.Time `json:"from" validate:"required"`
+	To     time.Time `json:"to" validate:"required"`
+	Format string    `json:"format" validate:"omitempty,oneof=csv xlsx CSV XLSX"`
+}
+
+func (r StatementRequest) Validate() error {
+	if err := validateStruct(r); err != nil {
+		return err
+	}
+	if !r.From.Before(r.To) {
+		return errors.New("from must come before to")
+	}
+	return nil
+}
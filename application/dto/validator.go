@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"worker-nicepay/domain/money"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// amount is a positive decimal; its decimals are checked against the currency later
+	v.RegisterValidation("amount", func(fl validator.FieldLevel) bool {
+		amount, err := money.ParseDecimal(fl.Field().String())
+		return err == nil && amount.Sign() > 0
+	})
+	v.RegisterValidation("percentage", func(fl validator.FieldLevel) bool {
+		percent, err := money.ParseDecimal(fl.Field().String())
+		return err == nil && percent.Sign() >= 0 && percent.Cmp(big.NewRat(100, 1)) <= 0
+	})
+	return v
+}
+
+// Validate checks the `validate` tags of the request and returns one message
+// per failing field.
+func (r CreatePaymentRequest) Validate() error {
+	return validateStruct(r)
+}
+
+func validateStruct(v interface{}) error {
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	msgs := make([]string, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		if fe.Param() != "" {
+			msgs = append(msgs, fmt.Sprintf("%s must satisfy %s=%s", fe.Field(), fe.Tag(), fe.Param()))
+		} else {
+			msgs = append(msgs, fmt.Sprintf("%s must satisfy %s", fe.Field(), fe.Tag()))
+		}
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SpecVersion is the CloudEvents specification version the envelope follows.
+	SpecVersion = "1.0"
+	// ContentTypeJSON is the content type of Envelope.Data.
+	ContentTypeJSON = "application/json"
+	// ContentTypeCloudEventsJSON is the content type of a whole envelope in structured mode.
+	ContentTypeCloudEventsJSON = "application/cloudevents+json"
+)
+
+// DefaultSource is written to Envelope.Source when an envelope is built.
+// main overrides it with the configured service name.
+var DefaultSource = "worker-nicepay"
+
+var ErrEventTypeMismatch = errors.New("event type mismatch")
+var ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
+
+// Data is implemented by every strongly typed event payload.
+type Data interface {
+	GetEventName() string
+	GetSchemaVersion() string
+}
+
+// Correlated is implemented by payloads that know which transaction and
+// merchant they belong to, so Wrap can fill the envelope attributes.
+type Correlated interface {
+	GetTransactionID() string
+	GetMerchantID() string
+}
+
+// Envelope is the CloudEvents-compatible wrapper around every event and
+// message published by the service. schemaversion, transactionid and
+// merchantid are CloudEvents extension attributes.
+type Envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Type            string          `json:"type"`
+	Source          string          `json:"source"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	SchemaVersion   string          `json:"schemaversion"`
+	TransactionID   string          `json:"transactionid,omitempty"`
+	MerchantID      string          `json:"merchantid,omitempty"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewEnvelope marshals data and wraps it with a fresh id and timestamp.
+func NewEnvelope(data Data, transactionID string, merchantID string) (Envelope, error) {
+	if data == nil {
+		return Envelope{}, errors.New("event data is nil")
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to generate event id: %w", err)
+	}
+
+	return Envelope{
+		SpecVersion:     SpecVersion,
+		ID:              id.String(),
+		Type:            data.GetEventName(),
+		Source:          DefaultSource,
+		Time:            time.Now().UTC(),
+		DataContentType: ContentTypeJSON,
+		SchemaVersion:   data.GetSchemaVersion(),
+		TransactionID:   transactionID,
+		MerchantID:      merchantID,
+		Data:            raw,
+	}, nil
+}
+
+// Wrap returns v as an Envelope. Envelopes are returned unchanged and typed
+// payloads are wrapped with NewEnvelope.
+func Wrap(v interface{}) (Envelope, error) {
+	switch e := v.(type) {
+	case Envelope:
+		return e, nil
+	case *Envelope:
+		if e == nil {
+			return Envelope{}, errors.New("event envelope is nil")
+		}
+		return *e, nil
+	case Data:
+		var transactionID, merchantID string
+		if c, ok := v.(Correlated); ok {
+			transactionID = c.GetTransactionID()
+			merchantID = c.GetMerchantID()
+		}
+		return NewEnvelope(e, transactionID, merchantID)
+	default:
+		return Envelope{}, fmt.Errorf("unsupported event payload %T", v)
+	}
+}
+
+func (e Envelope) GetEventName() string {
+	return e.Type
+}
+
+func (e Envelope) GetMessageName() string {
+	return e.Type
+}
+
+// Validate checks the required CloudEvents attributes.
+func (e Envelope) Validate() error {
+	switch {
+	case e.SpecVersion != SpecVersion:
+		return fmt.Errorf("unsupported specversion %q", e.SpecVersion)
+	case e.ID == "":
+		return errors.New("event id is required")
+	case e.Type == "":
+		return errors.New("event type is required")
+	case e.Source == "":
+		return errors.New("event source is required")
+	}
+	return nil
+}
+
+// Decode unmarshals Data into out. The envelope type must match out and the
+// schema major version must be the one out was written for; minor versions
+// only add fields and are accepted.
+func (e Envelope) Decode(out Data) error {
+	if e.Type != out.GetEventName() {
+		return fmt.Errorf("%w: got %s, want %s", ErrEventTypeMismatch, e.Type, out.GetEventName())
+	}
+	if majorVersion(e.SchemaVersion) != majorVersion(out.GetSchemaVersion()) {
+		return fmt.Errorf("%w: %s %s", ErrUnsupportedSchemaVersion, e.Type, e.SchemaVersion)
+	}
+	return json.Unmarshal(e.Data, out)
+}
+
+func majorVersion(v string) string {
+	major, _, _ := strings.Cut(v, ".")
+	return major
+}
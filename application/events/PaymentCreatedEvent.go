@@ -1,14 +1,43 @@
 package events
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
+
+const PaymentCreatedSchemaVersion = "1.2"
 
 type PaymentCreatedEvent struct {
-	Timestamp     time.Time              `json:"timestamp"`
-	TransactionID string                 `json:"transaction_id"`
-	Message       string                 `json:"message"`
-	Payload       map[string]interface{} `json:"payload"`
+	PaymentID     string      `json:"payment_id"`
+	TransactionID string      `json:"transaction_id"`
+	MerchantID    string      `json:"merchant_id"`
+	ReferenceNo   string      `json:"reference_no"`
+	ChannelCode   string      `json:"channel_code"`
+	Currency      string      `json:"currency"`
+	Amount        json.Number `json:"amount"`       // in major units
+	AmountMinor   int64       `json:"amount_minor"` // since 1.2
+	Status        string      `json:"status"`
+	RedirectURL   string      `json:"redirect_url,omitempty"`
+	CallbackURL   string      `json:"callback_url,omitempty"` // since 1.1
+	ExpiredAt     time.Time   `json:"expired_at"`
 }
 
 func (e PaymentCreatedEvent) GetEventName() string {
 	return PaymentCreatedEventName
 }
+
+func (e PaymentCreatedEvent) GetMessageName() string {
+	return PaymentCreatedEventName
+}
+
+func (e PaymentCreatedEvent) GetSchemaVersion() string {
+	return PaymentCreatedSchemaVersion
+}
+
+func (e PaymentCreatedEvent) GetTransactionID() string {
+	return e.TransactionID
+}
+
+func (e PaymentCreatedEvent) GetMerchantID() string {
+	return e.MerchantID
+}
@@ -1,13 +1,7 @@
 package messages
 
-import "time"
+import "worker-nicepay/application/events"
 
-type PaymentCreatedMessage struct {
-	Timestamp     time.Time `json:"timestamp"`
-	TransactionID string    `json:"transaction_id"`
-	Message       string    `json:"message"`
-}
-
-func (m PaymentCreatedMessage) GetMessageName() string {
-	return PaymentCreatedMessageName
-}
+// PaymentCreatedMessage shares its payload with the event so queue and
+// publisher consumers decode the same envelope data.
+type PaymentCreatedMessage = events.PaymentCreatedEvent
@@ -1,5 +1,7 @@
 package messages
 
+import "worker-nicepay/application/events"
+
 const (
-	PaymentCreatedMessageName = "payment.created"
+	PaymentCreatedMessageName = events.PaymentCreatedEventName
 )
@@ -7,4 +7,5 @@ import (
 
 type PaymentGateway interface {
 	RequestPaymentLink(ctx context.Context, req nicepay.RequestPaymentLinkDTO, url string) (nicepay.ResponsePaymentLinkDTO, error)
+	QueryPayment(ctx context.Context, req nicepay.RequestQueryPaymentDTO, url string) (nicepay.ResponseQueryPaymentDTO, error)
 }
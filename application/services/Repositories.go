@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+)
+
+// PaymentOutcome is the gateway outcome stored on a payment and its e-wallet
+// details. Empty fields are left unchanged.
+type PaymentOutcome struct {
+	Status               string
+	RedirectURL          string
+	NicepayTransactionID string
+	ResponseJson         json.RawMessage
+	UpdatedAt            time.Time
+	// Fees replaces the fees of the payment when set
+	Fees *PaymentFees
+}
+
+// PaymentFees is the fee breakdown of a payment in minor units of its
+// currency. ScheduleID is nil when no fee schedule applied.
+type PaymentFees struct {
+	ScheduleID   *uuid.UUID
+	Fee          int64
+	Tax          int64
+	Net          int64
+	CalculatedAt time.Time
+}
+
+type PaymentRepository interface {
+	Insert(ctx context.Context, payment *models.PaymentsDataModel) error
+	UpdateOutcome(ctx context.Context, id uuid.UUID, outcome PaymentOutcome) error
+	// ClaimUnresolved leases one UNKNOWN payment, one INITIATED payment
+	// created before staleBefore or one PENDING payment not updated since
+	// staleBefore, not attempted since attemptedBefore and not leased at now,
+	// until now+lease. UpdateOutcome ends the lease.
+	ClaimUnresolved(ctx context.Context, staleBefore int64, attemptedBefore int64, now time.Time, lease time.Duration) (*models.PaymentsDataModel, error)
+	// DailyUsage counts and sums, in minor units, the payments of a merchant's
+	// channel in a currency created since the given time that did not fail.
+	DailyUsage(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, since int64) (count int64, amount int64, err error)
+	// FindForUpdate locks the payment of a merchant transaction. It fails with
+	// a not found error when there is none.
+	FindForUpdate(ctx context.Context, transactionID string) (*models.PaymentsDataModel, error)
+}
+
+type PaymentEWalletRepository interface {
+	Insert(ctx context.Context, ewallet *models.PaymentNicepayEWalletsDataModel) error
+	UpdateOutcome(ctx context.Context, paymentID uuid.UUID, outcome PaymentOutcome) error
+}
+
+type CurrencyRepository interface {
+	FindByCode(ctx context.Context, code string) (*models.CurrenciesDataModel, error)
+	FindByID(ctx context.Context, id uuid.UUID) (*models.CurrenciesDataModel, error)
+}
+
+type CountryRepository interface {
+	FindByCountryID(ctx context.Context, countryID string) (*models.CountriesDataModel, error)
+}
+
+type PaymentMethodRepository interface {
+	FindByName(ctx context.Context, name string) (*models.PaymentMethodsDataModel, error)
+}
+
+type MerchantRepository interface {
+	FindByID(ctx context.Context, id uuid.UUID) (*models.MerchantsDataModel, error)
+}
+
+type EWalletProviderRepository interface {
+	// FindByProviderName returns nil when no provider is registered under name.
+	FindByProviderName(ctx context.Context, name string) (*models.EWalletProvidersDataModel, error)
+}
+
+type MerchantPaymentMethodRepository interface {
+	// FindForUpdate locks the merchant's configuration of a channel in a
+	// currency. It returns nil when the channel is not configured.
+	FindForUpdate(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID) (*models.MerchantPaymentMethodsDataModel, error)
+	// HasAny reports whether the merchant has any channel configured.
+	HasAny(ctx context.Context, merchantID uuid.UUID) (bool, error)
+}
+
+type FeeScheduleRepository interface {
+	// FindEffective returns the fee schedule of a merchant's channel in a
+	// currency in force at the given time, or nil when there is none.
+	FindEffective(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, at int64) (*models.FeeSchedulesDataModel, error)
+}
+
+type LedgerRepository interface {
+	// Post writes a balanced journal entry. It returns false when an entry
+	// with the same key was posted before.
+	Post(ctx context.Context, entry ledger.Entry) (bool, error)
+	// Refunds returns the refunds journaled for a payment, in minor units, by
+	// entry key.
+	Refunds(ctx context.Context, paymentID uuid.UUID) (map[string]int64, error)
+}
+
+// PaymentRepositories bundles the repositories of the payment creation flow.
+type PaymentRepositories struct {
+	Payments         PaymentRepository
+	EWallets         PaymentEWalletRepository
+	Currencies       CurrencyRepository
+	Countries        CountryRepository
+	PaymentMethods   PaymentMethodRepository
+	Merchants        MerchantRepository
+	EWalletProviders EWalletProviderRepository
+	// MerchantPaymentMethods is read inside the payment transaction and is
+	// never cached.
+	MerchantPaymentMethods MerchantPaymentMethodRepository
+	FeeSchedules           FeeScheduleRepository
+	Ledger                 LedgerRepository
+}
@@ -0,0 +1,9 @@
+package services
+
+import "context"
+
+// UnitOfWork runs fn in one transaction. Repositories called with the ctx
+// passed to fn take part in it; fn returning an error rolls everything back.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,158 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code is the machine-readable error code returned to API clients.
+type Code string
+
+const (
+	CodeInvalidRequest     Code = "INVALID_REQUEST"
+	CodeTimeout            Code = "GATEWAY_TIMEOUT"
+	CodeUpstreamError      Code = "UPSTREAM_ERROR"
+	CodeUpstreamRejected   Code = "UPSTREAM_REJECTED"
+	CodeGatewayUnavailable Code = "GATEWAY_UNAVAILABLE"
+	CodeInvalidChannel     Code = "INVALID_CHANNEL"
+	CodeMerchantNotFound   Code = "MERCHANT_NOT_FOUND"
+	CodeMasterDataMissing  Code = "MASTER_DATA_MISSING"
+	CodeChannelNotAllowed  Code = "CHANNEL_NOT_ALLOWED"
+	CodeLimitExceeded      Code = "LIMIT_EXCEEDED"
+	CodeNotFound           Code = "NOT_FOUND"
+	CodeConflict           Code = "CONFLICT"
+	CodeInternal           Code = "INTERNAL_ERROR"
+)
+
+var httpStatus = map[Code]int{
+	CodeInvalidRequest:     http.StatusBadRequest,
+	CodeTimeout:            http.StatusGatewayTimeout,
+	CodeUpstreamError:      http.StatusBadGateway,
+	CodeUpstreamRejected:   http.StatusUnprocessableEntity,
+	CodeGatewayUnavailable: http.StatusServiceUnavailable,
+	CodeInvalidChannel:     http.StatusBadRequest,
+	CodeMerchantNotFound:   http.StatusNotFound,
+	CodeMasterDataMissing:  http.StatusUnprocessableEntity,
+	CodeChannelNotAllowed:  http.StatusForbidden,
+	CodeLimitExceeded:      http.StatusUnprocessableEntity,
+	CodeNotFound:           http.StatusNotFound,
+	CodeConflict:           http.StatusConflict,
+	CodeInternal:           http.StatusInternalServerError,
+}
+
+// Sentinels for errors.Is; an *Error matches the sentinel with the same code.
+var (
+	ErrInvalidRequest     = &Error{Code: CodeInvalidRequest, Message: "invalid request"}
+	ErrTimeout            = &Error{Code: CodeTimeout, Message: "gateway timeout"}
+	ErrUpstreamError      = &Error{Code: CodeUpstreamError, Message: "upstream error"}
+	ErrUpstreamRejected   = &Error{Code: CodeUpstreamRejected, Message: "upstream rejected the request"}
+	ErrGatewayUnavailable = &Error{Code: CodeGatewayUnavailable, Message: "gateway unavailable"}
+	ErrInvalidChannel     = &Error{Code: CodeInvalidChannel, Message: "invalid channel"}
+	ErrMerchantNotFound   = &Error{Code: CodeMerchantNotFound, Message: "merchant not found"}
+	ErrMasterDataMissing  = &Error{Code: CodeMasterDataMissing, Message: "master data missing"}
+	ErrChannelNotAllowed  = &Error{Code: CodeChannelNotAllowed, Message: "channel not allowed"}
+	ErrLimitExceeded      = &Error{Code: CodeLimitExceeded, Message: "limit exceeded"}
+	ErrNotFound           = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrConflict           = &Error{Code: CodeConflict, Message: "conflict"}
+)
+
+type Error struct {
+	Code    Code
+	Message string
+	// UpstreamStatus is the HTTP status returned by the payment gateway, if any
+	UpstreamStatus int
+	Err            error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+func New(code Code, message string, cause error) *Error {
+	return &Error{Code: code, Message: message, Err: cause}
+}
+
+func InvalidRequest(cause error) *Error {
+	return New(CodeInvalidRequest, "invalid request", cause)
+}
+
+func Timeout(cause error) *Error {
+	return New(CodeTimeout, "gateway timeout", cause)
+}
+
+// Upstream classifies a gateway error response: 5xx are upstream errors, 429
+// means the gateway is throttling us, everything else rejects the request.
+func Upstream(status int, message string) *Error {
+	code := CodeUpstreamRejected
+	switch {
+	case status >= http.StatusInternalServerError:
+		code = CodeUpstreamError
+	case status == http.StatusTooManyRequests:
+		code = CodeGatewayUnavailable
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &Error{Code: code, Message: message, UpstreamStatus: status}
+}
+
+func GatewayUnavailable(cause error) *Error {
+	return New(CodeGatewayUnavailable, "gateway unavailable", cause)
+}
+
+func InvalidChannel(channel string) *Error {
+	return New(CodeInvalidChannel, fmt.Sprintf("invalid channel %q", channel), nil)
+}
+
+func MerchantNotFound(merchant string) *Error {
+	return New(CodeMerchantNotFound, fmt.Sprintf("merchant %q not found", merchant), nil)
+}
+
+func MasterDataMissing(kind string, key string) *Error {
+	return New(CodeMasterDataMissing, fmt.Sprintf("%s %q not found", kind, key), nil)
+}
+
+// ChannelNotAllowed reports a channel the merchant may not use, or not now.
+func ChannelNotAllowed(message string) *Error {
+	return New(CodeChannelNotAllowed, message, nil)
+}
+
+// LimitExceeded reports an amount outside the merchant's limits of a channel.
+func LimitExceeded(message string) *Error {
+	return New(CodeLimitExceeded, message, nil)
+}
+
+func NotFound(kind string, key string) *Error {
+	return New(CodeNotFound, fmt.Sprintf("%s %q not found", kind, key), nil)
+}
+
+func Conflict(message string, cause error) *Error {
+	return New(CodeConflict, message, cause)
+}
+
+// CodeOf returns the code of the first *Error in err's chain, or CodeInternal.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+// HTTPStatus maps err to the HTTP status returned to API clients.
+func HTTPStatus(err error) int {
+	return httpStatus[CodeOf(err)]
+}
@@ -10,6 +10,7 @@ type Incoming struct {
 	Webtype       string    `json:"webtype"`
 	Path          string    `json:"path"`
 	Merchant      string    `json:"merchant"`
+	Actor         string    `json:"actor"`
 	IP            string    `json:"ip"`
 	Method        string    `json:"method"`
 	RequestQuery  string    `json:"request_query"`
@@ -0,0 +1,163 @@
+package fee
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"worker-nicepay/domain/money"
+)
+
+type Type string
+
+const (
+	TypePercentage Type = "PERCENTAGE"
+	TypeFlat       Type = "FLAT"
+	TypeTiered     Type = "TIERED"
+)
+
+var ErrInvalidSchedule = errors.New("invalid fee schedule")
+
+// Tier prices payments up to UpTo minor units, or of any amount when UpTo is
+// nil. Percentage is a decimal percent of the gross amount, e.g. "1.5".
+type Tier struct {
+	UpTo       *int64 `json:"up_to"`
+	Percentage string `json:"percentage,omitempty"`
+	FlatAmount int64  `json:"flat_amount,omitempty"`
+}
+
+// Schedule is the fee contract of a merchant's channel. Amounts are in minor
+// units of the payment currency, percentages are decimal percents. The fee is
+// clamped to MinFee and MaxFee before TaxPercentage is charged on it.
+type Schedule struct {
+	Type          Type
+	Percentage    string
+	FlatAmount    int64
+	Tiers         []Tier // ascending by UpTo
+	MinFee        *int64
+	MaxFee        *int64
+	TaxPercentage string
+}
+
+// Breakdown splits a gross amount into the fee, the tax on the fee and the
+// net amount owed to the merchant.
+type Breakdown struct {
+	Gross money.Money
+	Fee   money.Money
+	Tax   money.Money
+	Net   money.Money
+}
+
+// None is the breakdown of a payment without a fee schedule.
+func None(gross money.Money) Breakdown {
+	zero := money.New(0, gross.Currency)
+	return Breakdown{Gross: gross, Fee: zero, Tax: zero, Net: gross}
+}
+
+func (s Schedule) Validate() error {
+	switch s.Type {
+	case TypePercentage:
+		if s.Percentage == "" {
+			return fmt.Errorf("%w: %s requires a percentage", ErrInvalidSchedule, s.Type)
+		}
+	case TypeFlat:
+	case TypeTiered:
+		if len(s.Tiers) == 0 {
+			return fmt.Errorf("%w: %s requires tiers", ErrInvalidSchedule, s.Type)
+		}
+		for i, tier := range s.Tiers {
+			last := i == len(s.Tiers)-1
+			if tier.UpTo == nil && !last {
+				return fmt.Errorf("%w: only the last tier may be unbounded", ErrInvalidSchedule)
+			}
+			if i > 0 && tier.UpTo != nil && *tier.UpTo <= *s.Tiers[i-1].UpTo {
+				return fmt.Errorf("%w: tiers must ascend", ErrInvalidSchedule)
+			}
+			if _, err := percentOf(0, tier.Percentage); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidSchedule, s.Type)
+	}
+	if s.MinFee != nil && s.MaxFee != nil && *s.MinFee > *s.MaxFee {
+		return fmt.Errorf("%w: minimum fee exceeds maximum fee", ErrInvalidSchedule)
+	}
+	if _, err := percentOf(0, s.Percentage); err != nil {
+		return err
+	}
+	_, err := percentOf(0, s.TaxPercentage)
+	return err
+}
+
+// Calculate prices gross under the schedule. Percentages round half up to
+// the minor unit.
+func (s Schedule) Calculate(gross money.Money) (Breakdown, error) {
+	if err := s.Validate(); err != nil {
+		return Breakdown{}, err
+	}
+
+	percentage, flat := s.Percentage, s.FlatAmount
+	switch s.Type {
+	case TypeFlat:
+		percentage = ""
+	case TypeTiered:
+		tier := s.Tiers[len(s.Tiers)-1]
+		for _, t := range s.Tiers {
+			if t.UpTo == nil || gross.Minor <= *t.UpTo {
+				tier = t
+				break
+			}
+		}
+		percentage, flat = tier.Percentage, tier.FlatAmount
+	}
+
+	amount, err := percentOf(gross.Minor, percentage)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	amount += flat
+	if s.MinFee != nil && amount < *s.MinFee {
+		amount = *s.MinFee
+	}
+	if s.MaxFee != nil && amount > *s.MaxFee {
+		amount = *s.MaxFee
+	}
+	tax, err := percentOf(amount, s.TaxPercentage)
+	if err != nil {
+		return Breakdown{}, err
+	}
+
+	return Breakdown{
+		Gross: gross,
+		Fee:   money.New(amount, gross.Currency),
+		Tax:   money.New(tax, gross.Currency),
+		Net:   money.New(gross.Minor-amount-tax, gross.Currency),
+	}, nil
+}
+
+var hundred = big.NewRat(100, 1)
+
+// percentOf returns percent of minor, rounded half up. An empty percent is 0.
+func percentOf(minor int64, percent string) (int64, error) {
+	if percent == "" {
+		return 0, nil
+	}
+	p, err := money.ParseDecimal(percent)
+	if err != nil {
+		return 0, fmt.Errorf("%w: percentage: %v", ErrInvalidSchedule, err)
+	}
+	if p.Sign() < 0 || p.Cmp(hundred) > 0 {
+		return 0, fmt.Errorf("%w: percentage %s is not within 0 and 100", ErrInvalidSchedule, percent)
+	}
+
+	r := new(big.Rat).SetInt64(minor)
+	r.Mul(r, p).Quo(r, hundred)
+	// floor(r + 1/2), amounts are not negative
+	r.Add(r, big.NewRat(1, 2))
+	rounded := new(big.Int).Quo(r.Num(), r.Denom())
+	if !rounded.IsInt64() {
+		return 0, fmt.Errorf("%w: fee of %d", money.ErrAmountOutOfRange, minor)
+	}
+	return rounded.Int64(), nil
+}
@@ -0,0 +1,120 @@
+package fee
+
+import (
+	"errors"
+	"testing"
+
+	"worker-nicepay/domain/money"
+)
+
+var idr = money.Currency{Code: "IDR", Exponent: 0}
+
+func amount(minor int64) *int64 { return &minor }
+
+func TestCalculate(t *testing.T) {
+	tiers := []Tier{
+		{UpTo: amount(100000), FlatAmount: 2000},
+		{UpTo: amount(1000000), Percentage: "1.5"},
+		{Percentage: "1", FlatAmount: 500},
+	}
+	tests := []struct {
+		name     string
+		schedule Schedule
+		gross    int64
+		// fee and tax in minor units; net is what is left of gross
+		fee, tax int64
+	}{
+		{name: "percentage", schedule: Schedule{Type: TypePercentage, Percentage: "2.5"}, gross: 200000, fee: 5000},
+		{name: "percentage with flat amount", schedule: Schedule{Type: TypePercentage, Percentage: "2.5", FlatAmount: 1000}, gross: 200000, fee: 6000},
+		{name: "percentage rounds half up", schedule: Schedule{Type: TypePercentage, Percentage: "1.5"}, gross: 100, fee: 2},
+		{name: "percentage rounds down below half", schedule: Schedule{Type: TypePercentage, Percentage: "1.4"}, gross: 100, fee: 1},
+		{name: "flat", schedule: Schedule{Type: TypeFlat, FlatAmount: 3000}, gross: 200000, fee: 3000},
+		{name: "flat ignores the percentage", schedule: Schedule{Type: TypeFlat, Percentage: "2.5", FlatAmount: 3000}, gross: 200000, fee: 3000},
+		{name: "tax on the fee", schedule: Schedule{Type: TypePercentage, Percentage: "2.5", TaxPercentage: "11"}, gross: 200000, fee: 5000, tax: 550},
+		{name: "tax rounds half up", schedule: Schedule{Type: TypeFlat, FlatAmount: 5, TaxPercentage: "10"}, gross: 1000, fee: 5, tax: 1},
+
+		{name: "first tier", schedule: Schedule{Type: TypeTiered, Tiers: tiers}, gross: 50000, fee: 2000},
+		{name: "tier bound is inclusive", schedule: Schedule{Type: TypeTiered, Tiers: tiers}, gross: 100000, fee: 2000},
+		{name: "middle tier", schedule: Schedule{Type: TypeTiered, Tiers: tiers}, gross: 100001, fee: 1500},
+		{name: "unbounded last tier", schedule: Schedule{Type: TypeTiered, Tiers: tiers}, gross: 2000000, fee: 20500},
+		{
+			name:     "above the last bounded tier",
+			schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{UpTo: amount(1000), FlatAmount: 10}, {UpTo: amount(2000), FlatAmount: 20}}},
+			gross:    5000,
+			fee:      20,
+		},
+
+		{name: "minimum fee", schedule: Schedule{Type: TypePercentage, Percentage: "1", MinFee: amount(1500)}, gross: 100000, fee: 1500},
+		{name: "above the minimum fee", schedule: Schedule{Type: TypePercentage, Percentage: "1", MinFee: amount(500)}, gross: 100000, fee: 1000},
+		{name: "maximum fee", schedule: Schedule{Type: TypePercentage, Percentage: "1", MaxFee: amount(5000)}, gross: 1000000, fee: 5000},
+		{name: "tax on the capped fee", schedule: Schedule{Type: TypePercentage, Percentage: "1", MaxFee: amount(5000), TaxPercentage: "11"}, gross: 1000000, fee: 5000, tax: 550},
+		{name: "tier fee capped", schedule: Schedule{Type: TypeTiered, Tiers: tiers, MaxFee: amount(10000)}, gross: 2000000, fee: 10000},
+		{name: "no fee", schedule: Schedule{Type: TypeFlat}, gross: 200000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := tt.schedule.Calculate(money.New(tt.gross, idr))
+			if err != nil {
+				t.Fatalf("Calculate: %v", err)
+			}
+			if b.Gross.Minor != tt.gross || b.Fee.Minor != tt.fee || b.Tax.Minor != tt.tax || b.Net.Minor != tt.gross-tt.fee-tt.tax {
+				t.Errorf("breakdown = gross %d fee %d tax %d net %d, want gross %d fee %d tax %d net %d",
+					b.Gross.Minor, b.Fee.Minor, b.Tax.Minor, b.Net.Minor, tt.gross, tt.fee, tt.tax, tt.gross-tt.fee-tt.tax)
+			}
+			for _, m := range []money.Money{b.Gross, b.Fee, b.Tax, b.Net} {
+				if m.Currency != idr {
+					t.Errorf("breakdown in %s, want IDR", m.Currency.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		valid    bool
+	}{
+		{name: "percentage", schedule: Schedule{Type: TypePercentage, Percentage: "2.5"}, valid: true},
+		{name: "flat", schedule: Schedule{Type: TypeFlat, FlatAmount: 1000}, valid: true},
+		{name: "tiered", schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{UpTo: amount(1000), FlatAmount: 10}, {Percentage: "1"}}}, valid: true},
+		{name: "equal minimum and maximum fee", schedule: Schedule{Type: TypeFlat, MinFee: amount(100), MaxFee: amount(100)}, valid: true},
+		{name: "percentage of 100", schedule: Schedule{Type: TypePercentage, Percentage: "100"}, valid: true},
+
+		{name: "unknown type", schedule: Schedule{Type: "DAILY"}},
+		{name: "percentage missing", schedule: Schedule{Type: TypePercentage}},
+		{name: "percentage above 100", schedule: Schedule{Type: TypePercentage, Percentage: "100.01"}},
+		{name: "negative percentage", schedule: Schedule{Type: TypePercentage, Percentage: "-1"}},
+		{name: "percentage not a number", schedule: Schedule{Type: TypePercentage, Percentage: "2,5"}},
+		{name: "tax above 100", schedule: Schedule{Type: TypeFlat, TaxPercentage: "101"}},
+		{name: "no tiers", schedule: Schedule{Type: TypeTiered}},
+		{name: "unbounded tier before the last", schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{Percentage: "1"}, {UpTo: amount(1000)}}}},
+		{name: "tiers descend", schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{UpTo: amount(2000)}, {UpTo: amount(1000)}}}},
+		{name: "tiers repeat a bound", schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{UpTo: amount(1000)}, {UpTo: amount(1000)}}}},
+		{name: "tier percentage above 100", schedule: Schedule{Type: TypeTiered, Tiers: []Tier{{Percentage: "150"}}}},
+		{name: "minimum fee above maximum", schedule: Schedule{Type: TypeFlat, MinFee: amount(200), MaxFee: amount(100)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidSchedule) {
+				t.Fatalf("Validate error = %v, want %v", err, ErrInvalidSchedule)
+			}
+			// an invalid schedule prices nothing
+			if _, err := tt.schedule.Calculate(money.New(1000, idr)); !tt.valid && err == nil {
+				t.Error("Calculate priced a payment under an invalid schedule")
+			}
+		})
+	}
+}
+
+func TestNone(t *testing.T) {
+	b := None(money.New(150000, idr))
+	if b.Fee.Minor != 0 || b.Tax.Minor != 0 || b.Net.Minor != 150000 || b.Net.Currency != idr {
+		t.Errorf("None = %+v, want the gross amount as net", b)
+	}
+}
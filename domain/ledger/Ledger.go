@@ -0,0 +1,233 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"worker-nicepay/domain/money"
+
+	"github.com/google/uuid"
+)
+
+var ErrUnbalanced = errors.New("unbalanced journal entry")
+
+type AccountType string
+
+const (
+	// MerchantPayable is what the gateway owes a merchant for its payments.
+	MerchantPayable AccountType = "MERCHANT_PAYABLE"
+	// GatewayReceivable is what Nicepay owes the gateway for collected payments.
+	GatewayReceivable AccountType = "GATEWAY_RECEIVABLE"
+	FeeRevenue        AccountType = "FEE_REVENUE"
+	// TaxPayable is the tax charged on fees, owed to the tax authority.
+	TaxPayable AccountType = "TAX_PAYABLE"
+)
+
+type Direction string
+
+const (
+	Debit  Direction = "DEBIT"
+	Credit Direction = "CREDIT"
+)
+
+// NormalBalance is the side that increases accounts of the type.
+func (t AccountType) NormalBalance() Direction {
+	if t == GatewayReceivable {
+		return Debit
+	}
+	return Credit
+}
+
+// Account is a ledger account in one currency. Merchant payables are kept
+// per merchant, the other accounts per currency only.
+type Account struct {
+	Type       AccountType
+	MerchantID *uuid.UUID
+	Currency   string
+}
+
+// Code identifies the account, e.g. MERCHANT_PAYABLE:<merchant id>:IDR.
+func (a Account) Code() string {
+	parts := []string{string(a.Type)}
+	if a.MerchantID != nil {
+		parts = append(parts, a.MerchantID.String())
+	}
+	return strings.Join(append(parts, a.Currency), ":")
+}
+
+type Line struct {
+	Account   Account
+	Direction Direction
+	Amount    int64 // in minor units of the entry currency
+}
+
+type EntryType string
+
+const (
+	PaymentCaptured EntryType = "PAYMENT_CAPTURED"
+	FeeAssessed     EntryType = "FEE_ASSESSED"
+	RefundCompleted EntryType = "REFUND_COMPLETED"
+)
+
+// Entry is an immutable journal entry. Key makes posting idempotent: an
+// entry whose key is already in the ledger is not posted again.
+type Entry struct {
+	Type        EntryType
+	Key         string
+	PaymentID   uuid.UUID
+	Currency    string
+	Description string
+	PostedAt    time.Time
+	Lines       []Line
+}
+
+// Validate checks that the entry has positive lines in its currency whose
+// debits equal its credits.
+func (e Entry) Validate() error {
+	if len(e.Lines) < 2 {
+		return fmt.Errorf("%w: %s needs at least two lines", ErrUnbalanced, e.Key)
+	}
+	var debit, credit int64
+	for _, line := range e.Lines {
+		if line.Amount <= 0 {
+			return fmt.Errorf("%w: %s has a line of %d", ErrUnbalanced, e.Key, line.Amount)
+		}
+		if line.Account.Currency != e.Currency {
+			return fmt.Errorf("%w: %s mixes %s into %s", ErrUnbalanced, e.Key, line.Account.Currency, e.Currency)
+		}
+		switch line.Direction {
+		case Debit:
+			debit += line.Amount
+		case Credit:
+			credit += line.Amount
+		default:
+			return fmt.Errorf("%w: %s has direction %q", ErrUnbalanced, e.Key, line.Direction)
+		}
+	}
+	if debit != credit {
+		return fmt.Errorf("%w: %s debits %d, credits %d", ErrUnbalanced, e.Key, debit, credit)
+	}
+	return nil
+}
+
+// CapturePayment records a paid payment: Nicepay owes the gross amount, which
+// is owed on to the merchant.
+func CapturePayment(paymentID uuid.UUID, merchantID uuid.UUID, gross money.Money, at time.Time) Entry {
+	code := gross.Currency.Code
+	return Entry{
+		Type:        PaymentCaptured,
+		Key:         "payment:" + paymentID.String() + ":captured",
+		PaymentID:   paymentID,
+		Currency:    code,
+		Description: "payment captured",
+		PostedAt:    at,
+		Lines: []Line{
+			{Account: Account{Type: GatewayReceivable, Currency: code}, Direction: Debit, Amount: gross.Minor},
+			{Account: Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: code}, Direction: Credit, Amount: gross.Minor},
+		},
+	}
+}
+
+// AssessFee charges the fee of a payment and the tax on it to the merchant.
+// It returns false when there is nothing to charge.
+func AssessFee(paymentID uuid.UUID, merchantID uuid.UUID, fee money.Money, tax money.Money, at time.Time) (Entry, bool) {
+	if fee.Minor+tax.Minor <= 0 {
+		return Entry{}, false
+	}
+	code := fee.Currency.Code
+	entry := Entry{
+		Type:        FeeAssessed,
+		Key:         "payment:" + paymentID.String() + ":fee",
+		PaymentID:   paymentID,
+		Currency:    code,
+		Description: "fee assessed",
+		PostedAt:    at,
+		Lines: []Line{
+			{Account: Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: code}, Direction: Debit, Amount: fee.Minor + tax.Minor},
+		},
+	}
+	if fee.Minor > 0 {
+		entry.Lines = append(entry.Lines, Line{Account: Account{Type: FeeRevenue, Currency: code}, Direction: Credit, Amount: fee.Minor})
+	}
+	if tax.Minor > 0 {
+		entry.Lines = append(entry.Lines, Line{Account: Account{Type: TaxPayable, Currency: code}, Direction: Credit, Amount: tax.Minor})
+	}
+	return entry, true
+}
+
+// CompleteRefund returns amount of a payment to its payer: the merchant owes
+// it back and Nicepay settles that much less. Fees are not refunded.
+func CompleteRefund(paymentID uuid.UUID, merchantID uuid.UUID, refundID string, amount money.Money, at time.Time) Entry {
+	code := amount.Currency.Code
+	return Entry{
+		Type:        RefundCompleted,
+		Key:         RefundKey(paymentID, refundID),
+		PaymentID:   paymentID,
+		Currency:    code,
+		Description: "refund " + refundID + " completed",
+		PostedAt:    at,
+		Lines: []Line{
+			{Account: Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: code}, Direction: Debit, Amount: amount.Minor},
+			{Account: Account{Type: GatewayReceivable, Currency: code}, Direction: Credit, Amount: amount.Minor},
+		},
+	}
+}
+
+// RefundKey is the key of the entry completing a refund of a payment.
+func RefundKey(paymentID uuid.UUID, refundID string) string {
+	return "payment:" + paymentID.String() + ":refund:" + refundID
+}
+
+// Balance is the balance of an account on its normal side, in minor units.
+type Balance struct {
+	Account Account
+	Debit   int64
+	Credit  int64
+	Balance int64
+}
+
+// NewBalance nets debit and credit totals on the normal side of the account.
+func NewBalance(account Account, debit int64, credit int64) Balance {
+	balance := credit - debit
+	if account.Type.NormalBalance() == Debit {
+		balance = debit - credit
+	}
+	return Balance{Account: account, Debit: debit, Credit: credit, Balance: balance}
+}
+
+// UnbalancedEntry is a journal entry whose debits differ from its credits.
+type UnbalancedEntry struct {
+	EntryID uuid.UUID `json:"entry_id"`
+	Key     string    `json:"key"`
+	Debit   int64     `json:"debit"`
+	Credit  int64     `json:"credit"`
+}
+
+// CurrencyTotal sums all journal lines in one currency.
+type CurrencyTotal struct {
+	Currency string `json:"currency"`
+	Debit    int64  `json:"debit"`
+	Credit   int64  `json:"credit"`
+}
+
+// CheckReport is the outcome of verifying that debits equal credits, entry
+// by entry and in total per currency.
+type CheckReport struct {
+	CheckedAt  time.Time         `json:"checked_at"`
+	Entries    int64             `json:"entries"`
+	Unbalanced []UnbalancedEntry `json:"unbalanced"`
+	Totals     []CurrencyTotal   `json:"totals"`
+	Balanced   bool              `json:"balanced"`
+}
+
+// Verify sets Balanced from the unbalanced entries and the totals.
+func (r *CheckReport) Verify() {
+	r.Balanced = len(r.Unbalanced) == 0
+	for _, total := range r.Totals {
+		if total.Debit != total.Credit {
+			r.Balanced = false
+		}
+	}
+}
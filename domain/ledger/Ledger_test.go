@@ -0,0 +1,143 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"worker-nicepay/domain/money"
+
+	"github.com/google/uuid"
+)
+
+var idr = money.Currency{Code: "IDR", Exponent: 0}
+
+func TestValidate(t *testing.T) {
+	merchantID := uuid.New()
+	payable := Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: "IDR"}
+	receivable := Account{Type: GatewayReceivable, Currency: "IDR"}
+	revenue := Account{Type: FeeRevenue, Currency: "IDR"}
+	tests := []struct {
+		name  string
+		lines []Line
+		valid bool
+	}{
+		{
+			name:  "balanced",
+			lines: []Line{{receivable, Debit, 150000}, {payable, Credit, 150000}},
+			valid: true,
+		},
+		{
+			name:  "one debit against two credits",
+			lines: []Line{{payable, Debit, 5550}, {revenue, Credit, 5000}, {Account{Type: TaxPayable, Currency: "IDR"}, Credit, 550}},
+			valid: true,
+		},
+		{name: "no lines"},
+		{name: "one line", lines: []Line{{receivable, Debit, 150000}}},
+		{name: "debits exceed credits", lines: []Line{{receivable, Debit, 150001}, {payable, Credit, 150000}}},
+		{name: "credits only", lines: []Line{{receivable, Credit, 150000}, {payable, Credit, 150000}}},
+		{name: "zero line", lines: []Line{{receivable, Debit, 0}, {payable, Credit, 0}}},
+		{name: "negative line", lines: []Line{{receivable, Debit, -100}, {payable, Credit, -100}}},
+		{
+			name:  "line in another currency",
+			lines: []Line{{receivable, Debit, 100}, {Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: "USD"}, Credit, 100}},
+		},
+		{name: "unknown direction", lines: []Line{{receivable, Debit, 100}, {payable, "BOTH", 100}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Entry{Key: "test", Currency: "IDR", Lines: tt.lines}.Validate()
+			if tt.valid && err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrUnbalanced) {
+				t.Fatalf("Validate error = %v, want %v", err, ErrUnbalanced)
+			}
+		})
+	}
+}
+
+// Every entry the ledger builds is balanced.
+func TestEntriesBalance(t *testing.T) {
+	paymentID, merchantID, at := uuid.New(), uuid.New(), time.Now()
+	fee, _ := AssessFee(paymentID, merchantID, money.New(5000, idr), money.New(550, idr), at)
+	untaxed, _ := AssessFee(paymentID, merchantID, money.New(5000, idr), money.New(0, idr), at)
+	entries := map[string]Entry{
+		"capture":       CapturePayment(paymentID, merchantID, money.New(150000, idr), at),
+		"fee":           fee,
+		"untaxed fee":   untaxed,
+		"refund":        CompleteRefund(paymentID, merchantID, "R1", money.New(40000, idr), at),
+		"second refund": CompleteRefund(paymentID, merchantID, "R2", money.New(1, idr), at),
+	}
+	keys := map[string]bool{}
+	for name, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if entry.PaymentID != paymentID || entry.Currency != "IDR" {
+			t.Errorf("%s is for payment %s in %s", name, entry.PaymentID, entry.Currency)
+		}
+		keys[entry.Key] = true
+	}
+	// fee entries of one payment share a key, so it is charged once
+	if len(keys) != len(entries)-1 {
+		t.Errorf("%d keys for %d entries: %v", len(keys), len(entries), keys)
+	}
+}
+
+func TestAssessFeeWithoutFee(t *testing.T) {
+	if _, ok := AssessFee(uuid.New(), uuid.New(), money.New(0, idr), money.New(0, idr), time.Now()); ok {
+		t.Error("AssessFee posted an entry of no fee")
+	}
+}
+
+func TestNewBalance(t *testing.T) {
+	merchantID := uuid.New()
+	tests := []struct {
+		account       Account
+		debit, credit int64
+		balance       int64
+	}{
+		{Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: "IDR"}, 5550, 150000, 144450},
+		{Account{Type: FeeRevenue, Currency: "IDR"}, 0, 5000, 5000},
+		{Account{Type: TaxPayable, Currency: "IDR"}, 0, 550, 550},
+		{Account{Type: GatewayReceivable, Currency: "IDR"}, 150000, 40000, 110000},
+		// a merchant refunded more than it was paid owes the difference
+		{Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: "IDR"}, 150000, 100000, -50000},
+	}
+	for _, tt := range tests {
+		b := NewBalance(tt.account, tt.debit, tt.credit)
+		if b.Balance != tt.balance || b.Debit != tt.debit || b.Credit != tt.credit || b.Account != tt.account {
+			t.Errorf("NewBalance(%s, %d, %d) = %+v, want balance %d", tt.account.Code(), tt.debit, tt.credit, b, tt.balance)
+		}
+	}
+}
+
+func TestAccountCode(t *testing.T) {
+	merchantID := uuid.MustParse("0192a5c4-7b3e-7c1d-9f00-1a2b3c4d5e6f")
+	if code := (Account{Type: MerchantPayable, MerchantID: &merchantID, Currency: "IDR"}).Code(); code != "MERCHANT_PAYABLE:0192a5c4-7b3e-7c1d-9f00-1a2b3c4d5e6f:IDR" {
+		t.Errorf("merchant account code = %s", code)
+	}
+	if code := (Account{Type: FeeRevenue, Currency: "IDR"}).Code(); code != "FEE_REVENUE:IDR" {
+		t.Errorf("fee account code = %s", code)
+	}
+}
+
+func TestCheckReportVerify(t *testing.T) {
+	tests := []struct {
+		name     string
+		report   CheckReport
+		balanced bool
+	}{
+		{name: "empty", balanced: true},
+		{name: "balanced totals", report: CheckReport{Totals: []CurrencyTotal{{"IDR", 100, 100}, {"USD", 5, 5}}}, balanced: true},
+		{name: "unbalanced entry", report: CheckReport{Unbalanced: []UnbalancedEntry{{Key: "k", Debit: 1}}}},
+		{name: "unbalanced currency", report: CheckReport{Totals: []CurrencyTotal{{"IDR", 100, 100}, {"USD", 5, 4}}}},
+	}
+	for _, tt := range tests {
+		tt.report.Verify()
+		if tt.report.Balanced != tt.balanced {
+			t.Errorf("%s: balanced = %v, want %v", tt.name, tt.report.Balanced, tt.balanced)
+		}
+	}
+}
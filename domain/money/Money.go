@@ -0,0 +1,91 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrTooManyDecimals  = errors.New("too many decimals for currency")
+	ErrAmountOutOfRange = errors.New("amount out of range")
+)
+
+// decimalPattern is the JSON number syntax; big.Rat alone would also accept
+// fractions such as "1/2", hexadecimal and unbounded exponents.
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d{1,2})?$`)
+
+// Currency is an ISO 4217 currency with the number of digits of its minor
+// unit, e.g. 2 for USD and 0 for JPY.
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+// Money is an amount in the minor units of its currency, e.g. 1050 for
+// USD 10.50. Amounts never pass through floating point.
+type Money struct {
+	Minor    int64
+	Currency Currency
+}
+
+func New(minor int64, currency Currency) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ParseDecimal parses a decimal amount such as "10000", "10.50" or "1e3".
+func ParseDecimal(amount string) (*big.Rat, error) {
+	amount = strings.TrimSpace(amount)
+	if !decimalPattern.MatchString(amount) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	return r, nil
+}
+
+// Parse converts a decimal amount in major units to Money. It fails when the
+// amount has more decimals than the currency allows.
+func Parse(amount string, currency Currency) (Money, error) {
+	r, err := ParseDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(currency.Exponent)), nil)
+	r.Mul(r, new(big.Rat).SetInt(scale))
+	if !r.IsInt() {
+		return Money{}, fmt.Errorf("%w: %s allows %d decimals, got %s", ErrTooManyDecimals, currency.Code, currency.Exponent, amount)
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, fmt.Errorf("%w: %s", ErrAmountOutOfRange, amount)
+	}
+	return New(r.Num().Int64(), currency), nil
+}
+
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// String formats the amount in major units with all minor digits, e.g.
+// "10.50" for 1050 USD cents.
+func (m Money) String() string {
+	if m.Currency.Exponent <= 0 {
+		return strconv.FormatInt(m.Minor, 10)
+	}
+	sign := ""
+	digits := strconv.FormatInt(m.Minor, 10)
+	if m.Minor < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if pad := m.Currency.Exponent + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	split := len(digits) - m.Currency.Exponent
+	return sign + digits[:split] + "." + digits[split:]
+}
@@ -0,0 +1,98 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	idr = Currency{Code: "IDR", Exponent: 0}
+	usd = Currency{Code: "USD", Exponent: 2}
+	kwd = Currency{Code: "KWD", Exponent: 3}
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency Currency
+		minor    int64
+		err      error
+	}{
+		{amount: "150000", currency: idr, minor: 150000},
+		{amount: "150000.00", currency: idr, minor: 150000},
+		{amount: " 150000 ", currency: idr, minor: 150000},
+		{amount: "1.5e5", currency: idr, minor: 150000},
+		{amount: "10.50", currency: usd, minor: 1050},
+		{amount: "10.5", currency: usd, minor: 1050},
+		{amount: "10", currency: usd, minor: 1000},
+		{amount: "0.01", currency: usd, minor: 1},
+		{amount: "1e-2", currency: usd, minor: 1},
+		{amount: "-10.50", currency: usd, minor: -1050},
+		{amount: "1.234", currency: kwd, minor: 1234},
+		{amount: "9223372036854775807", currency: idr, minor: 9223372036854775807},
+
+		// amounts are never rounded to the currency
+		{amount: "100.5", currency: idr, err: ErrTooManyDecimals},
+		{amount: "10.505", currency: usd, err: ErrTooManyDecimals},
+		{amount: "10.001", currency: usd, err: ErrTooManyDecimals},
+		{amount: "1.2345", currency: kwd, err: ErrTooManyDecimals},
+		{amount: "1e-1", currency: idr, err: ErrTooManyDecimals},
+
+		{amount: "9223372036854775808", currency: idr, err: ErrAmountOutOfRange},
+		{amount: "92233720368547758.08", currency: usd, err: ErrAmountOutOfRange},
+
+		{amount: "", currency: idr, err: ErrInvalidAmount},
+		{amount: "abc", currency: idr, err: ErrInvalidAmount},
+		{amount: "1/2", currency: usd, err: ErrInvalidAmount},
+		{amount: "0x10", currency: idr, err: ErrInvalidAmount},
+		{amount: "1e100", currency: idr, err: ErrInvalidAmount},
+		{amount: "1,000", currency: idr, err: ErrInvalidAmount},
+		{amount: ".5", currency: usd, err: ErrInvalidAmount},
+		{amount: "+5", currency: usd, err: ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.currency.Code+" "+tt.amount, func(t *testing.T) {
+			m, err := Parse(tt.amount, tt.currency)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Parse error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (m.Minor != tt.minor || m.Currency != tt.currency) {
+				t.Errorf("Parse = %d %s, want %d %s", m.Minor, m.Currency.Code, tt.minor, tt.currency.Code)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{New(150000, idr), "150000"},
+		{New(0, idr), "0"},
+		{New(-150000, idr), "-150000"},
+		{New(1050, usd), "10.50"},
+		{New(1000, usd), "10.00"},
+		{New(5, usd), "0.05"},
+		{New(0, usd), "0.00"},
+		{New(-5, usd), "-0.05"},
+		{New(-1050, usd), "-10.50"},
+		{New(1234, kwd), "1.234"},
+		{New(7, kwd), "0.007"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%d %s String() = %q, want %q", tt.money.Minor, tt.money.Currency.Code, got, tt.want)
+		}
+	}
+}
+
+// String formats what Parse reads back to the same amount.
+func TestStringParsesBack(t *testing.T) {
+	for _, m := range []Money{New(1050, usd), New(-5, usd), New(150000, idr), New(7, kwd)} {
+		back, err := Parse(m.String(), m.Currency)
+		if err != nil || back != m {
+			t.Errorf("Parse(%q) = %v, %v, want %v", m.String(), back, err, m)
+		}
+	}
+}
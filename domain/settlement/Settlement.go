@@ -0,0 +1,174 @@
+package settlement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidMapping = errors.New("invalid settlement column mapping")
+
+// Field is a settlement value the reconciliation reads from a file column.
+type Field string
+
+const (
+	FieldTransactionID Field = "transaction_id"
+	FieldReferenceNo   Field = "reference_no"
+	FieldAmount        Field = "amount" // gross, in major units
+	FieldCurrency      Field = "currency"
+	FieldStatus        Field = "status"
+)
+
+var fields = []Field{FieldTransactionID, FieldReferenceNo, FieldAmount, FieldCurrency, FieldStatus}
+
+// Mapping names the file column of each field. Header names are matched
+// case-insensitively.
+type Mapping map[Field]string
+
+// DefaultMapping expects columns named like the fields.
+func DefaultMapping() Mapping {
+	mapping := Mapping{}
+	for _, field := range fields {
+		mapping[field] = string(field)
+	}
+	return mapping
+}
+
+// ParseMapping reads field:Column pairs such as
+// "transaction_id:TrxID,amount:Settled Amount" over the default mapping.
+func ParseMapping(s string) (Mapping, error) {
+	mapping := DefaultMapping()
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, column, ok := strings.Cut(pair, ":")
+		field, column = strings.TrimSpace(field), strings.TrimSpace(column)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("%w: %q is not field:column", ErrInvalidMapping, pair)
+		}
+		if _, known := mapping[Field(field)]; !known {
+			return nil, fmt.Errorf("%w: unknown field %q", ErrInvalidMapping, field)
+		}
+		mapping[Field(field)] = column
+	}
+	return mapping, nil
+}
+
+// String formats the mapping as ParseMapping reads it.
+func (m Mapping) String() string {
+	pairs := make([]string, 0, len(m))
+	for field, column := range m {
+		pairs = append(pairs, string(field)+":"+column)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Row is a settlement file record; Line is its line number in the file.
+type Row struct {
+	Line          int
+	TransactionID string
+	ReferenceNo   string
+	Amount        string
+	Currency      string
+	Status        string
+}
+
+// Reader streams the rows of a settlement CSV file.
+type Reader struct {
+	csv     *csv.Reader
+	columns map[Field]int
+}
+
+// NewReader reads the header of the file. The amount column and a
+// transaction ID or reference number column are required, the others are
+// read when present.
+func NewReader(r io.Reader, mapping Mapping, comma rune) (*Reader, error) {
+	reader := csv.NewReader(r)
+	if comma != 0 {
+		reader.Comma = comma
+	}
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settlement header: %w", err)
+	}
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	columns := map[Field]int{}
+	for field, column := range mapping {
+		if i, ok := positions[strings.ToLower(column)]; ok {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns[FieldAmount]; !ok {
+		return nil, fmt.Errorf("%w: no %q column for %s", ErrInvalidMapping, mapping[FieldAmount], FieldAmount)
+	}
+	_, byTransaction := columns[FieldTransactionID]
+	_, byReference := columns[FieldReferenceNo]
+	if !byTransaction && !byReference {
+		return nil, fmt.Errorf("%w: no %q or %q column to match payments", ErrInvalidMapping, mapping[FieldTransactionID], mapping[FieldReferenceNo])
+	}
+	return &Reader{csv: reader, columns: columns}, nil
+}
+
+// Read returns the next row, or io.EOF after the last one.
+func (r *Reader) Read() (Row, error) {
+	record, err := r.csv.Read()
+	if err != nil {
+		return Row{}, err
+	}
+	line, _ := r.csv.FieldPos(0)
+	value := func(field Field) string {
+		i, ok := r.columns[field]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	return Row{
+		Line:          line,
+		TransactionID: value(FieldTransactionID),
+		ReferenceNo:   value(FieldReferenceNo),
+		Amount:        value(FieldAmount),
+		Currency:      strings.ToUpper(value(FieldCurrency)),
+		Status:        value(FieldStatus),
+	}, nil
+}
+
+// Result classifies a reconciliation item.
+type Result string
+
+const (
+	Matched    Result = "MATCHED"
+	Mismatched Result = "MISMATCHED"
+	// MissingPayment is a settled row without a payment on our side.
+	MissingPayment Result = "MISSING_PAYMENT"
+	// MissingSettlement is a paid payment of the period absent from the file.
+	MissingSettlement Result = "MISSING_SETTLEMENT"
+	// Invalid is a row that cannot be reconciled, e.g. an unreadable amount
+	// or a duplicate of an earlier row.
+	Invalid Result = "INVALID"
+)
+
+// Mismatch is a disagreement between a row and its payment.
+type Mismatch string
+
+const (
+	AmountMismatch   Mismatch = "AMOUNT"
+	CurrencyMismatch Mismatch = "CURRENCY"
+	StatusMismatch   Mismatch = "STATUS"
+)
@@ -0,0 +1,160 @@
+package settlement
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping Mapping
+		comma   rune
+		rows    []Row
+	}{
+		{
+			name: "default columns",
+			file: "transaction_id,reference_no,amount,currency,status\nNP-1,INV-1,150000,IDR,SETTLED\nNP-2,INV-2,99000,IDR,SETTLED\n",
+			rows: []Row{
+				{Line: 2, TransactionID: "NP-1", ReferenceNo: "INV-1", Amount: "150000", Currency: "IDR", Status: "SETTLED"},
+				{Line: 3, TransactionID: "NP-2", ReferenceNo: "INV-2", Amount: "99000", Currency: "IDR", Status: "SETTLED"},
+			},
+		},
+		{
+			name: "columns in any order and case",
+			file: "Amount,Reference_No,Currency\n150000,INV-1,idr\n",
+			rows: []Row{{Line: 2, ReferenceNo: "INV-1", Amount: "150000", Currency: "IDR"}},
+		},
+		{
+			name: "byte order mark and spaces",
+			file: "\ufefftransaction_id, amount \n NP-1 , 150000 \n",
+			rows: []Row{{Line: 2, TransactionID: "NP-1", Amount: "150000"}},
+		},
+		{
+			name:    "mapped columns",
+			file:    "TrxID,Settled Amount,Ccy\nNP-1,150000,IDR\n",
+			mapping: Mapping{FieldTransactionID: "trxid", FieldAmount: "Settled Amount", FieldCurrency: "CCY"},
+			rows:    []Row{{Line: 2, TransactionID: "NP-1", Amount: "150000", Currency: "IDR"}},
+		},
+		{
+			name:  "semicolons",
+			file:  "transaction_id;amount\nNP-1;150000,50\n",
+			comma: ';',
+			rows:  []Row{{Line: 2, TransactionID: "NP-1", Amount: "150000,50"}},
+		},
+		{
+			name: "short and quoted records",
+			file: "transaction_id,amount,status\nNP-1,150000\n\"NP-2\",\"1,000\",SETTLED\n",
+			rows: []Row{
+				{Line: 2, TransactionID: "NP-1", Amount: "150000"},
+				{Line: 3, TransactionID: "NP-2", Amount: "1,000", Status: "SETTLED"},
+			},
+		},
+		{
+			name: "blank lines are skipped",
+			file: "transaction_id,amount\n\nNP-1,150000\n\nNP-2,99000",
+			rows: []Row{
+				{Line: 3, TransactionID: "NP-1", Amount: "150000"},
+				{Line: 5, TransactionID: "NP-2", Amount: "99000"},
+			},
+		},
+		{
+			name: "header only",
+			file: "transaction_id,amount\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := tt.mapping
+			if mapping == nil {
+				mapping = DefaultMapping()
+			}
+			r, err := NewReader(strings.NewReader(tt.file), mapping, tt.comma)
+			if err != nil {
+				t.Fatalf("NewReader: %v", err)
+			}
+			var rows []Row
+			for {
+				row, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read: %v", err)
+				}
+				rows = append(rows, row)
+			}
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %+v, want %+v", rows, tt.rows)
+			}
+		})
+	}
+}
+
+func TestNewReaderRejectsHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping Mapping
+		err     error
+	}{
+		{name: "no amount", file: "transaction_id,reference_no\n", err: ErrInvalidMapping},
+		{name: "nothing to match payments on", file: "amount,currency\n", err: ErrInvalidMapping},
+		{name: "mapped column absent", file: "transaction_id,amount\n", mapping: Mapping{FieldTransactionID: "transaction_id", FieldAmount: "Settled Amount"}, err: ErrInvalidMapping},
+		{name: "empty file", file: "", err: io.EOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping := tt.mapping
+			if mapping == nil {
+				mapping = DefaultMapping()
+			}
+			if _, err := NewReader(strings.NewReader(tt.file), mapping, 0); !errors.Is(err, tt.err) {
+				t.Errorf("NewReader error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseMapping(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Mapping
+		err  bool
+	}{
+		{s: "", want: DefaultMapping()},
+		{
+			s: "transaction_id:TrxID, amount : Settled Amount,",
+			want: Mapping{
+				FieldTransactionID: "TrxID",
+				FieldReferenceNo:   "reference_no",
+				FieldAmount:        "Settled Amount",
+				FieldCurrency:      "currency",
+				FieldStatus:        "status",
+			},
+		},
+		{s: "amount", err: true},
+		{s: "amount:", err: true},
+		{s: "fee:Fee", err: true},
+	}
+	for _, tt := range tests {
+		mapping, err := ParseMapping(tt.s)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidMapping) {
+				t.Errorf("ParseMapping(%q) error = %v, want %v", tt.s, err, ErrInvalidMapping)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(mapping, tt.want) {
+			t.Errorf("ParseMapping(%q) = %v, %v, want %v", tt.s, mapping, err, tt.want)
+		}
+		// String formats what ParseMapping reads back
+		if back, err := ParseMapping(mapping.String()); err != nil || !reflect.DeepEqual(back, mapping) {
+			t.Errorf("ParseMapping(%q) = %v, %v, want %v", mapping.String(), back, err, mapping)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter writes the lines, then a blank record and the summary table.
+type csvWriter struct {
+	out    *csv.Writer
+	header bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{out: csv.NewWriter(w)}
+}
+
+func (w *csvWriter) row(cells []cell) error {
+	record := make([]string, len(cells))
+	for i, c := range cells {
+		record[i] = c.text
+	}
+	return w.out.Write(record)
+}
+
+func (w *csvWriter) Write(line Line) error {
+	if !w.header {
+		w.header = true
+		if err := w.row(headerRow(lineHeader)); err != nil {
+			return err
+		}
+	}
+	return w.row(lineRow(line))
+}
+
+func (w *csvWriter) Close(summary *Summary) error {
+	rows := [][]cell{}
+	if !w.header {
+		rows = append(rows, headerRow(lineHeader))
+	}
+	rows = append(rows, nil)
+	rows = append(rows, periodRows(summary)...)
+	rows = append(rows, nil, headerRow(summaryHeader))
+	for _, total := range summary.Totals() {
+		rows = append(rows, summaryRow(total))
+	}
+	for _, row := range rows {
+		if err := w.row(row); err != nil {
+			return err
+		}
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
@@ -0,0 +1,249 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/domain/money"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var Formats = []Format{FormatCSV, FormatXLSX}
+
+// ParseFormat reads a format name; an empty name is CSV.
+func ParseFormat(s string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(s)))
+	if format == "" {
+		return FormatCSV, nil
+	}
+	for _, known := range Formats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+}
+
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Line is a journal entry that moved the merchant's payable balance. Amounts
+// are in minor units: Credit and Debit on the merchant's account, Fee and Tax
+// credited to the gateway for a fee entry.
+type Line struct {
+	PostedAt      time.Time
+	EntryType     ledger.EntryType
+	TransactionID string
+	ReferenceNo   string
+	Channel       string
+	Currency      money.Currency
+	Credit        int64
+	Debit         int64
+	Fee           int64
+	Tax           int64
+}
+
+// Type names the line for merchants: PAYMENT, FEE or REFUND.
+func (l Line) Type() string {
+	switch l.EntryType {
+	case ledger.PaymentCaptured:
+		return "PAYMENT"
+	case ledger.FeeAssessed:
+		return "FEE"
+	case ledger.RefundCompleted:
+		return "REFUND"
+	}
+	return string(l.EntryType)
+}
+
+func (l Line) Gross() int64 {
+	if l.EntryType == ledger.PaymentCaptured {
+		return l.Credit
+	}
+	return 0
+}
+
+func (l Line) Refund() int64 {
+	if l.EntryType == ledger.RefundCompleted {
+		return l.Debit
+	}
+	return 0
+}
+
+// Net is the change of the merchant's balance, negative for fees and refunds.
+func (l Line) Net() int64 {
+	return l.Credit - l.Debit
+}
+
+// Total sums the lines of one currency. The net settlement is what the
+// gateway owes the merchant for the period: gross less fees, tax and refunds.
+type Total struct {
+	Currency money.Currency
+	Opening  int64
+	Payments int
+	Gross    int64
+	Fee      int64
+	Tax      int64
+	Refunds  int
+	Refunded int64
+	Net      int64
+}
+
+func (t Total) Closing() int64 {
+	return t.Opening + t.Net
+}
+
+// Summary is the statement of a merchant for [From, To).
+type Summary struct {
+	Merchant string
+	From     time.Time
+	To       time.Time
+	Lines    int
+	totals   map[string]*Total
+}
+
+func NewSummary(merchant string, from time.Time, to time.Time) *Summary {
+	return &Summary{Merchant: merchant, From: from, To: to, totals: map[string]*Total{}}
+}
+
+func (s *Summary) total(currency money.Currency) *Total {
+	total, ok := s.totals[currency.Code]
+	if !ok {
+		total = &Total{Currency: currency}
+		s.totals[currency.Code] = total
+	}
+	return total
+}
+
+// Open sets the balance owed to the merchant when the period starts.
+func (s *Summary) Open(currency money.Currency, balance int64) {
+	s.total(currency).Opening = balance
+}
+
+func (s *Summary) Add(line Line) {
+	s.Lines++
+	total := s.total(line.Currency)
+	switch line.EntryType {
+	case ledger.PaymentCaptured:
+		total.Payments++
+	case ledger.RefundCompleted:
+		total.Refunds++
+	}
+	total.Gross += line.Gross()
+	total.Fee += line.Fee
+	total.Tax += line.Tax
+	total.Refunded += line.Refund()
+	total.Net += line.Net()
+}
+
+// Totals returns the totals by currency code.
+func (s *Summary) Totals() []Total {
+	totals := make([]Total, 0, len(s.totals))
+	for _, total := range s.totals {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency.Code < totals[j].Currency.Code })
+	return totals
+}
+
+// Writer writes a statement as its lines are read. Close writes the summary
+// and must be called once all lines are written.
+type Writer interface {
+	Write(line Line) error
+	Close(summary *Summary) error
+}
+
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+var (
+	lineHeader    = []string{"posted_at", "type", "transaction_id", "reference_no", "channel", "currency", "gross", "fee", "tax", "refund", "net"}
+	summaryHeader = []string{"currency", "opening_balance", "payments", "gross", "fee", "tax", "refunds", "refunded", "net_settlement", "closing_balance"}
+)
+
+// cell is a value of a statement row; numbers are amounts and counts.
+type cell struct {
+	text   string
+	number bool
+}
+
+func text(s string) cell { return cell{text: s} }
+
+func amount(minor int64, currency money.Currency) cell {
+	return cell{text: money.New(minor, currency).String(), number: true}
+}
+
+func count(n int) cell { return cell{text: fmt.Sprint(n), number: true} }
+
+func lineRow(line Line) []cell {
+	return []cell{
+		text(line.PostedAt.Format(time.RFC3339)),
+		text(line.Type()),
+		text(line.TransactionID),
+		text(line.ReferenceNo),
+		text(line.Channel),
+		text(line.Currency.Code),
+		amount(line.Gross(), line.Currency),
+		amount(line.Fee, line.Currency),
+		amount(line.Tax, line.Currency),
+		amount(line.Refund(), line.Currency),
+		amount(line.Net(), line.Currency),
+	}
+}
+
+func summaryRow(total Total) []cell {
+	return []cell{
+		text(total.Currency.Code),
+		amount(total.Opening, total.Currency),
+		count(total.Payments),
+		amount(total.Gross, total.Currency),
+		amount(total.Fee, total.Currency),
+		amount(total.Tax, total.Currency),
+		count(total.Refunds),
+		amount(total.Refunded, total.Currency),
+		amount(total.Net, total.Currency),
+		amount(total.Closing(), total.Currency),
+	}
+}
+
+func headerRow(names []string) []cell {
+	row := make([]cell, len(names))
+	for i, name := range names {
+		row[i] = text(name)
+	}
+	return row
+}
+
+// periodRows describe the statement above its summary.
+func periodRows(summary *Summary) [][]cell {
+	return [][]cell{
+		{text("merchant"), text(summary.Merchant)},
+		{text("period_from"), text(summary.From.Format(time.RFC3339))},
+		{text("period_to"), text(summary.To.Format(time.RFC3339))},
+		{text("lines"), count(summary.Lines)},
+	}
+}
@@ -0,0 +1,145 @@
+package statement
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+)
+
+const spreadsheetNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+
+// xlsxParts are the fixed parts of a workbook with a Transactions and a
+// Summary sheet. Cells hold inline strings, so no shared string table is
+// needed and rows can be written as they come.
+var xlsxParts = []struct{ name, body string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="` + spreadsheetNS + `" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Transactions" sheetId="1" r:id="rId1"/><sheet name="Summary" sheetId="2" r:id="rId2"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet2.xml"/>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="` + spreadsheetNS + `">` +
+		`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// xlsxWriter streams the Transactions sheet into the zip archive; the
+// Summary sheet follows it on Close.
+type xlsxWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	x := &xlsxWriter{archive: zip.NewWriter(w)}
+	for _, part := range xlsxParts {
+		f, err := x.archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := x.openSheet("xl/worksheets/sheet1.xml"); err != nil {
+		return nil, err
+	}
+	return x, x.row(headerRow(lineHeader))
+}
+
+func (x *xlsxWriter) openSheet(name string) error {
+	f, err := x.archive.Create(name)
+	if err != nil {
+		return err
+	}
+	x.sheet, x.rows = bufio.NewWriter(f), 0
+	_, err = x.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" + `<worksheet xmlns="` + spreadsheetNS + `"><sheetData>`)
+	return err
+}
+
+func (x *xlsxWriter) closeSheet() error {
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return x.sheet.Flush()
+}
+
+func (x *xlsxWriter) row(cells []cell) error {
+	x.rows++
+	r := strconv.Itoa(x.rows)
+	x.sheet.WriteString(`<row r="` + r + `">`)
+	for i, c := range cells {
+		ref := column(i) + r
+		if c.number {
+			x.sheet.WriteString(`<c r="` + ref + `"><v>` + c.text + `</v></c>`)
+			continue
+		}
+		x.sheet.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(x.sheet, []byte(c.text)); err != nil {
+			return err
+		}
+		x.sheet.WriteString(`</t></is></c>`)
+	}
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (x *xlsxWriter) Write(line Line) error {
+	return x.row(lineRow(line))
+}
+
+func (x *xlsxWriter) Close(summary *Summary) error {
+	if err := x.closeSheet(); err != nil {
+		return err
+	}
+	if err := x.openSheet("xl/worksheets/sheet2.xml"); err != nil {
+		return err
+	}
+	rows := append(periodRows(summary), nil, headerRow(summaryHeader))
+	for _, total := range summary.Totals() {
+		rows = append(rows, summaryRow(total))
+	}
+	for _, row := range rows {
+		if err := x.row(row); err != nil {
+			return err
+		}
+	}
+	if err := x.closeSheet(); err != nil {
+		return err
+	}
+	return x.archive.Close()
+}
+
+// column names the i-th column, from A.
+func column(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
@@ -3,6 +3,8 @@ package common
 import (
 	"net/http"
 
+	"worker-nicepay/domain/apperror"
+
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
 )
@@ -25,26 +27,36 @@ type MetaData struct {
 }
 
 type Response struct {
-	Status  int         `json:"status"`
-	Error   bool        `json:"error"`
-	TrxId   string      `json:"trx_id,omitempty"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Meta    *MetaData   `json:"meta_data,omitempty"`
+	Status    int         `json:"status"`
+	Error     bool        `json:"error"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	TrxId     string      `json:"trx_id,omitempty"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Meta      *MetaData   `json:"meta_data,omitempty"`
 }
 
 func BuildErrorResponse(message string, status int, err error, trxId string) Response {
 
 	res := Response{
-		Error:   true,
-		TrxId:   trxId,
-		Status:  status,
-		Message: message,
-		Data:    err.Error(),
+		Error:     true,
+		ErrorCode: errorCode(status, err),
+		TrxId:     trxId,
+		Status:    status,
+		Message:   message,
+		Data:      err.Error(),
 	}
 	return res
 }
 
+// errorCode prefers the code of a typed error, otherwise derives one from status.
+func errorCode(status int, err error) string {
+	if code := apperror.CodeOf(err); code != apperror.CodeInternal || status >= http.StatusInternalServerError {
+		return string(code)
+	}
+	return string(apperror.CodeInvalidRequest)
+}
+
 func BuildSuccessResponse(message string, status int, data interface{}, trxId string) Response {
 	res := Response{
 		Error:   false,
@@ -80,3 +92,8 @@ func ErrorResponse(c *fiber.Ctx, status int, message string, err error, request
 
 	return c.Status(status).JSON(resp)
 }
+
+// AppErrorResponse responds with the HTTP status and error code mapped from a typed error.
+func AppErrorResponse(c *fiber.Ctx, err error, request interface{}, trxId string) error {
+	return ErrorResponse(c, apperror.HTTPStatus(err), err.Error(), err, request, trxId)
+}
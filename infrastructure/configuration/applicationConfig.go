@@ -5,29 +5,71 @@ import "github.com/spf13/viper"
 var AppConfig *appConfig
 
 type appConfig struct {
-	ApplicationPort       int
-	ApplicationName       string
-	ServiceName           string
-	Environment           string
-	XenditAPIURL          string
-	XenditAPIKey          string
-	XenditTimeout         int // in milliseconds
-	RedisHost             string
-	RedisPort             int
-	RedisPassword         string
-	RedisDatabase         int
-	YugabyteHost          string
-	YugabytePort          int
-	YugabyteUsername      string
-	YugabytePassword      string
-	YugabyteDatabase      string
-	RabbitMQURI           string
-	ElasticsearchAddress  string
-	ElasticsearchUsername string
-	ElasticsearchPassword string
-	CallbackURLNicepay    string
-	ReturnURLNicepay      string
-	NicepayURL            string
+	ApplicationPort           int
+	ApplicationName           string
+	ServiceName               string
+	Environment               string
+	XenditAPIURL              string
+	XenditAPIKey              string
+	XenditTimeout             int // in milliseconds
+	RedisHost                 string
+	RedisPort                 int
+	RedisPassword             string
+	RedisDatabase             int
+	RedisPoolSize             int
+	RedisStreamMaxLen         int64
+	PublisherDriver           string // log, redis-pubsub, redis-stream
+	PublisherChannel          string // prefix for redis channel / stream names
+	YugabyteHost              string
+	YugabytePort              int
+	YugabyteUsername          string
+	YugabytePassword          string
+	YugabyteDatabase          string
+	DBMigrateMode             string // up, check, off
+	DBMigrateLockTimeout      int    // in milliseconds
+	MasterDataCacheTTL        int    // in milliseconds, 0 disables
+	MasterDataCacheSize       int    // entries per table
+	RabbitMQURI               string
+	ConsumerConcurrency       int
+	ConsumerMaxRetries        int
+	ConsumerRetryBackoff      int    // in milliseconds
+	ConsumerMaxRetryBackoff   int    // in milliseconds
+	JobStoreDriver            string // memory, redis
+	JobResultTTL              int    // in seconds
+	WorkerQueueSize           int
+	WorkerMerchantMaxInFlight int
+	WorkerLaneCapacity        int // queued jobs per merchant, 0 for a quarter of the queue
+	WorkerRetryAfter          int // in seconds
+	WorkerBatchMaxItems       int
+	WorkerLaneWeights         string // merchantA:3,merchantB:2
+	WorkerPriorityBurst       int
+	ScheduleInterval          int // in milliseconds
+	ScheduleMaxHorizon        int // in hours
+	ScheduleLease             int // in milliseconds
+	ElasticsearchAddress      string
+	ElasticsearchUsername     string
+	ElasticsearchPassword     string
+	CallbackURLNicepay        string
+	ReturnURLNicepay          string
+	NicepayURL                string
+	NicepayRateLimit          float64 // requests per second, 0 disables
+	NicepayRateBurst          int
+	NicepayMaxInFlight        int
+	NicepayBulkheadWait       int // in milliseconds
+	NicepayBreakerThreshold   int
+	NicepayBreakerOpenTimeout int // in milliseconds
+	NicepayBreakerProbes      int
+	NicepayMaxRetries         int
+	NicepayRetryBackoff       int // in milliseconds
+	NicepayStatusURL          string
+	NicepayResolveInterval    int // in milliseconds
+	NicepayResolveAfter       int // in milliseconds
+	LedgerCheckInterval       int // in milliseconds, 0 disables
+	SettlementCSVMapping      string
+	SettlementCSVDelimiter    string
+	ReportDir                 string
+	ReportConcurrency         int
+	ReportRetention           int // in milliseconds
 }
 
 func InitializeAppConfig() {
@@ -47,16 +89,58 @@ func InitializeAppConfig() {
 	AppConfig.RedisPort = viper.GetInt("REDIS_PORT")
 	AppConfig.RedisPassword = viper.GetString("REDIS_PASSWORD")
 	AppConfig.RedisDatabase = viper.GetInt("REDIS_DATABASE")
+	AppConfig.RedisPoolSize = viper.GetInt("REDIS_POOL_SIZE")
+	AppConfig.RedisStreamMaxLen = viper.GetInt64("REDIS_STREAM_MAX_LEN")
+	AppConfig.PublisherDriver = viper.GetString("PUBLISHER_DRIVER")
+	AppConfig.PublisherChannel = viper.GetString("PUBLISHER_CHANNEL")
 	AppConfig.YugabyteHost = viper.GetString("YUGABYTE_HOST")
 	AppConfig.YugabytePort = viper.GetInt("YUGABYTE_PORT")
 	AppConfig.YugabyteUsername = viper.GetString("YUGABYTE_USERNAME")
 	AppConfig.YugabytePassword = viper.GetString("YUGABYTE_PASSWORD")
 	AppConfig.YugabyteDatabase = viper.GetString("YUGABYTE_DATABASE")
+	AppConfig.DBMigrateMode = viper.GetString("DB_MIGRATE_MODE")
+	AppConfig.DBMigrateLockTimeout = viper.GetInt("DB_MIGRATE_LOCK_TIMEOUT")
+	AppConfig.MasterDataCacheTTL = viper.GetInt("MASTER_DATA_CACHE_TTL")
+	AppConfig.MasterDataCacheSize = viper.GetInt("MASTER_DATA_CACHE_SIZE")
 	AppConfig.RabbitMQURI = viper.GetString("RABBITMQ_URI")
+	AppConfig.ConsumerConcurrency = viper.GetInt("CONSUMER_CONCURRENCY")
+	AppConfig.ConsumerMaxRetries = viper.GetInt("CONSUMER_MAX_RETRIES")
+	AppConfig.ConsumerRetryBackoff = viper.GetInt("CONSUMER_RETRY_BACKOFF")
+	AppConfig.ConsumerMaxRetryBackoff = viper.GetInt("CONSUMER_MAX_RETRY_BACKOFF")
+	AppConfig.JobStoreDriver = viper.GetString("JOB_STORE_DRIVER")
+	AppConfig.JobResultTTL = viper.GetInt("JOB_RESULT_TTL")
+	AppConfig.WorkerQueueSize = viper.GetInt("WORKER_QUEUE_SIZE")
+	AppConfig.WorkerMerchantMaxInFlight = viper.GetInt("WORKER_MERCHANT_MAX_IN_FLIGHT")
+	AppConfig.WorkerLaneCapacity = viper.GetInt("WORKER_LANE_CAPACITY")
+	AppConfig.WorkerRetryAfter = viper.GetInt("WORKER_RETRY_AFTER")
+	AppConfig.WorkerBatchMaxItems = viper.GetInt("WORKER_BATCH_MAX_ITEMS")
+	AppConfig.WorkerLaneWeights = viper.GetString("WORKER_LANE_WEIGHTS")
+	AppConfig.WorkerPriorityBurst = viper.GetInt("WORKER_PRIORITY_BURST")
+	AppConfig.ScheduleInterval = viper.GetInt("SCHEDULE_INTERVAL")
+	AppConfig.ScheduleMaxHorizon = viper.GetInt("SCHEDULE_MAX_HORIZON")
+	AppConfig.ScheduleLease = viper.GetInt("SCHEDULE_LEASE")
 	AppConfig.ElasticsearchAddress = viper.GetString("ELASTICSEARCH_ADDRESS")
 	AppConfig.ElasticsearchUsername = viper.GetString("ELASTICSEARCH_USERNAME")
 	AppConfig.ElasticsearchPassword = viper.GetString("ELASTICSEARCH_PASSWORD")
 	AppConfig.CallbackURLNicepay = viper.GetString("CALLBACK_URL_NICEPAY")
 	AppConfig.ReturnURLNicepay = viper.GetString("RETURN_URL_NICEPAY")
 	AppConfig.NicepayURL = viper.GetString("NICEPAY_URL")
+	AppConfig.NicepayRateLimit = viper.GetFloat64("NICEPAY_RATE_LIMIT")
+	AppConfig.NicepayRateBurst = viper.GetInt("NICEPAY_RATE_BURST")
+	AppConfig.NicepayMaxInFlight = viper.GetInt("NICEPAY_MAX_IN_FLIGHT")
+	AppConfig.NicepayBulkheadWait = viper.GetInt("NICEPAY_BULKHEAD_WAIT")
+	AppConfig.NicepayBreakerThreshold = viper.GetInt("NICEPAY_BREAKER_THRESHOLD")
+	AppConfig.NicepayBreakerOpenTimeout = viper.GetInt("NICEPAY_BREAKER_OPEN_TIMEOUT")
+	AppConfig.NicepayBreakerProbes = viper.GetInt("NICEPAY_BREAKER_PROBES")
+	AppConfig.NicepayMaxRetries = viper.GetInt("NICEPAY_MAX_RETRIES")
+	AppConfig.NicepayRetryBackoff = viper.GetInt("NICEPAY_RETRY_BACKOFF")
+	AppConfig.NicepayStatusURL = viper.GetString("NICEPAY_STATUS_URL")
+	AppConfig.NicepayResolveInterval = viper.GetInt("NICEPAY_RESOLVE_INTERVAL")
+	AppConfig.NicepayResolveAfter = viper.GetInt("NICEPAY_RESOLVE_AFTER")
+	AppConfig.LedgerCheckInterval = viper.GetInt("LEDGER_CHECK_INTERVAL")
+	AppConfig.SettlementCSVMapping = viper.GetString("SETTLEMENT_CSV_MAPPING")
+	AppConfig.SettlementCSVDelimiter = viper.GetString("SETTLEMENT_CSV_DELIMITER")
+	AppConfig.ReportDir = viper.GetString("REPORT_DIR")
+	AppConfig.ReportConcurrency = viper.GetInt("REPORT_CONCURRENCY")
+	AppConfig.ReportRetention = viper.GetInt("REPORT_RETENTION")
 }
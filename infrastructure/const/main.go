@@ -1,9 +1,28 @@
 package constant
 
 const (
-	PAYMENT_STATUS_PENDING = "PENDING"
-	PAYMENT_STATUS_SUCCESS = "SUCCESS"
-	PAYMENT_STATUS_FAILED  = "FAILED"
-	PAYMENT_STATUS_CANCEL  = "CANCEL"
-	PAYMENT_STATUS_EXPIRED = "EXPIRED"
+	PAYMENT_STATUS_INITIATED = "INITIATED"
+	PAYMENT_STATUS_PENDING   = "PENDING"
+	PAYMENT_STATUS_SUCCESS   = "SUCCESS"
+	PAYMENT_STATUS_FAILED    = "FAILED"
+	PAYMENT_STATUS_UNKNOWN   = "UNKNOWN"
+	PAYMENT_STATUS_CANCEL    = "CANCEL"
+	PAYMENT_STATUS_EXPIRED   = "EXPIRED"
+)
+
+const (
+	SCHEDULED_JOB_STATUS_SCHEDULED = "SCHEDULED"
+	SCHEDULED_JOB_STATUS_RELEASED  = "RELEASED"
+	SCHEDULED_JOB_STATUS_CANCELLED = "CANCELLED"
+)
+
+const (
+	RECONCILIATION_STATUS_RUNNING   = "RUNNING"
+	RECONCILIATION_STATUS_COMPLETED = "COMPLETED"
+	RECONCILIATION_STATUS_FAILED    = "FAILED"
+)
+
+const (
+	DATA_STATUS_ACTIVE  = "ACTIVE"
+	DATA_STATUS_DELETED = "DELETED"
 )
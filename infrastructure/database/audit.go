@@ -0,0 +1,166 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	constant "worker-nicepay/infrastructure/const"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SystemActor is recorded for changes made without an actor in the context,
+// e.g. by background workers.
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// Actor is who performs a change and from which IP.
+type Actor struct {
+	User string
+	IP   string
+}
+
+// WithActor returns a context whose database changes are recorded as made
+// by user from ip.
+func WithActor(ctx context.Context, user string, ip string) context.Context {
+	return context.WithValue(ctx, actorKey{}, Actor{User: user, IP: ip})
+}
+
+// ActorFrom returns the actor of ctx. The user defaults to SystemActor.
+func ActorFrom(ctx context.Context) Actor {
+	var actor Actor
+	if ctx != nil {
+		actor, _ = ctx.Value(actorKey{}).(Actor)
+	}
+	if actor.User == "" {
+		actor.User = SystemActor
+	}
+	return actor
+}
+
+// registerAuditCallbacks fills the Created*/Updated*/Deleted* audit columns
+// and DataStatus from the actor in the statement context. Deletes of models
+// with a DeletedDate column become soft deletes unless Unscoped.
+func registerAuditCallbacks(db *gorm.DB) {
+	if db == nil {
+		return
+	}
+
+	db.Callback().Create().Before("gorm:create").Register("audit_before_create", func(tx *gorm.DB) {
+		stmt := tx.Statement
+		if stmt == nil || stmt.Schema == nil {
+			return
+		}
+		actor := ActorFrom(stmt.Context)
+		values := map[string]interface{}{
+			"CreatedDate": time.Now().UnixMilli(),
+			"CreatedUser": actor.User,
+			"DataStatus":  constant.DATA_STATUS_ACTIVE,
+		}
+		if actor.IP != "" {
+			values["CreatedIp"] = actor.IP
+		}
+
+		rv := stmt.ReflectValue
+		if !rv.IsValid() {
+			return
+		}
+		switch rv.Kind() {
+		case reflect.Struct:
+			setIfZero(stmt, rv, values)
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < rv.Len(); i++ {
+				setIfZero(stmt, reflect.Indirect(rv.Index(i)), values)
+			}
+		}
+	})
+
+	db.Callback().Update().Before("gorm:update").Register("audit_before_update", func(tx *gorm.DB) {
+		stmt := tx.Statement
+		if stmt == nil || stmt.Schema == nil {
+			return
+		}
+		actor := ActorFrom(stmt.Context)
+		setColumnIfUnset(stmt, "UpdatedDate", time.Now().UnixMilli())
+		setColumnIfUnset(stmt, "UpdatedUser", actor.User)
+		if actor.IP != "" {
+			setColumnIfUnset(stmt, "UpdatedIp", actor.IP)
+		}
+	})
+
+	db.Callback().Delete().Before("gorm:delete").Register("audit_soft_delete", func(tx *gorm.DB) {
+		stmt := tx.Statement
+		if stmt == nil || stmt.Schema == nil || stmt.Unscoped || stmt.SQL.Len() > 0 {
+			return
+		}
+		deletedDate := stmt.Schema.LookUpField("DeletedDate")
+		if deletedDate == nil {
+			return
+		}
+
+		actor := ActorFrom(stmt.Context)
+		set := clause.Set{{Column: clause.Column{Name: deletedDate.DBName}, Value: time.Now().UnixMilli()}}
+		for _, column := range [][2]string{
+			{"DeletedUser", actor.User},
+			{"DeletedIp", actor.IP},
+			{"DataStatus", constant.DATA_STATUS_DELETED},
+		} {
+			if field := stmt.Schema.LookUpField(column[0]); field != nil && column[1] != "" {
+				set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: column[1]})
+			}
+		}
+		stmt.AddClause(set)
+
+		// the primary key of a loaded model is part of the condition, as for a hard delete
+		if stmt.ReflectValue.IsValid() && stmt.ReflectValue.Kind() == reflect.Struct {
+			_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
+			column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+			if len(values) > 0 {
+				stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+			}
+		}
+		if _, ok := stmt.Clauses["WHERE"]; !ok && !stmt.AllowGlobalUpdate {
+			tx.AddError(gorm.ErrMissingWhereClause)
+			return
+		}
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: deletedDate.DBName}, Value: nil},
+		}})
+
+		stmt.AddClauseIfNotExists(clause.Update{})
+		stmt.Build("UPDATE", "SET", "WHERE")
+	})
+}
+
+func setIfZero(stmt *gorm.Statement, rv reflect.Value, values map[string]interface{}) {
+	for name, value := range values {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			continue
+		}
+		if _, zero := field.ValueOf(stmt.Context, rv); zero {
+			field.Set(stmt.Context, rv, value)
+		}
+	}
+}
+
+// setColumnIfUnset sets an updated column unless the update assigns it already.
+func setColumnIfUnset(stmt *gorm.Statement, name string, value interface{}) {
+	field := stmt.Schema.LookUpField(name)
+	if field == nil {
+		return
+	}
+	if dest, ok := stmt.Dest.(map[string]interface{}); ok {
+		if _, set := dest[field.DBName]; set {
+			return
+		}
+		if _, set := dest[field.Name]; set {
+			return
+		}
+	}
+	stmt.SetColumn(field.DBName, value, true)
+}
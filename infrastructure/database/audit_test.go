@@ -0,0 +1,74 @@
+package database_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"worker-nicepay/infrastructure/database"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Changes made while handling a request are recorded as made by the actor
+// the Incoming middleware puts in the request context.
+func TestAuditRecordsRequestActor(t *testing.T) {
+	const merchantID = "0192a5c4-7b3e-7c1d-9f00-1a2b3c4d5e6f"
+	tests := []struct {
+		name  string
+		body  string
+		actor string
+		want  string
+	}{
+		{name: "merchant of the request", body: `{"merchant_id":"` + merchantID + `"}`, want: merchantID},
+		{name: "X-Actor before the merchant", body: `{"merchant_id":"` + merchantID + `"}`, actor: "ops@example.com", want: "ops@example.com"},
+		{name: "no merchant", body: `{"country":"ID"}`, want: database.SystemActor},
+		{name: "no body", want: database.SystemActor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, SkipDefaultTransaction: true, DisableAutomaticPing: true})
+			if err != nil {
+				t.Fatalf("open gorm: %v", err)
+			}
+			database.RegisterAuditCallbacks(db)
+
+			var created models.CountriesDataModel
+			app := fiber.New()
+			app.Use((&middleware.Middlewares{}).Incoming())
+			app.Post("/countries", func(c *fiber.Ctx) error {
+				created = models.CountriesDataModel{Name: "Indonesia", Code: "ID"}
+				if err := db.WithContext(c.UserContext()).Create(&created).Error; err != nil {
+					return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+				}
+				return c.SendStatus(fiber.StatusCreated)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/countries", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			if tt.actor != "" {
+				req.Header.Set("X-Actor", tt.actor)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("POST: %v", err)
+			}
+			if res.StatusCode != fiber.StatusCreated {
+				msg, _ := io.ReadAll(res.Body)
+				t.Fatalf("POST = %d: %s", res.StatusCode, msg)
+			}
+			if created.CreatedUser == nil || *created.CreatedUser != tt.want {
+				t.Errorf("created_user = %v, want %s", created.CreatedUser, tt.want)
+			}
+			if created.CreatedIp == nil || *created.CreatedIp == "" {
+				t.Errorf("created_ip = %v, want the request IP", created.CreatedIp)
+			}
+		})
+	}
+}
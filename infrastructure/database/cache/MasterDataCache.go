@@ -0,0 +1,202 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"worker-nicepay/application/services"
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds name the cached master data tables in invalidation messages. They
+// match the kinds of the admin master data endpoints.
+const (
+	KindCurrencies       = "currencies"
+	KindCountries        = "countries"
+	KindPaymentMethods   = "payment-methods"
+	KindMerchants        = "merchants"
+	KindEWalletProviders = "ewallet-providers"
+	// KindAll invalidates every kind.
+	KindAll = "*"
+)
+
+// MasterDataCache is a read-through cache in front of the master data
+// repositories of the payment flow. Every replica keeps its own copy; an
+// invalidation is published on channel so the other replicas drop theirs.
+type MasterDataCache struct {
+	enabled bool
+	client  redis.UniversalClient
+	channel string
+
+	currencies       *LRU[string, *models.CurrenciesDataModel]
+	countries        *LRU[string, *models.CountriesDataModel]
+	paymentMethods   *LRU[string, *models.PaymentMethodsDataModel]
+	merchants        *LRU[string, *models.MerchantsDataModel]
+	ewalletProviders *LRU[string, *models.EWalletProvidersDataModel]
+}
+
+// NewMasterDataCache caches up to size entries per kind for ttl. A ttl that
+// is not positive disables caching; client may be nil on a single replica.
+func NewMasterDataCache(client redis.UniversalClient, channel string, size int, ttl time.Duration) *MasterDataCache {
+	return &MasterDataCache{
+		enabled:          ttl > 0,
+		client:           client,
+		channel:          channel,
+		currencies:       NewLRU[string, *models.CurrenciesDataModel](size, ttl),
+		countries:        NewLRU[string, *models.CountriesDataModel](size, ttl),
+		paymentMethods:   NewLRU[string, *models.PaymentMethodsDataModel](size, ttl),
+		merchants:        NewLRU[string, *models.MerchantsDataModel](size, ttl),
+		ewalletProviders: NewLRU[string, *models.EWalletProvidersDataModel](size, ttl),
+	}
+}
+
+// Wrap puts the cache in front of the master data repositories of repos.
+func (c *MasterDataCache) Wrap(repos services.PaymentRepositories) services.PaymentRepositories {
+	if !c.enabled {
+		return repos
+	}
+	repos.Currencies = cachedCurrencies{next: repos.Currencies, lru: c.currencies}
+	repos.Countries = cachedCountries{next: repos.Countries, lru: c.countries}
+	repos.PaymentMethods = cachedPaymentMethods{next: repos.PaymentMethods, lru: c.paymentMethods}
+	repos.Merchants = cachedMerchants{next: repos.Merchants, lru: c.merchants}
+	repos.EWalletProviders = cachedEWalletProviders{next: repos.EWalletProviders, lru: c.ewalletProviders}
+	return repos
+}
+
+// Invalidate drops the cached entries of kinds on this replica and
+// publishes the invalidation to the others.
+func (c *MasterDataCache) Invalidate(ctx context.Context, kinds ...string) error {
+	c.purge(kinds...)
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Publish(ctx, c.channel, strings.Join(kinds, ",")).Err()
+}
+
+// Listen applies invalidations published by other replicas until ctx is
+// done. Everything is purged on start, as messages sent before the
+// subscription are lost.
+func (c *MasterDataCache) Listen(ctx context.Context) error {
+	if c.client == nil {
+		return nil
+	}
+	sub := c.client.Subscribe(ctx, c.channel)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+	c.purge(KindAll)
+
+	messages := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			c.purge(strings.Split(msg.Payload, ",")...)
+		}
+	}
+}
+
+// purge ignores kinds that are not cached, e.g. va-providers.
+func (c *MasterDataCache) purge(kinds ...string) {
+	for _, kind := range kinds {
+		switch kind {
+		case KindCurrencies:
+			c.currencies.Purge()
+		case KindCountries:
+			c.countries.Purge()
+		case KindPaymentMethods:
+			c.paymentMethods.Purge()
+		case KindMerchants:
+			c.merchants.Purge()
+		case KindEWalletProviders:
+			c.ewalletProviders.Purge()
+		case KindAll:
+			c.currencies.Purge()
+			c.countries.Purge()
+			c.paymentMethods.Purge()
+			c.merchants.Purge()
+			c.ewalletProviders.Purge()
+		}
+	}
+}
+
+// clone hands out copies, so callers cannot modify the cached rows.
+func clone[M any](model *M, err error) (*M, error) {
+	if model == nil || err != nil {
+		return nil, err
+	}
+	copied := *model
+	return &copied, nil
+}
+
+type cachedCurrencies struct {
+	next services.CurrencyRepository
+	lru  *LRU[string, *models.CurrenciesDataModel]
+}
+
+func (r cachedCurrencies) FindByCode(ctx context.Context, code string) (*models.CurrenciesDataModel, error) {
+	return clone(r.lru.GetOrLoad("code:"+code, func() (*models.CurrenciesDataModel, error) {
+		return r.next.FindByCode(ctx, code)
+	}))
+}
+
+func (r cachedCurrencies) FindByID(ctx context.Context, id uuid.UUID) (*models.CurrenciesDataModel, error) {
+	return clone(r.lru.GetOrLoad("id:"+id.String(), func() (*models.CurrenciesDataModel, error) {
+		return r.next.FindByID(ctx, id)
+	}))
+}
+
+type cachedCountries struct {
+	next services.CountryRepository
+	lru  *LRU[string, *models.CountriesDataModel]
+}
+
+func (r cachedCountries) FindByCountryID(ctx context.Context, countryID string) (*models.CountriesDataModel, error) {
+	return clone(r.lru.GetOrLoad(countryID, func() (*models.CountriesDataModel, error) {
+		return r.next.FindByCountryID(ctx, countryID)
+	}))
+}
+
+type cachedPaymentMethods struct {
+	next services.PaymentMethodRepository
+	lru  *LRU[string, *models.PaymentMethodsDataModel]
+}
+
+func (r cachedPaymentMethods) FindByName(ctx context.Context, name string) (*models.PaymentMethodsDataModel, error) {
+	return clone(r.lru.GetOrLoad(name, func() (*models.PaymentMethodsDataModel, error) {
+		return r.next.FindByName(ctx, name)
+	}))
+}
+
+type cachedMerchants struct {
+	next services.MerchantRepository
+	lru  *LRU[string, *models.MerchantsDataModel]
+}
+
+func (r cachedMerchants) FindByID(ctx context.Context, id uuid.UUID) (*models.MerchantsDataModel, error) {
+	return clone(r.lru.GetOrLoad(id.String(), func() (*models.MerchantsDataModel, error) {
+		return r.next.FindByID(ctx, id)
+	}))
+}
+
+// cachedEWalletProviders also caches misses, the common case for channels
+// that are not e-wallets.
+type cachedEWalletProviders struct {
+	next services.EWalletProviderRepository
+	lru  *LRU[string, *models.EWalletProvidersDataModel]
+}
+
+func (r cachedEWalletProviders) FindByProviderName(ctx context.Context, name string) (*models.EWalletProvidersDataModel, error) {
+	return clone(r.lru.GetOrLoad(name, func() (*models.EWalletProvidersDataModel, error) {
+		return r.next.FindByProviderName(ctx, name)
+	}))
+}
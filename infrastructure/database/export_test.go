@@ -0,0 +1,5 @@
+package database
+
+// RegisterAuditCallbacks lets the external tests register the audit callbacks
+// on their own gorm handle.
+var RegisterAuditCallbacks = registerAuditCallbacks
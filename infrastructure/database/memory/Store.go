@@ -0,0 +1,433 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"worker-nicepay/application/services"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Store keeps the payment creation tables in memory, so the payment services
+// can run without Yugabyte. Master data is seeded through the exported slices.
+type Store struct {
+	mu sync.Mutex
+
+	Payments         map[uuid.UUID]models.PaymentsDataModel
+	EWallets         map[uuid.UUID]models.PaymentNicepayEWalletsDataModel // by payment ID
+	Currencies       []models.CurrenciesDataModel
+	Countries        []models.CountriesDataModel
+	PaymentMethods   []models.PaymentMethodsDataModel
+	Merchants        []models.MerchantsDataModel
+	EWalletProviders []models.EWalletProvidersDataModel
+
+	MerchantPaymentMethods []models.MerchantPaymentMethodsDataModel
+	FeeSchedules           []models.FeeSchedulesDataModel
+
+	Journal []ledger.Entry
+}
+
+func NewStore() *Store {
+	return &Store{
+		Payments: make(map[uuid.UUID]models.PaymentsDataModel),
+		EWallets: make(map[uuid.UUID]models.PaymentNicepayEWalletsDataModel),
+	}
+}
+
+func (s *Store) Repositories() services.PaymentRepositories {
+	return services.PaymentRepositories{
+		Payments:         paymentRepository{s},
+		EWallets:         ewalletRepository{s},
+		Currencies:       currencyRepository{s},
+		Countries:        countryRepository{s},
+		PaymentMethods:   paymentMethodRepository{s},
+		Merchants:        merchantRepository{s},
+		EWalletProviders: ewalletProviderRepository{s},
+
+		MerchantPaymentMethods: merchantPaymentMethodRepository{s},
+		FeeSchedules:           feeScheduleRepository{s},
+		Ledger:                 ledgerRepository{s},
+	}
+}
+
+// UnitOfWork rolls the payment tables back when fn fails. Concurrent units
+// of work are not isolated from each other.
+func (s *Store) UnitOfWork() services.UnitOfWork {
+	return unitOfWork{s}
+}
+
+type unitOfWork struct{ s *Store }
+
+func (u unitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	u.s.mu.Lock()
+	payments := make(map[uuid.UUID]models.PaymentsDataModel, len(u.s.Payments))
+	for k, v := range u.s.Payments {
+		payments[k] = v
+	}
+	ewallets := make(map[uuid.UUID]models.PaymentNicepayEWalletsDataModel, len(u.s.EWallets))
+	for k, v := range u.s.EWallets {
+		ewallets[k] = v
+	}
+	journal := u.s.Journal[:len(u.s.Journal):len(u.s.Journal)]
+	u.s.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		u.s.mu.Lock()
+		u.s.Payments = payments
+		u.s.EWallets = ewallets
+		u.s.Journal = journal
+		u.s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// active mirrors the default scope of the gorm repositories.
+func active(deletedDate *int64, dataStatus *string) bool {
+	return deletedDate == nil && (dataStatus == nil || *dataStatus == constant.DATA_STATUS_ACTIVE)
+}
+
+// sameString compares two nullable unique columns; NULLs never collide.
+func sameString(a *string, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+func newID() uuid.UUID {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.New()
+	}
+	return id
+}
+
+type paymentRepository struct{ s *Store }
+
+func (r paymentRepository) Insert(ctx context.Context, payment *models.PaymentsDataModel) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// payments.transaction_id is unique, as in Yugabyte
+	for _, existing := range r.s.Payments {
+		if sameString(existing.TransactionID, payment.TransactionID) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if payment.ID == uuid.Nil {
+		payment.ID = newID()
+	}
+	// the gorm audit callback stamps this in Yugabyte
+	if payment.CreatedDate == nil {
+		createdDate := time.Now().UnixMilli()
+		payment.CreatedDate = &createdDate
+	}
+	r.s.Payments[payment.ID] = *payment
+	return nil
+}
+
+func (r paymentRepository) UpdateOutcome(ctx context.Context, id uuid.UUID, outcome services.PaymentOutcome) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	payment, ok := r.s.Payments[id]
+	if !ok {
+		return nil
+	}
+	payment.ResolvingUntil = nil
+	if outcome.Status != "" {
+		payment.Status = &outcome.Status
+	}
+	if outcome.RedirectURL != "" {
+		payment.RedirectURL = &outcome.RedirectURL
+	}
+	if outcome.ResponseJson != nil {
+		payment.ResponseJson = outcome.ResponseJson
+	}
+	if fees := outcome.Fees; fees != nil {
+		calculatedDate := fees.CalculatedAt.UnixMilli()
+		payment.FeeScheduleID = fees.ScheduleID
+		payment.FeeAmount, payment.FeeTaxAmount, payment.NetAmount = &fees.Fee, &fees.Tax, &fees.Net
+		payment.FeeCalculatedDate = &calculatedDate
+	}
+	updatedDate := outcome.UpdatedAt.UnixMilli()
+	payment.UpdatedDate = &updatedDate
+	r.s.Payments[id] = payment
+	return nil
+}
+
+func (r paymentRepository) ClaimUnresolved(ctx context.Context, staleBefore int64, attemptedBefore int64, now time.Time, lease time.Duration) (*models.PaymentsDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for id, payment := range r.s.Payments {
+		if payment.Status == nil || (payment.UpdatedDate != nil && *payment.UpdatedDate >= attemptedBefore) ||
+			(payment.ResolvingUntil != nil && *payment.ResolvingUntil >= now.UnixMilli()) {
+			continue
+		}
+		stale := payment.CreatedDate != nil && *payment.CreatedDate < staleBefore
+		checked := payment.CreatedDate
+		if payment.UpdatedDate != nil {
+			checked = payment.UpdatedDate
+		}
+		unchecked := checked != nil && *checked < staleBefore
+		if *payment.Status == constant.PAYMENT_STATUS_UNKNOWN || (*payment.Status == constant.PAYMENT_STATUS_INITIATED && stale) ||
+			(*payment.Status == constant.PAYMENT_STATUS_PENDING && unchecked) {
+			until := now.Add(lease).UnixMilli()
+			payment.ResolvingUntil = &until
+			r.s.Payments[id] = payment
+			return &payment, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r paymentRepository) DailyUsage(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, since int64) (int64, int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var count, amount int64
+	for _, payment := range r.s.Payments {
+		if payment.MerchantID == nil || *payment.MerchantID != merchantID ||
+			payment.PaymentMethodID == nil || *payment.PaymentMethodID != paymentMethodID ||
+			payment.CurrencyID == nil || *payment.CurrencyID != currencyID ||
+			payment.CreatedDate == nil || *payment.CreatedDate < since {
+			continue
+		}
+		if payment.Status != nil {
+			switch *payment.Status {
+			case constant.PAYMENT_STATUS_FAILED, constant.PAYMENT_STATUS_CANCEL, constant.PAYMENT_STATUS_EXPIRED:
+				continue
+			}
+		}
+		count++
+		if payment.Amount != nil {
+			amount += *payment.Amount
+		}
+	}
+	return count, amount, nil
+}
+
+// FindForUpdate takes no lock; units of work are not isolated anyway.
+func (r paymentRepository) FindForUpdate(ctx context.Context, transactionID string) (*models.PaymentsDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, payment := range r.s.Payments {
+		if payment.TransactionID != nil && *payment.TransactionID == transactionID && active(payment.DeletedDate, payment.DataStatus) {
+			return &payment, nil
+		}
+	}
+	return nil, apperror.NotFound("payment", transactionID)
+}
+
+type ewalletRepository struct{ s *Store }
+
+func (r ewalletRepository) Insert(ctx context.Context, ewallet *models.PaymentNicepayEWalletsDataModel) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, existing := range r.s.EWallets {
+		if sameString(existing.TransactionID, ewallet.TransactionID) {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	if ewallet.ID == uuid.Nil {
+		ewallet.ID = newID()
+	}
+	r.s.EWallets[*ewallet.PaymentID] = *ewallet
+	return nil
+}
+
+func (r ewalletRepository) UpdateOutcome(ctx context.Context, paymentID uuid.UUID, outcome services.PaymentOutcome) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	ewallet, ok := r.s.EWallets[paymentID]
+	if !ok {
+		return nil
+	}
+	if outcome.RedirectURL != "" {
+		ewallet.RedirectURL = &outcome.RedirectURL
+	}
+	if outcome.NicepayTransactionID != "" {
+		ewallet.NicepayTransactionID = &outcome.NicepayTransactionID
+	}
+	if outcome.ResponseJson != nil {
+		ewallet.ResponseJson = outcome.ResponseJson
+	}
+	updatedDate := outcome.UpdatedAt.UnixMilli()
+	ewallet.UpdatedDate = &updatedDate
+	r.s.EWallets[paymentID] = ewallet
+	return nil
+}
+
+type currencyRepository struct{ s *Store }
+
+func (r currencyRepository) FindByCode(ctx context.Context, code string) (*models.CurrenciesDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, currency := range r.s.Currencies {
+		if currency.Code == code && active(currency.DeletedDate, currency.DataStatus) {
+			return &currency, nil
+		}
+	}
+	return nil, apperror.MasterDataMissing("currency", code)
+}
+
+func (r currencyRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.CurrenciesDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, currency := range r.s.Currencies {
+		if currency.ID == id {
+			return &currency, nil
+		}
+	}
+	return nil, apperror.MasterDataMissing("currency", id.String())
+}
+
+type countryRepository struct{ s *Store }
+
+func (r countryRepository) FindByCountryID(ctx context.Context, countryID string) (*models.CountriesDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, country := range r.s.Countries {
+		if country.Code == countryID && active(country.DeletedDate, country.DataStatus) {
+			return &country, nil
+		}
+	}
+	return nil, apperror.MasterDataMissing("country", countryID)
+}
+
+type paymentMethodRepository struct{ s *Store }
+
+func (r paymentMethodRepository) FindByName(ctx context.Context, name string) (*models.PaymentMethodsDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, method := range r.s.PaymentMethods {
+		if method.Name == name && active(method.DeletedDate, method.DataStatus) {
+			return &method, nil
+		}
+	}
+	return nil, apperror.InvalidChannel(name)
+}
+
+type merchantRepository struct{ s *Store }
+
+func (r merchantRepository) FindByID(ctx context.Context, id uuid.UUID) (*models.MerchantsDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, merchant := range r.s.Merchants {
+		if merchant.ID == id && active(merchant.DeletedDate, merchant.DataStatus) {
+			return &merchant, nil
+		}
+	}
+	return nil, apperror.MerchantNotFound(id.String())
+}
+
+type ewalletProviderRepository struct{ s *Store }
+
+func (r ewalletProviderRepository) FindByProviderName(ctx context.Context, name string) (*models.EWalletProvidersDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, provider := range r.s.EWalletProviders {
+		if provider.ProviderName == name && active(provider.DeletedDate, provider.DataStatus) {
+			return &provider, nil
+		}
+	}
+	return nil, nil
+}
+
+type merchantPaymentMethodRepository struct{ s *Store }
+
+func (r merchantPaymentMethodRepository) FindForUpdate(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID) (*models.MerchantPaymentMethodsDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, config := range r.s.MerchantPaymentMethods {
+		if config.MerchantID == merchantID && config.PaymentMethodID == paymentMethodID && config.CurrencyID == currencyID &&
+			active(config.DeletedDate, config.DataStatus) {
+			return &config, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r merchantPaymentMethodRepository) HasAny(ctx context.Context, merchantID uuid.UUID) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, config := range r.s.MerchantPaymentMethods {
+		if config.MerchantID == merchantID && active(config.DeletedDate, config.DataStatus) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type feeScheduleRepository struct{ s *Store }
+
+func (r feeScheduleRepository) FindEffective(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, at int64) (*models.FeeSchedulesDataModel, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var effective *models.FeeSchedulesDataModel
+	for _, schedule := range r.s.FeeSchedules {
+		if schedule.MerchantID != merchantID || schedule.PaymentMethodID != paymentMethodID || schedule.CurrencyID != currencyID ||
+			schedule.EffectiveFrom > at || !active(schedule.DeletedDate, schedule.DataStatus) {
+			continue
+		}
+		if effective == nil || schedule.EffectiveFrom > effective.EffectiveFrom {
+			effective = &schedule
+		}
+	}
+	return effective, nil
+}
+
+type ledgerRepository struct{ s *Store }
+
+func (r ledgerRepository) Post(ctx context.Context, entry ledger.Entry) (bool, error) {
+	if err := entry.Validate(); err != nil {
+		return false, err
+	}
+
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, posted := range r.s.Journal {
+		if posted.Key == entry.Key {
+			return false, nil
+		}
+	}
+	r.s.Journal = append(r.s.Journal, entry)
+	return true, nil
+}
+
+func (r ledgerRepository) Refunds(ctx context.Context, paymentID uuid.UUID) (map[string]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	refunds := map[string]int64{}
+	for _, entry := range r.s.Journal {
+		if entry.Type != ledger.RefundCompleted || entry.PaymentID != paymentID {
+			continue
+		}
+		for _, line := range entry.Lines {
+			if line.Direction == ledger.Debit {
+				refunds[entry.Key] += line.Amount
+			}
+		}
+	}
+	return refunds, nil
+}
@@ -0,0 +1,65 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrLockTimeout = errors.New("timed out waiting for the migration lock")
+
+const (
+	lockPollInterval = time.Second
+	// a lock older than this is left over from a crashed run and can be taken over
+	lockStaleAfter = 15 * time.Minute
+)
+
+// acquireLock takes the single row of schema_migrations_lock, so only one
+// replica or command migrates at a time. It waits up to timeout.
+func (m *Migrator) acquireLock(ctx context.Context, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		now := time.Now()
+		res := m.db.WithContext(ctx).Exec(`
+			INSERT INTO schema_migrations_lock (id, locked_by, locked_at) VALUES (1, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET locked_by = EXCLUDED.locked_by, locked_at = EXCLUDED.locked_at
+			WHERE schema_migrations_lock.locked_at < ?`,
+			m.owner, now, now.Add(-lockStaleAfter))
+		if res.Error != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", res.Error)
+		}
+		if res.RowsAffected > 0 {
+			return nil
+		}
+
+		if now.After(deadline) {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+func (m *Migrator) releaseLock() error {
+	return m.db.Exec("DELETE FROM schema_migrations_lock WHERE id = 1 AND locked_by = ?", m.owner).Error
+}
+
+// withLock runs fn while holding the migration lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	if err := m.acquireLock(ctx, m.lockTimeout); err != nil {
+		return err
+	}
+	defer func() {
+		if err := m.releaseLock(); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+	return fn(m.db.WithContext(ctx))
+}
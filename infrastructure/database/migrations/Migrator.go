@@ -0,0 +1,246 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultLockTimeout = 2 * time.Minute
+
+// ErrChecksumMismatch means an applied migration was edited afterwards.
+var ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+type appliedMigration struct {
+	Version   int       `gorm:"column:version;primaryKey"`
+	Name      string    `gorm:"column:name"`
+	Checksum  string    `gorm:"column:checksum"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrationStatus is the state of one migration in the database.
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	// Modified is set when the applied checksum differs from the embedded file
+	Modified bool `json:"modified,omitempty"`
+	// Unknown is set for versions applied by a newer binary
+	Unknown bool `json:"unknown,omitempty"`
+}
+
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	owner       string
+	lockTimeout time.Duration
+}
+
+// NewMigrator returns a migrator over the embedded migrations. A lockTimeout
+// of zero uses the default.
+func NewMigrator(db *gorm.DB, lockTimeout time.Duration) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if lockTimeout <= 0 {
+		lockTimeout = defaultLockTimeout
+	}
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		owner:       host + "-" + uuid.NewString(),
+		lockTimeout: lockTimeout,
+	}, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		pending, err := m.pending(db)
+		if err != nil {
+			return err
+		}
+		for _, migration := range pending {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&appliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now(),
+				}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %s failed: %w", migration, err)
+			}
+			log.Printf("Applied migration %s", migration)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down reverts the last steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	err := m.withLock(ctx, func(db *gorm.DB) error {
+		var applied []appliedMigration
+		if err := db.Order("version DESC").Limit(steps).Find(&applied).Error; err != nil {
+			return err
+		}
+		for _, row := range applied {
+			migration, ok := m.find(row.Version)
+			if !ok {
+				return fmt.Errorf("migration %d is not known to this binary", row.Version)
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %s has no down script", migration)
+			}
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&appliedMigration{}, "version = ?", migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("revert of migration %s failed: %w", migration, err)
+			}
+			log.Printf("Reverted migration %s", migration)
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status lists every embedded or applied migration ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &row.AppliedAt
+			status.Modified = row.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:   row.Version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: &row.AppliedAt,
+			Unknown:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the migrations not applied yet. It fails when an applied
+// migration was modified.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	return m.pending(m.db.WithContext(ctx))
+}
+
+func (m *Migrator) pending(db *gorm.DB) ([]Migration, error) {
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		row, ok := applied[migration.Version]
+		if !ok {
+			pending = append(pending, migration)
+			continue
+		}
+		if row.Checksum != migration.Checksum {
+			return nil, fmt.Errorf("%w: %s", ErrChecksumMismatch, migration)
+		}
+	}
+	return pending, nil
+}
+
+func (m *Migrator) applied(db *gorm.DB) (map[int]appliedMigration, error) {
+	var rows []appliedMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]appliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint PRIMARY KEY,
+			name text NOT NULL,
+			checksum text NOT NULL,
+			applied_at timestamptz NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id int PRIMARY KEY,
+			locked_by text NOT NULL,
+			locked_at timestamptz NOT NULL
+		)`).Error
+}
+
+func (m *Migrator) find(version int) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+func (migration Migration) String() string {
+	return fmt.Sprintf("%04d_%s", migration.Version, migration.Name)
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS scheduled_payment_jobs;
+DROP TABLE IF EXISTS payment_xendit_ewallets;
+DROP TABLE IF EXISTS payment_xendit_vas;
+DROP TABLE IF EXISTS payment_xendit_qrises;
+DROP TABLE IF EXISTS payment_nicepay_ewallets;
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS va_providers;
+DROP TABLE IF EXISTS ewallet_providers;
+DROP TABLE IF EXISTS payment_methods;
+DROP TABLE IF EXISTS merchants;
+DROP TABLE IF EXISTS currencies;
+DROP TABLE IF EXISTS countries;
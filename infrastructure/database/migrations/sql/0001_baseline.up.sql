@@ -0,0 +1,282 @@
+-- Baseline schema, equivalent to what AutoMigrate created. Statements are
+-- idempotent so databases created by AutoMigrate adopt it unchanged.
+
+CREATE TABLE IF NOT EXISTS countries (
+    id uuid PRIMARY KEY,
+    name text,
+    code text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_countries_code ON countries (code);
+
+CREATE TABLE IF NOT EXISTS currencies (
+    id uuid PRIMARY KEY,
+    name text,
+    code text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_currencies_code ON currencies (code);
+
+CREATE TABLE IF NOT EXISTS merchants (
+    id uuid PRIMARY KEY,
+    name text,
+    code text,
+    username text,
+    password text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_merchants_code ON merchants (code);
+
+CREATE TABLE IF NOT EXISTS payment_methods (
+    id uuid PRIMARY KEY,
+    name text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_methods_name ON payment_methods (name);
+
+CREATE TABLE IF NOT EXISTS ewallet_providers (
+    id uuid PRIMARY KEY,
+    name text,
+    provider_name text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ewallet_providers_provider_name ON ewallet_providers (provider_name);
+
+CREATE TABLE IF NOT EXISTS va_providers (
+    id uuid PRIMARY KEY,
+    name text,
+    provider_name text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_va_providers_provider_name ON va_providers (provider_name);
+
+CREATE TABLE IF NOT EXISTS payments (
+    id uuid PRIMARY KEY,
+    transaction_id text,
+    payment_gateway text,
+    reference_no text,
+    payment_method_id uuid,
+    currency_id uuid,
+    amount decimal,
+    description text,
+    status text,
+    expired_payment timestamptz,
+    callback_url text,
+    redirect_url text,
+    merchant_id uuid,
+    country_id uuid,
+    response_json jsonb,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_payments_merchant FOREIGN KEY (merchant_id) REFERENCES merchants (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_payments_payment_method FOREIGN KEY (payment_method_id) REFERENCES payment_methods (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_payments_currency FOREIGN KEY (currency_id) REFERENCES currencies (id) ON UPDATE CASCADE ON DELETE SET NULL,
+    CONSTRAINT fk_payments_country FOREIGN KEY (country_id) REFERENCES countries (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_transaction_id ON payments (transaction_id);
+
+CREATE TABLE IF NOT EXISTS payment_nicepay_ewallets (
+    id uuid PRIMARY KEY,
+    payment_id uuid,
+    transaction_id text,
+    url_return text,
+    e_wallet_provider_id uuid,
+    customer_username text,
+    customer_msisdn text,
+    customer_email text,
+    redirect_url text,
+    nicepay_transaction_id text,
+    response_json jsonb,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_payment_nicepay_ewallets_payment FOREIGN KEY (payment_id) REFERENCES payments (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_payment_nicepay_ewallets_e_wallet_provider FOREIGN KEY (e_wallet_provider_id) REFERENCES ewallet_providers (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_nicepay_ewallets_payment_id ON payment_nicepay_ewallets (payment_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_nicepay_ewallets_transaction_id ON payment_nicepay_ewallets (transaction_id);
+
+CREATE INDEX IF NOT EXISTS idx_payment_nicepay_ewallets_nicepay_transaction_id ON payment_nicepay_ewallets (nicepay_transaction_id);
+
+CREATE TABLE IF NOT EXISTS payment_xendit_qrises (
+    id uuid PRIMARY KEY,
+    transaction_id text,
+    url_return text,
+    qrpy_id text,
+    customer_username text,
+    customer_msisdn text,
+    customer_email text,
+    response_url text,
+    response_json jsonb,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_xendit_qrises_transaction_id ON payment_xendit_qrises (transaction_id);
+
+CREATE TABLE IF NOT EXISTS payment_xendit_vas (
+    id uuid PRIMARY KEY,
+    transaction_id text,
+    url_return text,
+    va_provider_id uuid,
+    va_number text,
+    customer_username text,
+    customer_msisdn text,
+    customer_email text,
+    response_url text,
+    response_json jsonb,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_payment_xendit_vas_va_provider FOREIGN KEY (va_provider_id) REFERENCES va_providers (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_xendit_vas_transaction_id ON payment_xendit_vas (transaction_id);
+
+CREATE TABLE IF NOT EXISTS payment_xendit_ewallets (
+    id uuid PRIMARY KEY,
+    transaction_id text,
+    url_return text,
+    e_wallet_provider_id uuid,
+    customer_username text,
+    customer_msisdn text,
+    customer_email text,
+    response_url text,
+    response_json jsonb,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_payment_xendit_ewallets_e_wallet_provider FOREIGN KEY (e_wallet_provider_id) REFERENCES ewallet_providers (id) ON UPDATE CASCADE ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_xendit_ewallets_transaction_id ON payment_xendit_ewallets (transaction_id);
+
+CREATE TABLE IF NOT EXISTS scheduled_payment_jobs (
+    id uuid PRIMARY KEY,
+    job_id text,
+    merchant_id text,
+    run_at timestamptz,
+    status text,
+    priority boolean,
+    request jsonb,
+    incoming jsonb,
+    released_at timestamptz,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_payment_jobs_job_id ON scheduled_payment_jobs (job_id);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_payment_jobs_merchant_id ON scheduled_payment_jobs (merchant_id);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_payment_jobs_run_at ON scheduled_payment_jobs (run_at);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_payment_jobs_status ON scheduled_payment_jobs (status);
@@ -0,0 +1,13 @@
+ALTER TABLE payments ADD COLUMN amount_major decimal;
+
+UPDATE payments p SET amount_major = p.amount / POWER(10::numeric, c.minor_unit)
+FROM currencies c
+WHERE c.id = p.currency_id AND p.amount IS NOT NULL;
+
+UPDATE payments SET amount_major = amount / 100.0
+WHERE amount_major IS NULL AND amount IS NOT NULL;
+
+ALTER TABLE payments DROP COLUMN amount;
+ALTER TABLE payments RENAME COLUMN amount_major TO amount;
+
+ALTER TABLE currencies DROP COLUMN minor_unit;
@@ -0,0 +1,22 @@
+-- Amounts are stored as integer minor units of the payment currency. The
+-- exponents are set here for existing currencies; the seed keeps them.
+
+ALTER TABLE currencies ADD COLUMN minor_unit smallint NOT NULL DEFAULT 2;
+
+UPDATE currencies SET minor_unit = 0
+WHERE code IN ('BIF', 'CLP', 'DJF', 'GNF', 'ISK', 'JPY', 'KMF', 'KRW', 'PYG', 'RWF', 'UGX', 'VND', 'VUV', 'XAF', 'XOF', 'XPF');
+
+UPDATE currencies SET minor_unit = 3
+WHERE code IN ('BHD', 'IQD', 'JOD', 'KWD', 'LYD', 'OMR', 'TND');
+
+ALTER TABLE payments ADD COLUMN amount_minor bigint;
+
+UPDATE payments p SET amount_minor = ROUND(p.amount * POWER(10::numeric, c.minor_unit))::bigint
+FROM currencies c
+WHERE c.id = p.currency_id AND p.amount IS NOT NULL;
+
+UPDATE payments SET amount_minor = ROUND(amount * 100)::bigint
+WHERE amount_minor IS NULL AND amount IS NOT NULL;
+
+ALTER TABLE payments DROP COLUMN amount;
+ALTER TABLE payments RENAME COLUMN amount_minor TO amount;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_payments_merchant_created_date;
+DROP TABLE IF EXISTS merchant_payment_methods;
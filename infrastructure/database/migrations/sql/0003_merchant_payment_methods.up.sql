@@ -0,0 +1,38 @@
+-- Channels a merchant may use per currency, with their limits. Amounts are
+-- minor units of the currency; NULL limits do not apply. Active hours are
+-- HH:MM in timezone and may wrap past midnight.
+
+CREATE TABLE merchant_payment_methods (
+    id uuid PRIMARY KEY,
+    merchant_id uuid NOT NULL,
+    payment_method_id uuid NOT NULL,
+    currency_id uuid NOT NULL,
+    enabled boolean NOT NULL DEFAULT true,
+    min_amount bigint,
+    max_amount bigint,
+    daily_amount_cap bigint,
+    daily_count_cap bigint,
+    active_from text,
+    active_until text,
+    timezone text NOT NULL DEFAULT 'Asia/Jakarta',
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_merchant_payment_methods_merchant FOREIGN KEY (merchant_id) REFERENCES merchants (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_merchant_payment_methods_payment_method FOREIGN KEY (payment_method_id) REFERENCES payment_methods (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_merchant_payment_methods_currency FOREIGN KEY (currency_id) REFERENCES currencies (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+-- soft-deleted rows do not block configuring the channel again
+CREATE UNIQUE INDEX idx_merchant_payment_methods_channel ON merchant_payment_methods (merchant_id, payment_method_id, currency_id)
+WHERE deleted_date IS NULL;
+
+-- daily caps sum the merchant's payments of the day
+CREATE INDEX idx_payments_merchant_created_date ON payments (merchant_id, created_date);
@@ -0,0 +1,8 @@
+ALTER TABLE payments
+    DROP COLUMN IF EXISTS fee_calculated_date,
+    DROP COLUMN IF EXISTS net_amount,
+    DROP COLUMN IF EXISTS fee_tax_amount,
+    DROP COLUMN IF EXISTS fee_amount,
+    DROP COLUMN IF EXISTS fee_schedule_id;
+
+DROP TABLE IF EXISTS fee_schedules;
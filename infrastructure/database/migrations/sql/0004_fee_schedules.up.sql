@@ -0,0 +1,42 @@
+-- Fee contracts of a merchant's channel per currency. Amounts are minor units
+-- of the currency, percentages decimal percents. The schedule in force is the
+-- one with the latest effective_from not after the payment time.
+
+CREATE TABLE fee_schedules (
+    id uuid PRIMARY KEY,
+    merchant_id uuid NOT NULL,
+    payment_method_id uuid NOT NULL,
+    currency_id uuid NOT NULL,
+    fee_type text NOT NULL CHECK (fee_type IN ('PERCENTAGE', 'FLAT', 'TIERED')),
+    percentage numeric(7,4),
+    flat_amount bigint NOT NULL DEFAULT 0,
+    tiers jsonb,
+    min_fee bigint,
+    max_fee bigint,
+    tax_percentage numeric(7,4),
+    effective_from bigint NOT NULL,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text,
+    CONSTRAINT fk_fee_schedules_merchant FOREIGN KEY (merchant_id) REFERENCES merchants (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_fee_schedules_payment_method FOREIGN KEY (payment_method_id) REFERENCES payment_methods (id) ON UPDATE CASCADE ON DELETE CASCADE,
+    CONSTRAINT fk_fee_schedules_currency FOREIGN KEY (currency_id) REFERENCES currencies (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX idx_fee_schedules_channel ON fee_schedules (merchant_id, payment_method_id, currency_id, effective_from)
+WHERE deleted_date IS NULL;
+
+-- amount stays the gross amount; net = amount - fee_amount - fee_tax_amount
+ALTER TABLE payments
+    ADD COLUMN fee_schedule_id uuid,
+    ADD COLUMN fee_amount bigint,
+    ADD COLUMN fee_tax_amount bigint,
+    ADD COLUMN net_amount bigint,
+    ADD COLUMN fee_calculated_date bigint;
@@ -0,0 +1,61 @@
+-- Double-entry ledger. Amounts are minor units of the entry currency; the
+-- debits of every journal entry equal its credits. Entries and lines are
+-- immutable, corrections are posted as new entries.
+
+CREATE TABLE ledger_accounts (
+    id uuid PRIMARY KEY,
+    code text NOT NULL UNIQUE,
+    account_type text NOT NULL CHECK (account_type IN ('MERCHANT_PAYABLE', 'GATEWAY_RECEIVABLE', 'FEE_REVENUE', 'TAX_PAYABLE')),
+    merchant_id uuid,
+    currency text NOT NULL,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    CONSTRAINT fk_ledger_accounts_merchant FOREIGN KEY (merchant_id) REFERENCES merchants (id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_ledger_accounts_merchant ON ledger_accounts (merchant_id);
+
+CREATE TABLE journal_entries (
+    id uuid PRIMARY KEY,
+    idempotency_key text NOT NULL UNIQUE,
+    entry_type text NOT NULL CHECK (entry_type IN ('PAYMENT_CAPTURED', 'FEE_ASSESSED', 'REFUND_COMPLETED')),
+    payment_id uuid,
+    currency text NOT NULL,
+    description text,
+    posted_date bigint NOT NULL,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    CONSTRAINT fk_journal_entries_payment FOREIGN KEY (payment_id) REFERENCES payments (id) ON UPDATE CASCADE ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_journal_entries_payment ON journal_entries (payment_id);
+
+CREATE TABLE journal_lines (
+    id uuid PRIMARY KEY,
+    journal_entry_id uuid NOT NULL,
+    account_id uuid NOT NULL,
+    direction text NOT NULL CHECK (direction IN ('DEBIT', 'CREDIT')),
+    amount bigint NOT NULL CHECK (amount > 0),
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    CONSTRAINT fk_journal_lines_entry FOREIGN KEY (journal_entry_id) REFERENCES journal_entries (id) ON UPDATE RESTRICT ON DELETE RESTRICT,
+    CONSTRAINT fk_journal_lines_account FOREIGN KEY (account_id) REFERENCES ledger_accounts (id) ON UPDATE RESTRICT ON DELETE RESTRICT
+);
+
+CREATE INDEX idx_journal_lines_entry ON journal_lines (journal_entry_id);
+CREATE INDEX idx_journal_lines_account ON journal_lines (account_id);
+
+CREATE FUNCTION ledger_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_journal_entries_immutable BEFORE UPDATE OR DELETE ON journal_entries
+FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
+
+CREATE TRIGGER trg_journal_lines_immutable BEFORE UPDATE OR DELETE ON journal_lines
+FOR EACH ROW EXECUTE FUNCTION ledger_immutable();
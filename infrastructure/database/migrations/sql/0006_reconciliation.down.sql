@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_payments_reference_no;
+DROP TABLE IF EXISTS reconciliation_items;
+DROP TABLE IF EXISTS reconciliation_runs;
@@ -0,0 +1,57 @@
+-- Reconciliation of Nicepay settlement files against payments. A run keeps
+-- the outcome of one imported file; its items are the reconciled rows and
+-- the paid payments of the period missing from the file. Amounts are minor
+-- units of the payment currency.
+
+CREATE TABLE reconciliation_runs (
+    id uuid PRIMARY KEY,
+    file_name text NOT NULL,
+    status text NOT NULL CHECK (status IN ('RUNNING', 'COMPLETED', 'FAILED')),
+    mapping text NOT NULL,
+    period_from bigint,
+    period_to bigint,
+    total_rows integer NOT NULL DEFAULT 0,
+    matched integer NOT NULL DEFAULT 0,
+    mismatched integer NOT NULL DEFAULT 0,
+    missing_payment integer NOT NULL DEFAULT 0,
+    missing_settlement integer NOT NULL DEFAULT 0,
+    invalid integer NOT NULL DEFAULT 0,
+    error text,
+    finished_date bigint,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    updated_date bigint,
+    updated_user text,
+    updated_ip text,
+    deleted_date bigint,
+    deleted_user text,
+    deleted_ip text,
+    data_status text
+);
+
+CREATE TABLE reconciliation_items (
+    id uuid PRIMARY KEY,
+    run_id uuid NOT NULL,
+    line_no integer,
+    transaction_id text,
+    reference_no text,
+    payment_id uuid,
+    currency text,
+    settlement_amount bigint,
+    payment_amount bigint,
+    settlement_status text,
+    payment_status text,
+    result text NOT NULL CHECK (result IN ('MATCHED', 'MISMATCHED', 'MISSING_PAYMENT', 'MISSING_SETTLEMENT', 'INVALID')),
+    mismatches text,
+    detail text,
+    created_date bigint,
+    created_user text,
+    created_ip text,
+    CONSTRAINT fk_reconciliation_items_run FOREIGN KEY (run_id) REFERENCES reconciliation_runs (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX idx_reconciliation_items_run ON reconciliation_items (run_id, result, line_no);
+
+-- settlement rows are matched by reference number when they carry no transaction ID
+CREATE INDEX idx_payments_reference_no ON payments (reference_no);
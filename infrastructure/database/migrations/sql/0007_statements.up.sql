@@ -0,0 +1,3 @@
+-- Merchant statements read the journal by posting date.
+
+CREATE INDEX idx_journal_entries_posted_date ON journal_entries (posted_date);
@@ -0,0 +1 @@
+ALTER TABLE scheduled_payment_jobs DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,5 @@
+-- Due jobs are leased until claimed_until instead of being released when
+-- claimed. The row is released once processing starts, so a job lost with
+-- its replica is claimed again when the lease expires.
+
+ALTER TABLE scheduled_payment_jobs ADD COLUMN claimed_until timestamptz;
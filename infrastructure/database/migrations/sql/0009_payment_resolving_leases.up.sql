@@ -0,0 +1,5 @@
+-- The resolver leases a payment until resolving_until (epoch millis) while it
+-- queries Nicepay outside of any transaction; other replicas skip it until
+-- the lease ends.
+
+ALTER TABLE payments ADD COLUMN resolving_until bigint;
@@ -0,0 +1,19 @@
+-- Fails if a soft-deleted row shares its code or name with another row.
+
+DROP INDEX IF EXISTS idx_countries_code;
+CREATE UNIQUE INDEX idx_countries_code ON countries (code);
+
+DROP INDEX IF EXISTS idx_currencies_code;
+CREATE UNIQUE INDEX idx_currencies_code ON currencies (code);
+
+DROP INDEX IF EXISTS idx_merchants_code;
+CREATE UNIQUE INDEX idx_merchants_code ON merchants (code);
+
+DROP INDEX IF EXISTS idx_payment_methods_name;
+CREATE UNIQUE INDEX idx_payment_methods_name ON payment_methods (name);
+
+DROP INDEX IF EXISTS idx_ewallet_providers_provider_name;
+CREATE UNIQUE INDEX idx_ewallet_providers_provider_name ON ewallet_providers (provider_name);
+
+DROP INDEX IF EXISTS idx_va_providers_provider_name;
+CREATE UNIQUE INDEX idx_va_providers_provider_name ON va_providers (provider_name);
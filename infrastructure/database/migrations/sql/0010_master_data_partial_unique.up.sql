@@ -0,0 +1,20 @@
+-- Soft-deleted master data no longer blocks creating a row with the same code
+-- or name again.
+
+DROP INDEX IF EXISTS idx_countries_code;
+CREATE UNIQUE INDEX idx_countries_code ON countries (code) WHERE deleted_date IS NULL;
+
+DROP INDEX IF EXISTS idx_currencies_code;
+CREATE UNIQUE INDEX idx_currencies_code ON currencies (code) WHERE deleted_date IS NULL;
+
+DROP INDEX IF EXISTS idx_merchants_code;
+CREATE UNIQUE INDEX idx_merchants_code ON merchants (code) WHERE deleted_date IS NULL;
+
+DROP INDEX IF EXISTS idx_payment_methods_name;
+CREATE UNIQUE INDEX idx_payment_methods_name ON payment_methods (name) WHERE deleted_date IS NULL;
+
+DROP INDEX IF EXISTS idx_ewallet_providers_provider_name;
+CREATE UNIQUE INDEX idx_ewallet_providers_provider_name ON ewallet_providers (provider_name) WHERE deleted_date IS NULL;
+
+DROP INDEX IF EXISTS idx_va_providers_provider_name;
+CREATE UNIQUE INDEX idx_va_providers_provider_name ON va_providers (provider_name) WHERE deleted_date IS NULL;
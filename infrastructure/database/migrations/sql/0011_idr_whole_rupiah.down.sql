@@ -0,0 +1,47 @@
+-- IDR amounts go back to hundredths of a rupiah.
+
+UPDATE payments p SET
+    amount = p.amount * 100,
+    fee_amount = p.fee_amount * 100,
+    fee_tax_amount = p.fee_tax_amount * 100,
+    net_amount = p.net_amount * 100
+FROM currencies c
+WHERE c.id = p.currency_id AND c.code = 'IDR' AND c.minor_unit = 0;
+
+UPDATE merchant_payment_methods m SET
+    min_amount = m.min_amount * 100,
+    max_amount = m.max_amount * 100,
+    daily_amount_cap = m.daily_amount_cap * 100
+FROM currencies c
+WHERE c.id = m.currency_id AND c.code = 'IDR' AND c.minor_unit = 0;
+
+UPDATE fee_schedules f SET
+    flat_amount = f.flat_amount * 100,
+    min_fee = f.min_fee * 100,
+    max_fee = f.max_fee * 100,
+    tiers = CASE WHEN jsonb_typeof(f.tiers) = 'array' THEN (
+        SELECT coalesce(jsonb_agg(t || jsonb_strip_nulls(jsonb_build_object(
+            'up_to', (t->>'up_to')::bigint * 100,
+            'flat_amount', (t->>'flat_amount')::bigint * 100
+        )) ORDER BY n), '[]')
+        FROM jsonb_array_elements(f.tiers) WITH ORDINALITY AS tier(t, n)
+    ) ELSE f.tiers END
+FROM currencies c
+WHERE c.id = f.currency_id AND c.code = 'IDR' AND c.minor_unit = 0;
+
+ALTER TABLE journal_lines DISABLE TRIGGER trg_journal_lines_immutable;
+
+UPDATE journal_lines l SET amount = l.amount * 100
+FROM journal_entries e
+WHERE e.id = l.journal_entry_id AND e.currency = 'IDR'
+AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 0);
+
+ALTER TABLE journal_lines ENABLE TRIGGER trg_journal_lines_immutable;
+
+UPDATE reconciliation_items SET
+    settlement_amount = settlement_amount * 100,
+    payment_amount = payment_amount * 100
+WHERE currency = 'IDR'
+AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 0);
+
+UPDATE currencies SET minor_unit = 2 WHERE code = 'IDR';
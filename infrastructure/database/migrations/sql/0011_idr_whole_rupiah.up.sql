@@ -0,0 +1,82 @@
+-- Nicepay takes and settles IDR in whole rupiah, so IDR has no minor digits.
+-- IDR amounts stored so far are hundredths of a rupiah and become rupiah;
+-- the migration fails rather than round any amount with cents.
+
+CREATE TEMPORARY TABLE idr_amounts ON COMMIT DROP AS
+SELECT amount FROM (
+    SELECT unnest(ARRAY[p.amount, p.fee_amount, p.fee_tax_amount, p.net_amount]) AS amount
+    FROM payments p JOIN currencies c ON c.id = p.currency_id
+    WHERE c.code = 'IDR' AND c.minor_unit = 2
+    UNION ALL
+    SELECT unnest(ARRAY[m.min_amount, m.max_amount, m.daily_amount_cap])
+    FROM merchant_payment_methods m JOIN currencies c ON c.id = m.currency_id
+    WHERE c.code = 'IDR' AND c.minor_unit = 2
+    UNION ALL
+    SELECT unnest(ARRAY[f.flat_amount, f.min_fee, f.max_fee, (t->>'up_to')::bigint, (t->>'flat_amount')::bigint])
+    FROM fee_schedules f JOIN currencies c ON c.id = f.currency_id
+    LEFT JOIN LATERAL jsonb_array_elements(CASE WHEN jsonb_typeof(f.tiers) = 'array' THEN f.tiers ELSE '[]' END) t ON true
+    WHERE c.code = 'IDR' AND c.minor_unit = 2
+    UNION ALL
+    SELECT l.amount
+    FROM journal_lines l JOIN journal_entries e ON e.id = l.journal_entry_id
+    WHERE e.currency = 'IDR' AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 2)
+    UNION ALL
+    SELECT unnest(ARRAY[r.settlement_amount, r.payment_amount])
+    FROM reconciliation_items r
+    WHERE r.currency = 'IDR' AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 2)
+) amounts;
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM idr_amounts WHERE amount % 100 <> 0) THEN
+        RAISE EXCEPTION 'IDR amounts with cents are stored; convert them to whole rupiah first';
+    END IF;
+END;
+$$;
+
+UPDATE payments p SET
+    amount = p.amount / 100,
+    fee_amount = p.fee_amount / 100,
+    fee_tax_amount = p.fee_tax_amount / 100,
+    net_amount = p.net_amount / 100
+FROM currencies c
+WHERE c.id = p.currency_id AND c.code = 'IDR' AND c.minor_unit = 2;
+
+UPDATE merchant_payment_methods m SET
+    min_amount = m.min_amount / 100,
+    max_amount = m.max_amount / 100,
+    daily_amount_cap = m.daily_amount_cap / 100
+FROM currencies c
+WHERE c.id = m.currency_id AND c.code = 'IDR' AND c.minor_unit = 2;
+
+UPDATE fee_schedules f SET
+    flat_amount = f.flat_amount / 100,
+    min_fee = f.min_fee / 100,
+    max_fee = f.max_fee / 100,
+    tiers = CASE WHEN jsonb_typeof(f.tiers) = 'array' THEN (
+        SELECT coalesce(jsonb_agg(t || jsonb_strip_nulls(jsonb_build_object(
+            'up_to', (t->>'up_to')::bigint / 100,
+            'flat_amount', (t->>'flat_amount')::bigint / 100
+        )) ORDER BY n), '[]')
+        FROM jsonb_array_elements(f.tiers) WITH ORDINALITY AS tier(t, n)
+    ) ELSE f.tiers END
+FROM currencies c
+WHERE c.id = f.currency_id AND c.code = 'IDR' AND c.minor_unit = 2;
+
+-- the journal is append-only; rescaling keeps every entry balanced
+ALTER TABLE journal_lines DISABLE TRIGGER trg_journal_lines_immutable;
+
+UPDATE journal_lines l SET amount = l.amount / 100
+FROM journal_entries e
+WHERE e.id = l.journal_entry_id AND e.currency = 'IDR'
+AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 2);
+
+ALTER TABLE journal_lines ENABLE TRIGGER trg_journal_lines_immutable;
+
+UPDATE reconciliation_items SET
+    settlement_amount = settlement_amount / 100,
+    payment_amount = payment_amount / 100
+WHERE currency = 'IDR'
+AND EXISTS (SELECT 1 FROM currencies WHERE code = 'IDR' AND minor_unit = 2);
+
+UPDATE currencies SET minor_unit = 0 WHERE code = 'IDR';
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+
+	"worker-nicepay/domain/fee"
+
+	"github.com/google/uuid"
+)
+
+// FeeSchedulesDataModel is the fee contract of a merchant's channel in one
+// currency from EffectiveFrom on. Amounts are in minor units of the currency,
+// percentages are decimal percents. Tiers holds the fee.Tier list of TIERED
+// schedules.
+type FeeSchedulesDataModel struct {
+	ID              uuid.UUID                `gorm:"primaryKey;column:id;type:uuid" json:"id"`
+	MerchantID      uuid.UUID                `gorm:"column:merchant_id;type:uuid" json:"merchant_id"`
+	PaymentMethodID uuid.UUID                `gorm:"column:payment_method_id;type:uuid" json:"payment_method_id"`
+	CurrencyID      uuid.UUID                `gorm:"column:currency_id;type:uuid" json:"currency_id"`
+	FeeType         string                   `gorm:"column:fee_type" json:"fee_type"`
+	Percentage      *string                  `gorm:"column:percentage;type:numeric(7,4)" json:"percentage"`
+	FlatAmount      int64                    `gorm:"column:flat_amount" json:"flat_amount"`
+	Tiers           json.RawMessage          `gorm:"column:tiers;type:jsonb" json:"tiers"`
+	MinFee          *int64                   `gorm:"column:min_fee" json:"min_fee"`
+	MaxFee          *int64                   `gorm:"column:max_fee" json:"max_fee"`
+	TaxPercentage   *string                  `gorm:"column:tax_percentage;type:numeric(7,4)" json:"tax_percentage"`
+	EffectiveFrom   int64                    `gorm:"column:effective_from" json:"effective_from"`
+	PaymentMethod   *PaymentMethodsDataModel `gorm:"foreignKey:PaymentMethodID;references:ID" json:"-"`
+	Currency        *CurrenciesDataModel     `gorm:"foreignKey:CurrencyID;references:ID" json:"-"`
+	CreatedDate     *int64                   `json:"created_date"`
+	CreatedUser     *string                  `json:"created_user"`
+	CreatedIp       *string                  `json:"created_ip"`
+	UpdatedDate     *int64                   `json:"updated_date"`
+	UpdatedUser     *string                  `json:"updated_user"`
+	UpdatedIp       *string                  `json:"updated_ip"`
+	DeletedDate     *int64                   `json:"deleted_date"`
+	DeletedUser     *string                  `json:"deleted_user"`
+	DeletedIp       *string                  `json:"deleted_ip"`
+	DataStatus      *string                  `json:"data_status"`
+}
+
+// Schedule returns the fee contract of the row.
+func (m FeeSchedulesDataModel) Schedule() (fee.Schedule, error) {
+	schedule := fee.Schedule{
+		Type:       fee.Type(m.FeeType),
+		FlatAmount: m.FlatAmount,
+		MinFee:     m.MinFee,
+		MaxFee:     m.MaxFee,
+	}
+	if m.Percentage != nil {
+		schedule.Percentage = *m.Percentage
+	}
+	if m.TaxPercentage != nil {
+		schedule.TaxPercentage = *m.TaxPercentage
+	}
+	if len(m.Tiers) > 0 && string(m.Tiers) != "null" {
+		if err := json.Unmarshal(m.Tiers, &schedule.Tiers); err != nil {
+			return schedule, err
+		}
+	}
+	return schedule, nil
+}
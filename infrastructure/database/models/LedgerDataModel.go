@@ -0,0 +1,42 @@
+package models
+
+import "github.com/google/uuid"
+
+// The ledger tables are append-only: rows carry no updated or deleted audit
+// columns and the database rejects updates and deletes.
+
+type LedgerAccountsDataModel struct {
+	ID          uuid.UUID  `gorm:"primaryKey;column:id;type:uuid"`
+	Code        string     `gorm:"column:code"`
+	AccountType string     `gorm:"column:account_type"`
+	MerchantID  *uuid.UUID `gorm:"column:merchant_id;type:uuid"`
+	Currency    string     `gorm:"column:currency"`
+	CreatedDate *int64
+	CreatedUser *string
+	CreatedIp   *string
+}
+
+type JournalEntriesDataModel struct {
+	ID             uuid.UUID               `gorm:"primaryKey;column:id;type:uuid"`
+	IdempotencyKey string                  `gorm:"column:idempotency_key"`
+	EntryType      string                  `gorm:"column:entry_type"`
+	PaymentID      *uuid.UUID              `gorm:"column:payment_id;type:uuid"`
+	Currency       string                  `gorm:"column:currency"`
+	Description    string                  `gorm:"column:description"`
+	PostedDate     int64                   `gorm:"column:posted_date"`
+	Lines          []JournalLinesDataModel `gorm:"foreignKey:JournalEntryID;references:ID"`
+	CreatedDate    *int64
+	CreatedUser    *string
+	CreatedIp      *string
+}
+
+type JournalLinesDataModel struct {
+	ID             uuid.UUID `gorm:"primaryKey;column:id;type:uuid"`
+	JournalEntryID uuid.UUID `gorm:"column:journal_entry_id;type:uuid"`
+	AccountID      uuid.UUID `gorm:"column:account_id;type:uuid"`
+	Direction      string    `gorm:"column:direction"`
+	Amount         int64     `gorm:"column:amount"` // in minor units of the entry currency
+	CreatedDate    *int64
+	CreatedUser    *string
+	CreatedIp      *string
+}
@@ -0,0 +1,33 @@
+package models
+
+import "github.com/google/uuid"
+
+// MerchantPaymentMethodsDataModel configures a channel for a merchant in one
+// currency. Amounts are in minor units of the currency; nil limits do not
+// apply. ActiveFrom and ActiveUntil are HH:MM in Timezone.
+type MerchantPaymentMethodsDataModel struct {
+	ID              uuid.UUID                `gorm:"primaryKey;column:id;type:uuid" json:"id"`
+	MerchantID      uuid.UUID                `gorm:"column:merchant_id;type:uuid" json:"merchant_id"`
+	PaymentMethodID uuid.UUID                `gorm:"column:payment_method_id;type:uuid" json:"payment_method_id"`
+	CurrencyID      uuid.UUID                `gorm:"column:currency_id;type:uuid" json:"currency_id"`
+	Enabled         bool                     `gorm:"column:enabled" json:"enabled"`
+	MinAmount       *int64                   `gorm:"column:min_amount" json:"min_amount"`
+	MaxAmount       *int64                   `gorm:"column:max_amount" json:"max_amount"`
+	DailyAmountCap  *int64                   `gorm:"column:daily_amount_cap" json:"daily_amount_cap"`
+	DailyCountCap   *int64                   `gorm:"column:daily_count_cap" json:"daily_count_cap"`
+	ActiveFrom      *string                  `gorm:"column:active_from" json:"active_from"`
+	ActiveUntil     *string                  `gorm:"column:active_until" json:"active_until"`
+	Timezone        string                   `gorm:"column:timezone" json:"timezone"`
+	PaymentMethod   *PaymentMethodsDataModel `gorm:"foreignKey:PaymentMethodID;references:ID" json:"-"`
+	Currency        *CurrenciesDataModel     `gorm:"foreignKey:CurrencyID;references:ID" json:"-"`
+	CreatedDate     *int64                   `json:"created_date"`
+	CreatedUser     *string                  `json:"created_user"`
+	CreatedIp       *string                  `json:"created_ip"`
+	UpdatedDate     *int64                   `json:"updated_date"`
+	UpdatedUser     *string                  `json:"updated_user"`
+	UpdatedIp       *string                  `json:"updated_ip"`
+	DeletedDate     *int64                   `json:"deleted_date"`
+	DeletedUser     *string                  `json:"deleted_user"`
+	DeletedIp       *string                  `json:"deleted_ip"`
+	DataStatus      *string                  `json:"data_status"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+type PaymentNicepayEWalletsDataModel struct {
+	ID                   uuid.UUID                  `gorm:"primaryKey;column:id;type:uuid"`
+	PaymentID            *uuid.UUID                 `gorm:"column:payment_id;type:uuid;uniqueIndex"`
+	Payment              *PaymentsDataModel         `gorm:"foreignKey:PaymentID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	TransactionID        *string                    `gorm:"column:transaction_id;uniqueIndex"`
+	URLReturn            string                     `gorm:"column:url_return"`
+	EWalletProviderID    *uuid.UUID                 `gorm:"column:e_wallet_provider_id;type:uuid"`
+	EWalletProvider      *EWalletProvidersDataModel `gorm:"foreignKey:EWalletProviderID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	CustomerUsername     *string                    `gorm:"column:customer_username"`
+	CustomerMSISDN       *string                    `gorm:"column:customer_msisdn"`
+	CustomerEmail        *string                    `gorm:"column:customer_email"`
+	RedirectURL          *string                    `gorm:"column:redirect_url"`
+	NicepayTransactionID *string                    `gorm:"column:nicepay_transaction_id;index"`
+	ResponseJson         json.RawMessage            `gorm:"column:response_json;type:jsonb"`
+	CreatedDate          *int64
+	CreatedUser          *string
+	CreatedIp            *string
+	UpdatedDate          *int64
+	UpdatedUser          *string
+	UpdatedIp            *string
+	DeletedDate          *int64
+	DeletedUser          *string
+	DeletedIp            *string
+	DataStatus           *string
+}
+
+func (PaymentNicepayEWalletsDataModel) TableName() string {
+	return "payment_nicepay_ewallets"
+}
@@ -14,11 +14,12 @@ type PaymentsDataModel struct {
 	ReferenceNo     *string                  `gorm:"column:reference_no"`
 	PaymentMethodID *uuid.UUID               `gorm:"column:payment_method_id;type:uuid"`
 	CurrencyID      *uuid.UUID               `gorm:"column:currency_id;type:uuid"`
-	Amount          *float64                 `gorm:"column:amount"`
+	Amount          *int64                   `gorm:"column:amount"` // in minor units of the currency
 	Description     *string                  `gorm:"column:description"`
 	Status          *string                  `gorm:"column:status"`
 	ExpiredPayment  *time.Time               `gorm:"column:expired_payment"`
 	CallbackURL     *string                  `gorm:"column:callback_url"`
+	RedirectURL     *string                  `gorm:"column:redirect_url"`
 	MerchantID      *uuid.UUID               `gorm:"column:merchant_id;type:uuid"`
 	CountryID       *uuid.UUID               `gorm:"column:country_id;type:uuid"`
 	Merchant        *MerchantsDataModel      `gorm:"foreignKey:MerchantID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
@@ -26,14 +27,21 @@ type PaymentsDataModel struct {
 	Currency        *CurrenciesDataModel     `gorm:"foreignKey:CurrencyID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Country         *CountriesDataModel      `gorm:"foreignKey:CountryID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	ResponseJson    json.RawMessage          `gorm:"column:response_json;type:jsonb"`
-	CreatedDate     *int64
-	CreatedUser     *string
-	CreatedIp       *string
-	UpdatedDate     *int64
-	UpdatedUser     *string
-	UpdatedIp       *string
-	DeletedDate     *int64
-	DeletedUser     *string
-	DeletedIp       *string
-	DataStatus      *string
+	// Amount is the gross amount; fees are in minor units of the currency
+	FeeScheduleID     *uuid.UUID `gorm:"column:fee_schedule_id;type:uuid"`
+	FeeAmount         *int64     `gorm:"column:fee_amount"`
+	FeeTaxAmount      *int64     `gorm:"column:fee_tax_amount"`
+	NetAmount         *int64     `gorm:"column:net_amount"`
+	FeeCalculatedDate *int64     `gorm:"column:fee_calculated_date"`
+	ResolvingUntil    *int64     `gorm:"column:resolving_until"`
+	CreatedDate       *int64
+	CreatedUser       *string
+	CreatedIp         *string
+	UpdatedDate       *int64
+	UpdatedUser       *string
+	UpdatedIp         *string
+	DeletedDate       *int64
+	DeletedUser       *string
+	DeletedIp         *string
+	DataStatus        *string
 }
@@ -0,0 +1,55 @@
+package models
+
+import "github.com/google/uuid"
+
+// ReconciliationRunsDataModel is the outcome of reconciling one settlement
+// file. Mapping is the column mapping the file was read with.
+type ReconciliationRunsDataModel struct {
+	ID                uuid.UUID `gorm:"primaryKey;column:id;type:uuid" json:"id"`
+	FileName          string    `gorm:"column:file_name" json:"file_name"`
+	Status            string    `gorm:"column:status" json:"status"`
+	Mapping           string    `gorm:"column:mapping" json:"mapping"`
+	PeriodFrom        *int64    `gorm:"column:period_from" json:"period_from"`
+	PeriodTo          *int64    `gorm:"column:period_to" json:"period_to"`
+	TotalRows         int       `gorm:"column:total_rows" json:"total_rows"`
+	Matched           int       `gorm:"column:matched" json:"matched"`
+	Mismatched        int       `gorm:"column:mismatched" json:"mismatched"`
+	MissingPayment    int       `gorm:"column:missing_payment" json:"missing_payment"`
+	MissingSettlement int       `gorm:"column:missing_settlement" json:"missing_settlement"`
+	Invalid           int       `gorm:"column:invalid" json:"invalid"`
+	Error             *string   `gorm:"column:error" json:"error,omitempty"`
+	FinishedDate      *int64    `gorm:"column:finished_date" json:"finished_date"`
+	CreatedDate       *int64    `json:"created_date"`
+	CreatedUser       *string   `json:"created_user"`
+	CreatedIp         *string   `json:"created_ip"`
+	UpdatedDate       *int64    `json:"updated_date"`
+	UpdatedUser       *string   `json:"updated_user"`
+	UpdatedIp         *string   `json:"updated_ip"`
+	DeletedDate       *int64    `json:"deleted_date"`
+	DeletedUser       *string   `json:"deleted_user"`
+	DeletedIp         *string   `json:"deleted_ip"`
+	DataStatus        *string   `json:"data_status"`
+}
+
+// ReconciliationItemsDataModel is a reconciled settlement row, or a paid
+// payment missing from the file. Amounts are in minor units of the payment
+// currency; Mismatches lists the disagreeing fields, comma separated.
+type ReconciliationItemsDataModel struct {
+	ID               uuid.UUID  `gorm:"primaryKey;column:id;type:uuid" json:"id"`
+	RunID            uuid.UUID  `gorm:"column:run_id;type:uuid" json:"run_id"`
+	LineNo           *int       `gorm:"column:line_no" json:"line_no"`
+	TransactionID    *string    `gorm:"column:transaction_id" json:"transaction_id"`
+	ReferenceNo      *string    `gorm:"column:reference_no" json:"reference_no"`
+	PaymentID        *uuid.UUID `gorm:"column:payment_id;type:uuid" json:"payment_id"`
+	Currency         *string    `gorm:"column:currency" json:"currency"`
+	SettlementAmount *int64     `gorm:"column:settlement_amount" json:"settlement_amount"`
+	PaymentAmount    *int64     `gorm:"column:payment_amount" json:"payment_amount"`
+	SettlementStatus *string    `gorm:"column:settlement_status" json:"settlement_status"`
+	PaymentStatus    *string    `gorm:"column:payment_status" json:"payment_status"`
+	Result           string     `gorm:"column:result" json:"result"`
+	Mismatches       *string    `gorm:"column:mismatches" json:"mismatches"`
+	Detail           *string    `gorm:"column:detail" json:"detail"`
+	CreatedDate      *int64     `json:"created_date"`
+	CreatedUser      *string    `json:"created_user"`
+	CreatedIp        *string    `json:"created_ip"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ScheduledPaymentJobsDataModel struct {
+	ID         uuid.UUID       `gorm:"primaryKey;column:id;type:uuid"`
+	JobID      string          `gorm:"column:job_id;uniqueIndex"`
+	MerchantID string          `gorm:"column:merchant_id;index"`
+	RunAt      time.Time       `gorm:"column:run_at;index"`
+	Status     string          `gorm:"column:status;index"`
+	Priority   bool            `gorm:"column:priority"`
+	Request    json.RawMessage `gorm:"column:request;type:jsonb"`
+	Incoming   json.RawMessage `gorm:"column:incoming;type:jsonb"`
+	ReleasedAt *time.Time      `gorm:"column:released_at"`
+	// ClaimedUntil is the end of the lease of the replica releasing the job
+	ClaimedUntil *time.Time `gorm:"column:claimed_until"`
+	CreatedDate  *int64
+	CreatedUser  *string
+	CreatedIp    *string
+	UpdatedDate  *int64
+	UpdatedUser  *string
+	UpdatedIp    *string
+	DeletedDate  *int64
+	DeletedUser  *string
+	DeletedIp    *string
+	DataStatus   *string
+}
+
+func (ScheduledPaymentJobsDataModel) TableName() string {
+	return "scheduled_payment_jobs"
+}
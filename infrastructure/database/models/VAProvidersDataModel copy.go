@@ -3,17 +3,17 @@ package models
 import "github.com/google/uuid"
 
 type VAProvidersDataModel struct {
-	ID           uuid.UUID `gorm:"primaryKey;column:id;type:uuid"`
-	Name         string    `gorm:"column:name"`
-	ProviderName string    `gorm:"column:provider_name;uniqueIndex"`
-	CreatedDate  *int64
-	CreatedUser  *string
-	CreatedIp    *string
-	UpdatedDate  *int64
-	UpdatedUser  *string
-	UpdatedIp    *string
-	DeletedDate  *int64
-	DeletedUser  *string
-	DeletedIp    *string
-	DataStatus   *string
+	ID           uuid.UUID `gorm:"primaryKey;column:id;type:uuid" json:"id"`
+	Name         string    `gorm:"column:name" json:"name"`
+	ProviderName string    `gorm:"column:provider_name;uniqueIndex" json:"provider_name"`
+	CreatedDate  *int64    `json:"created_date"`
+	CreatedUser  *string   `json:"created_user"`
+	CreatedIp    *string   `json:"created_ip"`
+	UpdatedDate  *int64    `json:"updated_date"`
+	UpdatedUser  *string   `json:"updated_user"`
+	UpdatedIp    *string   `json:"updated_ip"`
+	DeletedDate  *int64    `json:"deleted_date"`
+	DeletedUser  *string   `json:"deleted_user"`
+	DeletedIp    *string   `json:"deleted_ip"`
+	DataStatus   *string   `json:"data_status"`
 }
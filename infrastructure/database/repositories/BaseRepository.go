@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"errors"
+
+	"worker-nicepay/domain/apperror"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BaseRepository implements the common reads and writes of a table with the
+// audit and soft-delete columns. Reads only see active rows unless a
+// QueryOption says otherwise; kind names the row in NotFound errors.
+type BaseRepository[M any] struct {
+	kind string
+}
+
+func NewBaseRepository[M any](kind string) BaseRepository[M] {
+	return BaseRepository[M]{kind: kind}
+}
+
+func (r BaseRepository[M]) Insert(tx *gorm.DB, model *M) error {
+	if tx == nil || model == nil {
+		return nil
+	}
+	return tx.Create(model).Error
+}
+
+func (r BaseRepository[M]) FindAll(tx *gorm.DB, opts ...QueryOption) ([]M, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var rows []M
+	err := tx.Scopes(Active(opts...)).Find(&rows).Error
+	return rows, err
+}
+
+func (r BaseRepository[M]) FindByID(tx *gorm.DB, id uuid.UUID, opts ...QueryOption) (*M, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	row, err := r.first(tx, opts, "id = ?", id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.NotFound(r.kind, id.String())
+	}
+	return row, err
+}
+
+// Update updates a row that is not deleted and fails with NotFound when
+// there is none. Inactive rows can be updated.
+func (r BaseRepository[M]) Update(tx *gorm.DB, id uuid.UUID, values map[string]interface{}) error {
+	if tx == nil {
+		return nil
+	}
+	res := tx.Model(new(M)).Scopes(NotDeleted).Where("id = ?", id).Updates(values)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperror.NotFound(r.kind, id.String())
+	}
+	return nil
+}
+
+// SoftDelete soft-deletes a row; the audit callback records the user and IP
+// of the actor in tx's context. Deleting a row twice fails with NotFound.
+func (r BaseRepository[M]) SoftDelete(tx *gorm.DB, id uuid.UUID) error {
+	if tx == nil {
+		return nil
+	}
+	res := tx.Where("id = ?", id).Delete(new(M))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperror.NotFound(r.kind, id.String())
+	}
+	return nil
+}
+
+// first returns the first matching row, or gorm.ErrRecordNotFound for the
+// caller to map.
+func (r BaseRepository[M]) first(tx *gorm.DB, opts []QueryOption, query interface{}, args ...interface{}) (*M, error) {
+	var row M
+	if err := tx.Scopes(Active(opts...)).Where(query, args...).First(&row).Error; err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// upsert inserts rows keyed by the unique column key. Existing rows that are
+// not deleted get the given columns overwritten, but only when one of them
+// changed, so re-running a seed leaves the audit columns of unchanged rows
+// alone. It returns the number of inserted or updated rows.
+func (r BaseRepository[M]) upsert(tx *gorm.DB, rows []M, key string, columns ...string) (int64, error) {
+	if tx == nil || len(rows) == 0 {
+		return 0, nil
+	}
+
+	onConflict := clause.OnConflict{
+		Columns:     []clause.Column{{Name: key}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "deleted_date IS NULL"}}},
+	}
+	if len(columns) == 0 {
+		onConflict.DoNothing = true
+	} else {
+		changed := make([]clause.Expression, 0, len(columns))
+		for _, column := range columns {
+			onConflict.DoUpdates = append(onConflict.DoUpdates, clause.Assignment{
+				Column: clause.Column{Name: column},
+				Value:  clause.Column{Table: "excluded", Name: column},
+			})
+			changed = append(changed, clause.Expr{
+				SQL:  "? IS DISTINCT FROM ?",
+				Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: column}, clause.Column{Table: "excluded", Name: column}},
+			})
+		}
+		onConflict.DoUpdates = append(onConflict.DoUpdates,
+			clause.Assignment{Column: clause.Column{Name: "updated_date"}, Value: clause.Column{Table: "excluded", Name: "created_date"}},
+			clause.Assignment{Column: clause.Column{Name: "updated_user"}, Value: clause.Column{Table: "excluded", Name: "created_user"}},
+		)
+		onConflict.Where = clause.Where{Exprs: []clause.Expression{clause.Or(changed...)}}
+	}
+
+	res := tx.Clauses(onConflict).Create(&rows)
+	return res.RowsAffected, res.Error
+}
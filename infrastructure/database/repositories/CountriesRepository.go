@@ -2,44 +2,32 @@ package repositories
 
 import (
 	"errors"
+	"worker-nicepay/domain/apperror"
 	"worker-nicepay/infrastructure/database/models"
 
 	"gorm.io/gorm"
 )
 
-type CountriesRepository struct{}
-
-func NewCountriesRepository() *CountriesRepository {
-	return &CountriesRepository{}
+type CountriesRepository struct {
+	BaseRepository[models.CountriesDataModel]
 }
 
-func (r *CountriesRepository) Insert(tx *gorm.DB, model *models.CountriesDataModel) error {
-	if tx == nil || model == nil {
-		return nil
-	}
-	return tx.Create(model).Error
+func NewCountriesRepository() *CountriesRepository {
+	return &CountriesRepository{BaseRepository: NewBaseRepository[models.CountriesDataModel]("country")}
 }
 
-func (r *CountriesRepository) FindAll(tx *gorm.DB) ([]models.CountriesDataModel, error) {
+func (r *CountriesRepository) FindByCountryID(tx *gorm.DB, countryID string, opts ...QueryOption) (*models.CountriesDataModel, error) {
 	if tx == nil {
 		return nil, nil
 	}
-	var countries []models.CountriesDataModel
-	err := tx.Find(&countries).Error
-	return countries, err
+	country, err := r.first(tx, opts, "code = ?", countryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.MasterDataMissing("country", countryID)
+	}
+	return country, err
 }
 
-func (r *CountriesRepository) FindByCountryID(tx *gorm.DB, countryID string) (*models.CountriesDataModel, error) {
-	if tx == nil {
-		return nil, nil
-	}
-	var country models.CountriesDataModel
-	err := tx.Where("country_id = ?", countryID).First(&country).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	return &country, nil
+// Upsert inserts seed rows by code and updates the name of existing rows.
+func (r *CountriesRepository) Upsert(tx *gorm.DB, rows []models.CountriesDataModel) (int64, error) {
+	return r.upsert(tx, rows, "code", "name")
 }
@@ -2,44 +2,45 @@ package repositories
 
 import (
 	"errors"
+	"worker-nicepay/domain/apperror"
 	"worker-nicepay/infrastructure/database/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-type CurrenciesRepository struct{}
-
-func NewCurrenciesRepository() *CurrenciesRepository {
-	return &CurrenciesRepository{}
+type CurrenciesRepository struct {
+	BaseRepository[models.CurrenciesDataModel]
 }
 
-func (r *CurrenciesRepository) Insert(tx *gorm.DB, model *models.CurrenciesDataModel) error {
-	if tx == nil || model == nil {
-		return nil
-	}
-	return tx.Create(model).Error
+func NewCurrenciesRepository() *CurrenciesRepository {
+	return &CurrenciesRepository{BaseRepository: NewBaseRepository[models.CurrenciesDataModel]("currency")}
 }
 
-func (r *CurrenciesRepository) FindAll(tx *gorm.DB) ([]models.CurrenciesDataModel, error) {
+func (r *CurrenciesRepository) FindByCode(tx *gorm.DB, code string, opts ...QueryOption) (*models.CurrenciesDataModel, error) {
 	if tx == nil {
 		return nil, nil
 	}
-	var currencies []models.CurrenciesDataModel
-	err := tx.Find(&currencies).Error
-	return currencies, err
+	currency, err := r.first(tx, opts, "code = ?", code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.MasterDataMissing("currency", code)
+	}
+	return currency, err
 }
 
-func (r *CurrenciesRepository) FindByCode(tx *gorm.DB, code string) (*models.CurrenciesDataModel, error) {
+func (r *CurrenciesRepository) FindByID(tx *gorm.DB, id uuid.UUID, opts ...QueryOption) (*models.CurrenciesDataModel, error) {
 	if tx == nil {
 		return nil, nil
 	}
-	var currency models.CurrenciesDataModel
-	err := tx.Where("code = ?", code).First(&currency).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
-		}
-		return nil, err
+	currency, err := r.first(tx, opts, "id = ?", id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.MasterDataMissing("currency", id.String())
 	}
-	return &currency, nil
+	return currency, err
+}
+
+// Upsert inserts seed rows by code and updates the name and minor unit of
+// existing rows.
+func (r *CurrenciesRepository) Upsert(tx *gorm.DB, rows []models.CurrenciesDataModel) (int64, error) {
+	return r.upsert(tx, rows, "code", "name", "minor_unit")
 }
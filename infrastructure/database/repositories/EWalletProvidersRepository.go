@@ -1,29 +1,34 @@
 package repositories
 
 import (
+	"errors"
+
 	"worker-nicepay/infrastructure/database/models"
 
 	"gorm.io/gorm"
 )
 
-type EWalletProvidersRepository struct{}
-
-func NewEWalletProvidersRepository() *EWalletProvidersRepository {
-	return &EWalletProvidersRepository{}
+type EWalletProvidersRepository struct {
+	BaseRepository[models.EWalletProvidersDataModel]
 }
 
-func (r *EWalletProvidersRepository) Insert(tx *gorm.DB, model *models.EWalletProvidersDataModel) error {
-	if tx == nil || model == nil {
-		return nil
-	}
-	return tx.Create(model).Error
+func NewEWalletProvidersRepository() *EWalletProvidersRepository {
+	return &EWalletProvidersRepository{BaseRepository: NewBaseRepository[models.EWalletProvidersDataModel]("e-wallet provider")}
 }
 
-func (r *EWalletProvidersRepository) FindAll(tx *gorm.DB) ([]models.EWalletProvidersDataModel, error) {
+// FindByProviderName returns nil when the channel is not a registered e-wallet provider.
+func (r *EWalletProvidersRepository) FindByProviderName(tx *gorm.DB, providerName string, opts ...QueryOption) (*models.EWalletProvidersDataModel, error) {
 	if tx == nil {
 		return nil, nil
 	}
-	var providers []models.EWalletProvidersDataModel
-	err := tx.Find(&providers).Error
-	return providers, err
+	provider, err := r.first(tx, opts, "provider_name = ?", providerName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return provider, err
+}
+
+// Upsert inserts seed rows by provider name and updates the name of existing rows.
+func (r *EWalletProvidersRepository) Upsert(tx *gorm.DB, rows []models.EWalletProvidersDataModel) (int64, error) {
+	return r.upsert(tx, rows, "provider_name", "name")
 }
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"errors"
+
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FeeSchedulesRepository struct {
+	BaseRepository[models.FeeSchedulesDataModel]
+}
+
+func NewFeeSchedulesRepository() *FeeSchedulesRepository {
+	return &FeeSchedulesRepository{BaseRepository: NewBaseRepository[models.FeeSchedulesDataModel]("fee schedule")}
+}
+
+// FindByMerchant returns the fee schedules of a merchant with their payment
+// method and currency loaded, newest contract first.
+func (r *FeeSchedulesRepository) FindByMerchant(tx *gorm.DB, merchantID uuid.UUID, opts ...QueryOption) ([]models.FeeSchedulesDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var rows []models.FeeSchedulesDataModel
+	err := tx.Scopes(Active(opts...)).
+		Preload("PaymentMethod").
+		Preload("Currency").
+		Where("merchant_id = ?", merchantID).
+		Order("effective_from DESC").
+		Find(&rows).Error
+	return rows, err
+}
+
+// FindEffective returns the schedule of a merchant's channel in a currency in
+// force at the given time. It returns nil when the channel has no schedule.
+func (r *FeeSchedulesRepository) FindEffective(tx *gorm.DB, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, at int64) (*models.FeeSchedulesDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var row models.FeeSchedulesDataModel
+	err := tx.Scopes(Active()).
+		Where("merchant_id = ? AND payment_method_id = ? AND currency_id = ? AND effective_from <= ?", merchantID, paymentMethodID, currencyID, at).
+		Order("effective_from DESC").
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	debitSum  = "COALESCE(SUM(CASE WHEN journal_lines.direction = 'DEBIT' THEN journal_lines.amount END), 0)"
+	creditSum = "COALESCE(SUM(CASE WHEN journal_lines.direction = 'CREDIT' THEN journal_lines.amount END), 0)"
+)
+
+type LedgerRepository struct{}
+
+func NewLedgerRepository() *LedgerRepository {
+	return &LedgerRepository{}
+}
+
+// Post writes a balanced journal entry and its lines, opening missing
+// accounts. It returns false when an entry with the same key was posted
+// before. Must run inside a transaction so the entry is posted whole.
+func (r *LedgerRepository) Post(tx *gorm.DB, entry ledger.Entry) (bool, error) {
+	if tx == nil {
+		return false, nil
+	}
+	if err := entry.Validate(); err != nil {
+		return false, err
+	}
+
+	row := models.JournalEntriesDataModel{
+		IdempotencyKey: entry.Key,
+		EntryType:      string(entry.Type),
+		Currency:       entry.Currency,
+		Description:    entry.Description,
+		PostedDate:     entry.PostedAt.UnixMilli(),
+	}
+	if entry.PaymentID != uuid.Nil {
+		row.PaymentID = &entry.PaymentID
+	}
+	res := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "idempotency_key"}}, DoNothing: true}).
+		Omit("Lines").
+		Create(&row)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return false, nil
+	}
+
+	lines := make([]models.JournalLinesDataModel, 0, len(entry.Lines))
+	for _, line := range entry.Lines {
+		account, err := r.account(tx, line.Account)
+		if err != nil {
+			return false, err
+		}
+		lines = append(lines, models.JournalLinesDataModel{
+			JournalEntryID: row.ID,
+			AccountID:      account.ID,
+			Direction:      string(line.Direction),
+			Amount:         line.Amount,
+		})
+	}
+	return true, tx.Create(&lines).Error
+}
+
+// account returns the ledger account, opening it on first use.
+func (r *LedgerRepository) account(tx *gorm.DB, account ledger.Account) (*models.LedgerAccountsDataModel, error) {
+	var row models.LedgerAccountsDataModel
+	err := tx.Where("code = ?", account.Code()).First(&row).Error
+	if err == nil {
+		return &row, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	row = models.LedgerAccountsDataModel{
+		Code:        account.Code(),
+		AccountType: string(account.Type),
+		MerchantID:  account.MerchantID,
+		Currency:    account.Currency,
+	}
+	// a concurrent posting may open the same account
+	err = tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "code"}}, DoNothing: true}).Create(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("code = ?", account.Code()).First(&row).Error; err != nil {
+		return nil, fmt.Errorf("failed to open ledger account %s: %w", account.Code(), err)
+	}
+	return &row, nil
+}
+
+// Refunds sums the refunds journaled for a payment by entry key.
+func (r *LedgerRepository) Refunds(tx *gorm.DB, paymentID uuid.UUID) (map[string]int64, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var rows []struct {
+		IdempotencyKey string
+		Amount         int64
+	}
+	err := tx.Table("journal_entries").
+		Select("journal_entries.idempotency_key, "+debitSum+" AS amount").
+		Joins("JOIN journal_lines ON journal_lines.journal_entry_id = journal_entries.id").
+		Where("journal_entries.payment_id = ? AND journal_entries.entry_type = ?", paymentID, string(ledger.RefundCompleted)).
+		Group("journal_entries.idempotency_key").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		refunds[row.IdempotencyKey] = row.Amount
+	}
+	return refunds, nil
+}
+
+// Balances sums the lines of each account posted up to asOf, in unix
+// milliseconds. A nil merchantID returns every account of the ledger.
+func (r *LedgerRepository) Balances(tx *gorm.DB, merchantID *uuid.UUID, asOf int64) ([]ledger.Balance, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var rows []struct {
+		AccountType string
+		MerchantID  *uuid.UUID
+		Currency    string
+		Debit       int64
+		Credit      int64
+	}
+	query := tx.Table("ledger_accounts").
+		Select("ledger_accounts.account_type, ledger_accounts.merchant_id, ledger_accounts.currency, "+debitSum+" AS debit, "+creditSum+" AS credit").
+		Joins("JOIN journal_lines ON journal_lines.account_id = ledger_accounts.id").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Where("journal_entries.posted_date <= ?", asOf).
+		Group("ledger_accounts.id, ledger_accounts.account_type, ledger_accounts.merchant_id, ledger_accounts.currency").
+		Order("ledger_accounts.code")
+	if merchantID != nil {
+		query = query.Where("ledger_accounts.merchant_id = ?", *merchantID)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	balances := make([]ledger.Balance, 0, len(rows))
+	for _, row := range rows {
+		account := ledger.Account{Type: ledger.AccountType(row.AccountType), MerchantID: row.MerchantID, Currency: row.Currency}
+		balances = append(balances, ledger.NewBalance(account, row.Debit, row.Credit))
+	}
+	return balances, nil
+}
+
+// Check verifies that every journal entry has at least two lines whose
+// debits equal its credits, and that the ledger balances per currency.
+func (r *LedgerRepository) Check(tx *gorm.DB) (ledger.CheckReport, error) {
+	report := ledger.CheckReport{CheckedAt: time.Now()}
+	if tx == nil {
+		return report, nil
+	}
+	if err := tx.Model(&models.JournalEntriesDataModel{}).Count(&report.Entries).Error; err != nil {
+		return report, err
+	}
+	err := tx.Table("journal_entries").
+		Select("journal_entries.id AS entry_id, journal_entries.idempotency_key AS key, " + debitSum + " AS debit, " + creditSum + " AS credit").
+		Joins("LEFT JOIN journal_lines ON journal_lines.journal_entry_id = journal_entries.id").
+		Group("journal_entries.id, journal_entries.idempotency_key").
+		Having(debitSum + " <> " + creditSum + " OR COUNT(journal_lines.id) < 2").
+		Scan(&report.Unbalanced).Error
+	if err != nil {
+		return report, err
+	}
+	err = tx.Table("journal_lines").
+		Select("journal_entries.currency, " + debitSum + " AS debit, " + creditSum + " AS credit").
+		Joins("JOIN journal_entries ON journal_entries.id = journal_lines.journal_entry_id").
+		Group("journal_entries.currency").
+		Order("journal_entries.currency").
+		Scan(&report.Totals).Error
+	if err != nil {
+		return report, err
+	}
+	report.Verify()
+	return report, nil
+}
+
+// StatementEntry is a journal entry on a merchant's payable account, with the
+// merchant's side and the fee and tax it paid, in minor units.
+type StatementEntry struct {
+	EntryID       uuid.UUID
+	EntryType     string
+	PostedDate    int64
+	Currency      string
+	TransactionID *string
+	ReferenceNo   *string
+	Channel       *string
+	Credit        int64
+	Debit         int64
+	Fee           int64
+	Tax           int64
+}
+
+// EachStatementEntry calls fn for the entries on the merchant's accounts
+// posted in [from, to), in unix milliseconds, in posting order. Rows are
+// read one at a time, so long periods are not held in memory.
+func (r *LedgerRepository) EachStatementEntry(tx *gorm.DB, merchantID uuid.UUID, from int64, to int64, fn func(entry StatementEntry) error) error {
+	if tx == nil {
+		return nil
+	}
+	merchantEntries := tx.Table("journal_lines").
+		Select("journal_lines.journal_entry_id").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = journal_lines.account_id").
+		Where("ledger_accounts.merchant_id = ?", merchantID)
+	rows, err := tx.Table("journal_entries").
+		Select("journal_entries.id AS entry_id, journal_entries.entry_type, journal_entries.posted_date, journal_entries.currency, "+
+			"payments.transaction_id, payments.reference_no, payment_methods.name AS channel, "+
+			"COALESCE(SUM(CASE WHEN ledger_accounts.merchant_id = ? AND journal_lines.direction = 'CREDIT' THEN journal_lines.amount END), 0) AS credit, "+
+			"COALESCE(SUM(CASE WHEN ledger_accounts.merchant_id = ? AND journal_lines.direction = 'DEBIT' THEN journal_lines.amount END), 0) AS debit, "+
+			"COALESCE(SUM(CASE WHEN ledger_accounts.account_type = ? THEN journal_lines.amount END), 0) AS fee, "+
+			"COALESCE(SUM(CASE WHEN ledger_accounts.account_type = ? THEN journal_lines.amount END), 0) AS tax",
+			merchantID, merchantID, string(ledger.FeeRevenue), string(ledger.TaxPayable)).
+		Joins("JOIN journal_lines ON journal_lines.journal_entry_id = journal_entries.id").
+		Joins("JOIN ledger_accounts ON ledger_accounts.id = journal_lines.account_id").
+		Joins("LEFT JOIN payments ON payments.id = journal_entries.payment_id").
+		Joins("LEFT JOIN payment_methods ON payment_methods.id = payments.payment_method_id").
+		Where("journal_entries.id IN (?)", merchantEntries).
+		Where("journal_entries.posted_date >= ? AND journal_entries.posted_date < ?", from, to).
+		Group("journal_entries.id, journal_entries.entry_type, journal_entries.posted_date, journal_entries.currency, " +
+			"payments.transaction_id, payments.reference_no, payment_methods.name").
+		Order("journal_entries.posted_date, journal_entries.id").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry StatementEntry
+		if err := tx.ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
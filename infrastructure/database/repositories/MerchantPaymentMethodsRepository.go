@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"errors"
+
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MerchantPaymentMethodsRepository struct {
+	BaseRepository[models.MerchantPaymentMethodsDataModel]
+}
+
+func NewMerchantPaymentMethodsRepository() *MerchantPaymentMethodsRepository {
+	return &MerchantPaymentMethodsRepository{BaseRepository: NewBaseRepository[models.MerchantPaymentMethodsDataModel]("merchant payment method")}
+}
+
+// FindByMerchant returns the channels of a merchant with their payment
+// method and currency loaded.
+func (r *MerchantPaymentMethodsRepository) FindByMerchant(tx *gorm.DB, merchantID uuid.UUID, opts ...QueryOption) ([]models.MerchantPaymentMethodsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var rows []models.MerchantPaymentMethodsDataModel
+	err := tx.Scopes(Active(opts...)).
+		Preload("PaymentMethod").
+		Preload("Currency").
+		Where("merchant_id = ?", merchantID).
+		Order("created_date").
+		Find(&rows).Error
+	return rows, err
+}
+
+// FindForUpdate locks the merchant's configuration of a channel in a
+// currency, so concurrent payments are checked against the daily caps one
+// at a time. It returns nil when the channel is not configured.
+func (r *MerchantPaymentMethodsRepository) FindForUpdate(tx *gorm.DB, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID) (*models.MerchantPaymentMethodsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	row, err := r.first(tx.Clauses(clause.Locking{Strength: "UPDATE"}), nil,
+		"merchant_id = ? AND payment_method_id = ? AND currency_id = ?", merchantID, paymentMethodID, currencyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return row, err
+}
+
+// HasAny reports whether the merchant has any channel configured.
+func (r *MerchantPaymentMethodsRepository) HasAny(tx *gorm.DB, merchantID uuid.UUID) (bool, error) {
+	if tx == nil {
+		return false, nil
+	}
+	var count int64
+	err := tx.Model(&models.MerchantPaymentMethodsDataModel{}).
+		Scopes(Active()).
+		Where("merchant_id = ?", merchantID).
+		Count(&count).Error
+	return count > 0, err
+}
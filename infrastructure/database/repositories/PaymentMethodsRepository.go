@@ -1,38 +1,34 @@
 package repositories
 
 import (
+	"errors"
+	"worker-nicepay/domain/apperror"
 	"worker-nicepay/infrastructure/database/models"
 
 	"gorm.io/gorm"
 )
 
-type PaymentMethodsRepository struct{}
-
-func NewPaymentMethodsRepository() *PaymentMethodsRepository {
-	return &PaymentMethodsRepository{}
-}
-
-func (r *PaymentMethodsRepository) Insert(tx *gorm.DB, model *models.PaymentMethodsDataModel) error {
-	if tx == nil || model == nil {
-		return nil
-	}
-	return tx.Create(model).Error
+type PaymentMethodsRepository struct {
+	BaseRepository[models.PaymentMethodsDataModel]
 }
 
-func (r *PaymentMethodsRepository) FindAll(tx *gorm.DB) ([]models.PaymentMethodsDataModel, error) {
-	if tx == nil {
-		return nil, nil
-	}
-	var methods []models.PaymentMethodsDataModel
-	err := tx.Find(&methods).Error
-	return methods, err
+func NewPaymentMethodsRepository() *PaymentMethodsRepository {
+	return &PaymentMethodsRepository{BaseRepository: NewBaseRepository[models.PaymentMethodsDataModel]("payment method")}
 }
 
-func (r *PaymentMethodsRepository) FindOne(tx *gorm.DB, where models.PaymentMethodsDataModel) (models.PaymentMethodsDataModel, error) {
+func (r *PaymentMethodsRepository) FindOne(tx *gorm.DB, where models.PaymentMethodsDataModel, opts ...QueryOption) (models.PaymentMethodsDataModel, error) {
 	if tx == nil {
 		return models.PaymentMethodsDataModel{}, nil
 	}
 	var method models.PaymentMethodsDataModel
-	err := tx.Where(&where).Last(&method).Error
+	err := tx.Scopes(Active(opts...)).Where(&where).Last(&method).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return method, apperror.InvalidChannel(where.Name)
+	}
 	return method, err
 }
+
+// Upsert inserts seed rows by name and leaves existing rows alone.
+func (r *PaymentMethodsRepository) Upsert(tx *gorm.DB, rows []models.PaymentMethodsDataModel) (int64, error) {
+	return r.upsert(tx, rows, "name")
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"errors"
+
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentNicepayEWalletsRepository struct{}
+
+func NewPaymentNicepayEWalletsRepository() *PaymentNicepayEWalletsRepository {
+	return &PaymentNicepayEWalletsRepository{}
+}
+
+func (r *PaymentNicepayEWalletsRepository) Insert(tx *gorm.DB, model *models.PaymentNicepayEWalletsDataModel) error {
+	if tx == nil || model == nil {
+		return nil
+	}
+	return tx.Create(model).Error
+}
+
+func (r *PaymentNicepayEWalletsRepository) UpdateByPaymentID(tx *gorm.DB, paymentID uuid.UUID, values map[string]interface{}) error {
+	if tx == nil {
+		return nil
+	}
+	return tx.Model(&models.PaymentNicepayEWalletsDataModel{}).Where("payment_id = ?", paymentID).Updates(values).Error
+}
+
+func (r *PaymentNicepayEWalletsRepository) FindByPaymentID(tx *gorm.DB, paymentID uuid.UUID) (*models.PaymentNicepayEWalletsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var ewallet models.PaymentNicepayEWalletsDataModel
+	err := tx.Scopes(Active()).Preload("EWalletProvider").Where("payment_id = ?", paymentID).First(&ewallet).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ewallet, nil
+}
+
+// FindByNicepayTransactionIDs returns the e-wallet details Nicepay knows
+// under any of the transaction IDs, with their payment and its currency
+// loaded. Details of deleted payments have no payment.
+func (r *PaymentNicepayEWalletsRepository) FindByNicepayTransactionIDs(tx *gorm.DB, nicepayTransactionIDs []string) ([]models.PaymentNicepayEWalletsDataModel, error) {
+	if tx == nil || len(nicepayTransactionIDs) == 0 {
+		return nil, nil
+	}
+	var ewallets []models.PaymentNicepayEWalletsDataModel
+	err := tx.Scopes(Active()).
+		Preload("Payment", func(tx *gorm.DB) *gorm.DB { return tx.Scopes(Active()) }).
+		Preload("Payment.Currency").
+		Where("nicepay_transaction_id IN ?", nicepayTransactionIDs).
+		Find(&ewallets).Error
+	return ewallets, err
+}
@@ -1,9 +1,16 @@
 package repositories
 
 import (
+	"errors"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	constant "worker-nicepay/infrastructure/const"
 	"worker-nicepay/infrastructure/database/models"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentRepositoryYugabyteDB struct{}
@@ -18,3 +25,142 @@ func (r *PaymentRepositoryYugabyteDB) Insert(tx *gorm.DB, model *models.Payments
 	}
 	return tx.Create(model).Error
 }
+
+func (r *PaymentRepositoryYugabyteDB) Update(tx *gorm.DB, id uuid.UUID, values map[string]interface{}) error {
+	if tx == nil {
+		return nil
+	}
+	return tx.Model(&models.PaymentsDataModel{}).Where("id = ?", id).Updates(values).Error
+}
+
+// ClaimUnresolved leases one payment whose gateway outcome is unknown or not
+// final yet: UNKNOWN rows, INITIATED rows created before staleBefore (the
+// process died during the call) and PENDING rows not checked since
+// staleBefore, whose payer may have paid. Rows attempted at or after
+// attemptedBefore are skipped so a pass does not pick the same row twice, and
+// rows leased by another replica are skipped until the lease ends. The claim
+// is its own short transaction, so no lock is held while Nicepay is queried.
+func (r *PaymentRepositoryYugabyteDB) ClaimUnresolved(tx *gorm.DB, staleBefore int64, attemptedBefore int64, now time.Time, lease time.Duration) (*models.PaymentsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var payment *models.PaymentsDataModel
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		var row models.PaymentsDataModel
+		err := tx.Scopes(Active()).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? OR (status = ? AND created_date < ?) OR (status = ? AND COALESCE(updated_date, created_date) < ?)) AND COALESCE(updated_date, 0) < ?",
+				constant.PAYMENT_STATUS_UNKNOWN, constant.PAYMENT_STATUS_INITIATED, staleBefore,
+				constant.PAYMENT_STATUS_PENDING, staleBefore, attemptedBefore).
+			Where("COALESCE(resolving_until, 0) < ?", now.UnixMilli()).
+			Order("updated_date NULLS FIRST").
+			First(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		until := now.Add(lease).UnixMilli()
+		if err := tx.Model(&models.PaymentsDataModel{}).Where("id = ?", row.ID).Update("resolving_until", until).Error; err != nil {
+			return err
+		}
+		row.ResolvingUntil = &until
+		payment = &row
+		return nil
+	})
+	return payment, err
+}
+
+// DailyUsage counts and sums the payments of a merchant's channel in a
+// currency created since the given time. Failed, cancelled and expired
+// payments do not count.
+func (r *PaymentRepositoryYugabyteDB) DailyUsage(tx *gorm.DB, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, since int64) (int64, int64, error) {
+	if tx == nil {
+		return 0, 0, nil
+	}
+	var usage struct {
+		Count  int64
+		Amount int64
+	}
+	err := tx.Model(&models.PaymentsDataModel{}).
+		Scopes(Active()).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("merchant_id = ? AND payment_method_id = ? AND currency_id = ? AND created_date >= ?", merchantID, paymentMethodID, currencyID, since).
+		Where("status NOT IN ?", []string{constant.PAYMENT_STATUS_FAILED, constant.PAYMENT_STATUS_CANCEL, constant.PAYMENT_STATUS_EXPIRED}).
+		Scan(&usage).Error
+	return usage.Count, usage.Amount, err
+}
+
+// FindByTransactionID returns the payment of a merchant transaction with its
+// merchant, payment method and currency loaded.
+func (r *PaymentRepositoryYugabyteDB) FindByTransactionID(tx *gorm.DB, transactionID string) (*models.PaymentsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var payment models.PaymentsDataModel
+	err := tx.Scopes(Active()).
+		Preload("Merchant").
+		Preload("PaymentMethod").
+		Preload("Currency").
+		Where("transaction_id = ?", transactionID).
+		First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.NotFound("payment", transactionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindForUpdate locks the payment of a merchant transaction, so concurrent
+// refunds of it are checked against its amount one at a time.
+func (r *PaymentRepositoryYugabyteDB) FindForUpdate(tx *gorm.DB, transactionID string) (*models.PaymentsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var payment models.PaymentsDataModel
+	err := tx.Scopes(Active()).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("transaction_id = ?", transactionID).
+		First(&payment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperror.NotFound("payment", transactionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// FindByReferenceNos returns the payments with any of the reference
+// numbers, with their currency loaded.
+func (r *PaymentRepositoryYugabyteDB) FindByReferenceNos(tx *gorm.DB, referenceNos []string) ([]models.PaymentsDataModel, error) {
+	if tx == nil || len(referenceNos) == 0 {
+		return nil, nil
+	}
+	var payments []models.PaymentsDataModel
+	err := tx.Scopes(Active()).
+		Preload("Currency").
+		Where("reference_no IN ?", referenceNos).
+		Find(&payments).Error
+	return payments, err
+}
+
+// FindSucceededBetween pages through the successful payments created in
+// [from, to), ordered by ID after afterID, with their currency loaded.
+func (r *PaymentRepositoryYugabyteDB) FindSucceededBetween(tx *gorm.DB, from int64, to int64, afterID uuid.UUID, limit int) ([]models.PaymentsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var payments []models.PaymentsDataModel
+	err := tx.Scopes(Active()).
+		Preload("Currency").
+		Where("status = ? AND created_date >= ? AND created_date < ? AND id > ?", constant.PAYMENT_STATUS_SUCCESS, from, to, afterID).
+		Order("id").
+		Limit(limit).
+		Find(&payments).Error
+	return payments, err
+}
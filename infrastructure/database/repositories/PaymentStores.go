@@ -0,0 +1,193 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"worker-nicepay/application/services"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// The stores adapt the tx-based repositories to the context-based
+// repository interfaces of the application layer. They run on the
+// transaction of the current unit of work, or on db outside of one.
+
+func NewPaymentRepositories(db *gorm.DB) services.PaymentRepositories {
+	return services.PaymentRepositories{
+		Payments:               &PaymentStore{db: db, repo: NewPaymentRepositoryYugabyteDB()},
+		EWallets:               &PaymentEWalletStore{db: db, repo: NewPaymentNicepayEWalletsRepository()},
+		Currencies:             &CurrencyStore{db: db, repo: NewCurrenciesRepository()},
+		Countries:              &CountryStore{db: db, repo: NewCountriesRepository()},
+		PaymentMethods:         &PaymentMethodStore{db: db, repo: NewPaymentMethodsRepository()},
+		Merchants:              &MerchantStore{db: db, repo: NewMerchantsRepository()},
+		EWalletProviders:       &EWalletProviderStore{db: db, repo: NewEWalletProvidersRepository()},
+		MerchantPaymentMethods: &MerchantPaymentMethodStore{db: db, repo: NewMerchantPaymentMethodsRepository()},
+		FeeSchedules:           &FeeScheduleStore{db: db, repo: NewFeeSchedulesRepository()},
+		Ledger:                 &LedgerStore{db: db, repo: NewLedgerRepository()},
+	}
+}
+
+func outcomeValues(outcome services.PaymentOutcome) map[string]interface{} {
+	values := map[string]interface{}{
+		"updated_date": outcome.UpdatedAt.UnixMilli(),
+	}
+	if outcome.ResponseJson != nil {
+		values["response_json"] = outcome.ResponseJson
+	}
+	if outcome.RedirectURL != "" {
+		values["redirect_url"] = outcome.RedirectURL
+	}
+	return values
+}
+
+type PaymentStore struct {
+	db   *gorm.DB
+	repo *PaymentRepositoryYugabyteDB
+}
+
+func (s *PaymentStore) Insert(ctx context.Context, payment *models.PaymentsDataModel) error {
+	return s.repo.Insert(DB(ctx, s.db), payment)
+}
+
+func (s *PaymentStore) UpdateOutcome(ctx context.Context, id uuid.UUID, outcome services.PaymentOutcome) error {
+	values := outcomeValues(outcome)
+	values["resolving_until"] = nil
+	if outcome.Status != "" {
+		values["status"] = outcome.Status
+	}
+	if fees := outcome.Fees; fees != nil {
+		values["fee_schedule_id"] = fees.ScheduleID
+		values["fee_amount"] = fees.Fee
+		values["fee_tax_amount"] = fees.Tax
+		values["net_amount"] = fees.Net
+		values["fee_calculated_date"] = fees.CalculatedAt.UnixMilli()
+	}
+	return s.repo.Update(DB(ctx, s.db), id, values)
+}
+
+func (s *PaymentStore) ClaimUnresolved(ctx context.Context, staleBefore int64, attemptedBefore int64, now time.Time, lease time.Duration) (*models.PaymentsDataModel, error) {
+	return s.repo.ClaimUnresolved(DB(ctx, s.db), staleBefore, attemptedBefore, now, lease)
+}
+
+func (s *PaymentStore) DailyUsage(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, since int64) (int64, int64, error) {
+	return s.repo.DailyUsage(DB(ctx, s.db), merchantID, paymentMethodID, currencyID, since)
+}
+
+func (s *PaymentStore) FindForUpdate(ctx context.Context, transactionID string) (*models.PaymentsDataModel, error) {
+	return s.repo.FindForUpdate(DB(ctx, s.db), transactionID)
+}
+
+type PaymentEWalletStore struct {
+	db   *gorm.DB
+	repo *PaymentNicepayEWalletsRepository
+}
+
+func (s *PaymentEWalletStore) Insert(ctx context.Context, ewallet *models.PaymentNicepayEWalletsDataModel) error {
+	return s.repo.Insert(DB(ctx, s.db), ewallet)
+}
+
+func (s *PaymentEWalletStore) UpdateOutcome(ctx context.Context, paymentID uuid.UUID, outcome services.PaymentOutcome) error {
+	values := outcomeValues(outcome)
+	if outcome.NicepayTransactionID != "" {
+		values["nicepay_transaction_id"] = outcome.NicepayTransactionID
+	}
+	return s.repo.UpdateByPaymentID(DB(ctx, s.db), paymentID, values)
+}
+
+type CurrencyStore struct {
+	db   *gorm.DB
+	repo *CurrenciesRepository
+}
+
+func (s *CurrencyStore) FindByCode(ctx context.Context, code string) (*models.CurrenciesDataModel, error) {
+	return s.repo.FindByCode(DB(ctx, s.db), code)
+}
+
+func (s *CurrencyStore) FindByID(ctx context.Context, id uuid.UUID) (*models.CurrenciesDataModel, error) {
+	// payments keep referencing a currency after it is deleted
+	return s.repo.FindByID(DB(ctx, s.db), id, IncludeDeleted())
+}
+
+type CountryStore struct {
+	db   *gorm.DB
+	repo *CountriesRepository
+}
+
+func (s *CountryStore) FindByCountryID(ctx context.Context, countryID string) (*models.CountriesDataModel, error) {
+	return s.repo.FindByCountryID(DB(ctx, s.db), countryID)
+}
+
+type PaymentMethodStore struct {
+	db   *gorm.DB
+	repo *PaymentMethodsRepository
+}
+
+func (s *PaymentMethodStore) FindByName(ctx context.Context, name string) (*models.PaymentMethodsDataModel, error) {
+	method, err := s.repo.FindOne(DB(ctx, s.db), models.PaymentMethodsDataModel{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return &method, nil
+}
+
+type MerchantStore struct {
+	db   *gorm.DB
+	repo *MerchantsRepository
+}
+
+func (s *MerchantStore) FindByID(ctx context.Context, id uuid.UUID) (*models.MerchantsDataModel, error) {
+	merchant, err := s.repo.FindByID(DB(ctx, s.db), id)
+	if apperror.CodeOf(err) == apperror.CodeNotFound {
+		return nil, apperror.MerchantNotFound(id.String())
+	}
+	return merchant, err
+}
+
+type EWalletProviderStore struct {
+	db   *gorm.DB
+	repo *EWalletProvidersRepository
+}
+
+func (s *EWalletProviderStore) FindByProviderName(ctx context.Context, name string) (*models.EWalletProvidersDataModel, error) {
+	return s.repo.FindByProviderName(DB(ctx, s.db), name)
+}
+
+type MerchantPaymentMethodStore struct {
+	db   *gorm.DB
+	repo *MerchantPaymentMethodsRepository
+}
+
+func (s *MerchantPaymentMethodStore) FindForUpdate(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID) (*models.MerchantPaymentMethodsDataModel, error) {
+	return s.repo.FindForUpdate(DB(ctx, s.db), merchantID, paymentMethodID, currencyID)
+}
+
+func (s *MerchantPaymentMethodStore) HasAny(ctx context.Context, merchantID uuid.UUID) (bool, error) {
+	return s.repo.HasAny(DB(ctx, s.db), merchantID)
+}
+
+type FeeScheduleStore struct {
+	db   *gorm.DB
+	repo *FeeSchedulesRepository
+}
+
+func (s *FeeScheduleStore) FindEffective(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, at int64) (*models.FeeSchedulesDataModel, error) {
+	return s.repo.FindEffective(DB(ctx, s.db), merchantID, paymentMethodID, currencyID, at)
+}
+
+type LedgerStore struct {
+	db   *gorm.DB
+	repo *LedgerRepository
+}
+
+func (s *LedgerStore) Post(ctx context.Context, entry ledger.Entry) (bool, error) {
+	return s.repo.Post(DB(ctx, s.db), entry)
+}
+
+func (s *LedgerStore) Refunds(ctx context.Context, paymentID uuid.UUID) (map[string]int64, error) {
+	return s.repo.Refunds(DB(ctx, s.db), paymentID)
+}
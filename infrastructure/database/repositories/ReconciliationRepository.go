@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"worker-nicepay/infrastructure/database/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const reconciliationItemBatch = 500
+
+type ReconciliationRepository struct {
+	BaseRepository[models.ReconciliationRunsDataModel]
+}
+
+func NewReconciliationRepository() *ReconciliationRepository {
+	return &ReconciliationRepository{BaseRepository: NewBaseRepository[models.ReconciliationRunsDataModel]("reconciliation run")}
+}
+
+// FindRuns returns the latest runs first.
+func (r *ReconciliationRepository) FindRuns(tx *gorm.DB, offset int, limit int) ([]models.ReconciliationRunsDataModel, int64, error) {
+	if tx == nil {
+		return nil, 0, nil
+	}
+	query := tx.Model(&models.ReconciliationRunsDataModel{}).Scopes(Active())
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.ReconciliationRunsDataModel
+	err := query.Order("created_date DESC").Offset(offset).Limit(limit).Find(&rows).Error
+	return rows, total, err
+}
+
+func (r *ReconciliationRepository) InsertItems(tx *gorm.DB, items []models.ReconciliationItemsDataModel) error {
+	if tx == nil || len(items) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(items, reconciliationItemBatch).Error
+}
+
+// itemsQuery selects the items of a run in file order, the payments missing
+// from the file last. An empty result selects all items.
+func (r *ReconciliationRepository) itemsQuery(tx *gorm.DB, runID uuid.UUID, result string) *gorm.DB {
+	query := tx.Model(&models.ReconciliationItemsDataModel{}).
+		Where("run_id = ?", runID).
+		Order("line_no NULLS LAST, transaction_id")
+	if result != "" {
+		query = query.Where("result = ?", result)
+	}
+	return query
+}
+
+func (r *ReconciliationRepository) FindItems(tx *gorm.DB, runID uuid.UUID, result string, offset int, limit int) ([]models.ReconciliationItemsDataModel, int64, error) {
+	if tx == nil {
+		return nil, 0, nil
+	}
+	var total int64
+	if err := r.itemsQuery(tx, runID, result).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []models.ReconciliationItemsDataModel
+	err := r.itemsQuery(tx, runID, result).Offset(offset).Limit(limit).Find(&rows).Error
+	return rows, total, err
+}
+
+// EachItem calls fn for the items of a run one row at a time, so exports do
+// not hold the run in memory.
+func (r *ReconciliationRepository) EachItem(tx *gorm.DB, runID uuid.UUID, result string, fn func(item models.ReconciliationItemsDataModel) error) error {
+	if tx == nil {
+		return nil
+	}
+	rows, err := r.itemsQuery(tx, runID, result).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item models.ReconciliationItemsDataModel
+		if err := tx.ScanRows(rows, &item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
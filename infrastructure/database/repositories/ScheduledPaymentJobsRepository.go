@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"time"
+
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledPaymentJobsRepository struct{}
+
+func NewScheduledPaymentJobsRepository() *ScheduledPaymentJobsRepository {
+	return &ScheduledPaymentJobsRepository{}
+}
+
+func (r *ScheduledPaymentJobsRepository) Insert(tx *gorm.DB, model *models.ScheduledPaymentJobsDataModel) error {
+	if tx == nil || model == nil {
+		return nil
+	}
+	return tx.Create(model).Error
+}
+
+// ClaimDue leases up to limit due jobs until now+lease and returns them.
+// Rows locked by another replica or under an unexpired lease are skipped.
+// The jobs stay scheduled until Start, so the jobs of a replica that dies
+// before processing them are claimed again once their lease expires.
+func (r *ScheduledPaymentJobsRepository) ClaimDue(tx *gorm.DB, now time.Time, lease time.Duration, limit int) ([]models.ScheduledPaymentJobsDataModel, error) {
+	if tx == nil {
+		return nil, nil
+	}
+	var jobs []models.ScheduledPaymentJobsDataModel
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		err := tx.Scopes(Active()).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND run_at <= ?", constant.SCHEDULED_JOB_STATUS_SCHEDULED, now).
+			Where("claimed_until IS NULL OR claimed_until <= ?", now).
+			Order("run_at").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		ids := make([]string, len(jobs))
+		for i, job := range jobs {
+			ids[i] = job.JobID
+		}
+		return tx.Model(&models.ScheduledPaymentJobsDataModel{}).
+			Where("job_id IN ?", ids).
+			Update("claimed_until", now.Add(lease)).Error
+	})
+	return jobs, err
+}
+
+// Unclaim ends the lease of claimed jobs that could not be queued, so the
+// next pass claims them again.
+func (r *ScheduledPaymentJobsRepository) Unclaim(tx *gorm.DB, jobIDs []string) error {
+	if tx == nil || len(jobIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.ScheduledPaymentJobsDataModel{}).
+		Where("job_id IN ? AND status = ?", jobIDs, constant.SCHEDULED_JOB_STATUS_SCHEDULED).
+		Update("claimed_until", nil).Error
+}
+
+// Start releases a claimed job when its processing starts. It reports false
+// when the job was cancelled or already started by another replica.
+func (r *ScheduledPaymentJobsRepository) Start(tx *gorm.DB, jobID string, now time.Time) (bool, error) {
+	if tx == nil {
+		return false, nil
+	}
+	res := tx.Model(&models.ScheduledPaymentJobsDataModel{}).
+		Where("job_id = ? AND status = ?", jobID, constant.SCHEDULED_JOB_STATUS_SCHEDULED).
+		Updates(map[string]interface{}{
+			"status":        constant.SCHEDULED_JOB_STATUS_RELEASED,
+			"released_at":   now,
+			"claimed_until": nil,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Cancel cancels a merchant's job that has not been released yet. It reports
+// whether a scheduled job was found.
+func (r *ScheduledPaymentJobsRepository) Cancel(tx *gorm.DB, merchantID string, jobID string) (bool, error) {
+	if tx == nil {
+		return false, nil
+	}
+	res := tx.Model(&models.ScheduledPaymentJobsDataModel{}).
+		Where("job_id = ? AND merchant_id = ? AND status = ?", jobID, merchantID, constant.SCHEDULED_JOB_STATUS_SCHEDULED).
+		Update("status", constant.SCHEDULED_JOB_STATUS_CANCELLED)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *ScheduledPaymentJobsRepository) FindByMerchant(tx *gorm.DB, merchantID string, status string, offset int, limit int) ([]models.ScheduledPaymentJobsDataModel, int64, error) {
+	if tx == nil {
+		return nil, 0, nil
+	}
+	query := tx.Model(&models.ScheduledPaymentJobsDataModel{}).Scopes(Active())
+	if merchantID != "" {
+		query = query.Where("merchant_id = ?", merchantID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var jobs []models.ScheduledPaymentJobsDataModel
+	err := query.Order("run_at").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, total, err
+}
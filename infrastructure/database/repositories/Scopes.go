@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	constant "worker-nicepay/infrastructure/const"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueryOption widens the rows a read returns beyond the active ones.
+type QueryOption func(*queryOptions)
+
+type queryOptions struct {
+	includeDeleted  bool
+	includeInactive bool
+}
+
+// IncludeDeleted returns soft-deleted rows as well, e.g. to resolve the master
+// data a historical payment references. It implies IncludeInactive.
+func IncludeDeleted() QueryOption {
+	return func(o *queryOptions) {
+		o.includeDeleted = true
+		o.includeInactive = true
+	}
+}
+
+// IncludeInactive returns rows whose data_status is not ACTIVE, but still
+// excludes soft-deleted ones.
+func IncludeInactive() QueryOption {
+	return func(o *queryOptions) {
+		o.includeInactive = true
+	}
+}
+
+// NotDeleted excludes soft-deleted rows.
+func NotDeleted(db *gorm.DB) *gorm.DB {
+	return db.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "deleted_date"}, Value: nil})
+}
+
+// Active excludes soft-deleted and inactive rows unless opts include them.
+// Rows written before data_status was filled count as active.
+func Active(opts ...QueryOption) func(*gorm.DB) *gorm.DB {
+	var o queryOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		if !o.includeDeleted {
+			db = NotDeleted(db)
+		}
+		if !o.includeInactive {
+			status := clause.Column{Table: clause.CurrentTable, Name: "data_status"}
+			db = db.Where(clause.Or(
+				clause.Eq{Column: status, Value: nil},
+				clause.Eq{Column: status, Value: constant.DATA_STATUS_ACTIVE},
+			))
+		}
+		return db
+	}
+}
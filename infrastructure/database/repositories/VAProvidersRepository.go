@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"worker-nicepay/infrastructure/database/models"
+
+	"gorm.io/gorm"
+)
+
+type VAProvidersRepository struct {
+	BaseRepository[models.VAProvidersDataModel]
+}
+
+func NewVAProvidersRepository() *VAProvidersRepository {
+	return &VAProvidersRepository{BaseRepository: NewBaseRepository[models.VAProvidersDataModel]("VA provider")}
+}
+
+// Upsert inserts seed rows by provider name and updates the name of existing rows.
+func (r *VAProvidersRepository) Upsert(tx *gorm.DB, rows []models.VAProvidersDataModel) (int64, error) {
+	return r.upsert(tx, rows, "provider_name", "name")
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database/migrations"
+)
+
+const (
+	MigrateModeUp    = "up"
+	MigrateModeCheck = "check"
+	MigrateModeOff   = "off"
+)
+
+func NewMigrator() *migrations.Migrator {
+	lockTimeout := time.Duration(configuration.AppConfig.DBMigrateLockTimeout) * time.Millisecond
+	migrator, err := migrations.NewMigrator(YugabyteDBClient, lockTimeout)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return migrator
+}
+
+// InitializeSchema brings the schema up to date before the server starts.
+// Mode "up" applies pending migrations under the migration lock, "check"
+// refuses to start while migrations are pending and "off" skips both.
+func InitializeSchema() {
+	mode := configuration.AppConfig.DBMigrateMode
+	if mode == "" {
+		mode = MigrateModeUp
+	}
+
+	switch mode {
+	case MigrateModeOff:
+		return
+	case MigrateModeUp:
+		if _, err := NewMigrator().Up(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	case MigrateModeCheck:
+		pending, err := NewMigrator().Pending(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(pending) > 0 {
+			log.Fatalf("Schema is behind: %d pending migrations starting at %s, run `migrate up`", len(pending), pending[0])
+		}
+	default:
+		log.Fatalf("Unknown DB_MIGRATE_MODE %q", mode)
+	}
+}
@@ -0,0 +1,151 @@
+package seeds
+
+import (
+	"embed"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+
+	"gorm.io/gorm"
+)
+
+//go:embed data/*.csv
+var files embed.FS
+
+// Actor is recorded as the creator of seeded rows.
+const Actor = "seed"
+
+const (
+	channelTypeEWallet = "ewallet"
+	channelTypeVA      = "va"
+)
+
+// Result counts the rows inserted or updated per table.
+type Result struct {
+	Currencies       int64
+	Countries        int64
+	PaymentMethods   int64
+	EWalletProviders int64
+	VAProviders      int64
+}
+
+// Run loads ISO 4217 currencies, ISO 3166-1 countries and the channel catalog
+// in one transaction. It is idempotent; existing rows only change when the
+// embedded data changed. Audit columns come from the actor in db's context.
+func Run(db *gorm.DB) (Result, error) {
+	var result Result
+
+	currencies, err := readCSV("currencies.csv", "code", "name", "minor_unit")
+	if err != nil {
+		return result, err
+	}
+	countries, err := readCSV("countries.csv", "code", "name")
+	if err != nil {
+		return result, err
+	}
+	channels, err := readCSV("channels.csv", "code", "name", "type")
+	if err != nil {
+		return result, err
+	}
+
+	currencyRows := make([]models.CurrenciesDataModel, 0, len(currencies))
+	for _, row := range currencies {
+		minorUnit, err := strconv.Atoi(row["minor_unit"])
+		if err != nil {
+			return result, fmt.Errorf("currency %s has invalid minor_unit %q", row["code"], row["minor_unit"])
+		}
+		currencyRows = append(currencyRows, models.CurrenciesDataModel{
+			Code:      row["code"],
+			Name:      row["name"],
+			MinorUnit: minorUnit,
+		})
+	}
+
+	countryRows := make([]models.CountriesDataModel, 0, len(countries))
+	for _, row := range countries {
+		countryRows = append(countryRows, models.CountriesDataModel{
+			Code: row["code"],
+			Name: row["name"],
+		})
+	}
+
+	// every channel is a payment method; its type decides the provider table
+	var methodRows []models.PaymentMethodsDataModel
+	var ewalletRows []models.EWalletProvidersDataModel
+	var vaRows []models.VAProvidersDataModel
+	for _, row := range channels {
+		methodRows = append(methodRows, models.PaymentMethodsDataModel{
+			Name: row["code"],
+		})
+		switch row["type"] {
+		case channelTypeEWallet:
+			ewalletRows = append(ewalletRows, models.EWalletProvidersDataModel{
+				Name:         row["name"],
+				ProviderName: row["code"],
+			})
+		case channelTypeVA:
+			vaRows = append(vaRows, models.VAProvidersDataModel{
+				Name:         row["name"],
+				ProviderName: row["code"],
+			})
+		default:
+			return result, fmt.Errorf("channel %s has unknown type %q", row["code"], row["type"])
+		}
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if result.Currencies, err = repositories.NewCurrenciesRepository().Upsert(tx, currencyRows); err != nil {
+			return err
+		}
+		if result.Countries, err = repositories.NewCountriesRepository().Upsert(tx, countryRows); err != nil {
+			return err
+		}
+		if result.PaymentMethods, err = repositories.NewPaymentMethodsRepository().Upsert(tx, methodRows); err != nil {
+			return err
+		}
+		if result.EWalletProviders, err = repositories.NewEWalletProvidersRepository().Upsert(tx, ewalletRows); err != nil {
+			return err
+		}
+		result.VAProviders, err = repositories.NewVAProvidersRepository().Upsert(tx, vaRows)
+		return err
+	})
+	return result, err
+}
+
+// readCSV reads an embedded CSV file whose header must start with columns.
+func readCSV(name string, columns ...string) ([]map[string]string, error) {
+	f, err := files.Open("data/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+
+	header := records[0]
+	for i, column := range columns {
+		if i >= len(header) || header[i] != column {
+			return nil, fmt.Errorf("%s: expected column %d to be %q", name, i+1, column)
+		}
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"worker-nicepay/infrastructure/configuration"
-	"worker-nicepay/infrastructure/database/models"
 
 	"github.com/google/uuid"
 	"gorm.io/driver/postgres"
@@ -34,6 +33,7 @@ func InitializeYugabyteDB() {
 			SingularTable: false,
 			NameReplacer:  strings.NewReplacer("DataModel", ""),
 		},
+		TranslateError: true,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -42,21 +42,7 @@ func InitializeYugabyteDB() {
 	YugabyteDBClient = db
 
 	registerUUIDv7BeforeCreate(YugabyteDBClient)
-
-	if err := YugabyteDBClient.AutoMigrate(
-		&models.PaymentXenditQrisesDataModel{},
-		&models.PaymentXenditVasDataModel{},
-		&models.PaymentXenditEWalletsDataModel{},
-		&models.EWalletProvidersDataModel{},
-		&models.VAProvidersDataModel{},
-		&models.PaymentsDataModel{},
-		&models.MerchantsDataModel{},
-		&models.PaymentMethodsDataModel{},
-		&models.CurrenciesDataModel{},
-		&models.CountriesDataModel{},
-	); err != nil {
-		log.Fatal(err)
-	}
+	registerAuditCallbacks(YugabyteDBClient)
 }
 
 func registerUUIDv7BeforeCreate(db *gorm.DB) {
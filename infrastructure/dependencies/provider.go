@@ -6,27 +6,39 @@ import (
 	"worker-nicepay/application/services"
 	"worker-nicepay/infrastructure/configuration"
 	"worker-nicepay/infrastructure/database"
+	"worker-nicepay/infrastructure/database/cache"
 	"worker-nicepay/infrastructure/database/connectors"
 	"worker-nicepay/infrastructure/database/repositories"
 	"worker-nicepay/infrastructure/gateway/nicepay"
+	"worker-nicepay/infrastructure/gateway/resilience"
 	"worker-nicepay/infrastructure/publishers"
+	"worker-nicepay/infrastructure/queue"
 	"worker-nicepay/infrastructure/service"
 
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 )
 
 // singleton
 var gatewayOnce sync.Once
+var resilientGatewayOnce sync.Once
 var transactionServiceOnce sync.Once
 var publisherOnce sync.Once
+var masterDataCacheOnce sync.Once
+var eventQueueOnce sync.Once
 var yugabyteClientOnce sync.Once
 var masterDataRepoOnce sync.Once
 var paymentRepoOnce sync.Once
 var xenditRepoOnce sync.Once
+var reconciliationServiceOnce sync.Once
+var statementServiceOnce sync.Once
 
 // singleton instance
 var nicepayGatewayInstance *nicepay.NicepayGateway
-var publisherInstance *publishers.PublisherLog
+var resilientGatewayInstance *nicepay.ResilientGateway
+var publisherInstance services.Publisher
+var masterDataCacheInstance *cache.MasterDataCache
+var eventQueueInstance *queue.RabbitMQQueue
 var yugabyteClientInstance *connectors.YugabyteConnector
 var masterDataRepoInstance *repositories.MasterDataRepositoryYugabyteDB
 var paymentRepoInstance *repositories.PaymentRepositoryYugabyteDB
@@ -35,12 +47,25 @@ var currenciesRepoInstance *repositories.CurrenciesRepository
 var countriesRepoInstance *repositories.CountriesRepository
 var merchantsRepoInstance *repositories.MerchantsRepository
 var paymentMethodsRepoInstance *repositories.PaymentMethodsRepository
+var paymentNicepayEWalletsRepoInstance *repositories.PaymentNicepayEWalletsRepository
+var ewalletProvidersRepoInstance *repositories.EWalletProvidersRepository
+var vaProvidersRepoInstance *repositories.VAProvidersRepository
+var merchantPaymentMethodsRepoInstance *repositories.MerchantPaymentMethodsRepository
+var feeSchedulesRepoInstance *repositories.FeeSchedulesRepository
+var ledgerRepoInstance *repositories.LedgerRepository
+var reconciliationRepoInstance *repositories.ReconciliationRepository
+var reconciliationServiceInstance *service.ReconciliationService
+var statementServiceInstance *service.StatementService
+var scheduledPaymentJobsRepoInstance *repositories.ScheduledPaymentJobsRepository
+var unitOfWorkInstance *repositories.GormUnitOfWork
 var NicepaytransactionServiceInstance *service.NicePayTransactionService
 
 var ProviderSet wire.ProviderSet = wire.NewSet(
 	ProvideNicepayGateway,
+	ProvideResilientNicepayGateway,
 	ProvideTransactionService,
 	ProvideYugabyteClient,
+	ProvideUnitOfWork,
 	ProvideMasterDataRepository,
 	ProvidePaymentRepository,
 	ProvideXenditRepository,
@@ -48,10 +73,23 @@ var ProviderSet wire.ProviderSet = wire.NewSet(
 	ProvideCountriesRepository,
 	ProvideMerchantsRepository,
 	ProvidePaymentMethodsRepository,
+	ProvidePaymentNicepayEWalletsRepository,
+	ProvideEWalletProvidersRepository,
+	ProvideVAProvidersRepository,
+	ProvideMerchantPaymentMethodsRepository,
+	ProvideFeeSchedulesRepository,
+	ProvideLedgerRepository,
+	ProvideReconciliationRepository,
+	ProvideReconciliationService,
+	ProvideStatementService,
+	ProvideScheduledPaymentJobsRepository,
 	ProvidePublisher,
-	wire.Bind(new(services.PaymentGateway), new(*nicepay.NicepayGateway)),
+	ProvideMasterDataCache,
+	ProvideEventQueue,
+	wire.Bind(new(services.PaymentGateway), new(*nicepay.ResilientGateway)),
 	wire.Bind(new(services.TransactionService), new(*service.NicePayTransactionService)),
-	wire.Bind(new(services.Publisher), new(*publishers.PublisherLog)),
+	wire.Bind(new(services.EventQueue), new(*queue.RabbitMQQueue)),
+	wire.Bind(new(services.UnitOfWork), new(*repositories.GormUnitOfWork)),
 )
 
 func ProvideNicepayGateway() *nicepay.NicepayGateway {
@@ -62,21 +100,45 @@ func ProvideNicepayGateway() *nicepay.NicepayGateway {
 	return nicepayGatewayInstance
 }
 
+func ProvideResilientNicepayGateway() *nicepay.ResilientGateway {
+	resilientGatewayOnce.Do(func() {
+		cfg := configuration.AppConfig
+		resilientGatewayInstance = nicepay.NewResilientGateway(ProvideNicepayGateway(), nicepay.ResilienceConfig{
+			RateLimit:    cfg.NicepayRateLimit,
+			RateBurst:    cfg.NicepayRateBurst,
+			MaxInFlight:  cfg.NicepayMaxInFlight,
+			BulkheadWait: time.Duration(cfg.NicepayBulkheadWait) * time.Millisecond,
+			Breaker: resilience.BreakerConfig{
+				FailureThreshold: cfg.NicepayBreakerThreshold,
+				OpenTimeout:      time.Duration(cfg.NicepayBreakerOpenTimeout) * time.Millisecond,
+				HalfOpenProbes:   cfg.NicepayBreakerProbes,
+			},
+			MaxRetries:   cfg.NicepayMaxRetries,
+			RetryBackoff: time.Duration(cfg.NicepayRetryBackoff) * time.Millisecond,
+		})
+	})
+	return resilientGatewayInstance
+}
+
 func ProvideTransactionService() *service.NicePayTransactionService {
 	transactionServiceOnce.Do(func() {
-		// masterRepo := ProvideMasterDataRepository()
-		paymentRepo := ProvidePaymentRepository()
-		currencyRepo := ProvideCurrenciesRepository()
-		countryRepo := ProvideCountriesRepository()
-		merchantRepo := ProvideMerchantsRepository()
-		paymentMethodRepo := ProvidePaymentMethodsRepository()
-		gateway := ProvideNicepayGateway()
 		db := ProvideYugabyteClient().GetDB()
-		NicepaytransactionServiceInstance = service.NewNicePayTransactionService(db, paymentRepo, currencyRepo, countryRepo, paymentMethodRepo, merchantRepo, gateway)
+		uow := ProvideUnitOfWork()
+		repos := ProvideMasterDataCache().Wrap(repositories.NewPaymentRepositories(db))
+		gateway := ProvideResilientNicepayGateway()
+		eventQueue := ProvideEventQueue()
+		NicepaytransactionServiceInstance = service.NewNicePayTransactionService(uow, repos, gateway, eventQueue)
 	})
 	return NicepaytransactionServiceInstance
 }
 
+func ProvideUnitOfWork() *repositories.GormUnitOfWork {
+	if unitOfWorkInstance == nil {
+		unitOfWorkInstance = repositories.NewGormUnitOfWork(ProvideYugabyteClient().GetDB())
+	}
+	return unitOfWorkInstance
+}
+
 func ProvideYugabyteClient() *connectors.YugabyteConnector {
 	yugabyteClientOnce.Do(func() {
 		yugabyteClientInstance = connectors.NewYugabyteConnector(database.YugabyteDBClient)
@@ -105,13 +167,49 @@ func ProvideXenditRepository() *repositories.XenditRepositoryYugabyteDB {
 	return xenditRepoInstance
 }
 
-func ProvidePublisher() *publishers.PublisherLog {
+// ProvidePublisher selects the services.Publisher implementation from PUBLISHER_DRIVER.
+func ProvidePublisher() services.Publisher {
 	publisherOnce.Do(func() {
-		publisherInstance = publishers.NewPublisherLog()
+		prefix := configuration.AppConfig.PublisherChannel
+		if prefix == "" {
+			prefix = "events:"
+		}
+		switch configuration.AppConfig.PublisherDriver {
+		case "redis-pubsub":
+			publisherInstance = publishers.NewPublisherRedis(publishers.RDS, publishers.RedisPublishPubSub, prefix, 0)
+		case "redis-stream":
+			publisherInstance = publishers.NewPublisherRedis(publishers.RDS, publishers.RedisPublishStream, prefix, configuration.AppConfig.RedisStreamMaxLen)
+		default:
+			publisherInstance = publishers.NewPublisherLog()
+		}
 	})
 	return publisherInstance
 }
 
+func ProvideEventQueue() *queue.RabbitMQQueue {
+	eventQueueOnce.Do(func() {
+		eventQueueInstance = queue.NewRabbitMQQueue(queue.RabbitChan)
+	})
+	return eventQueueInstance
+}
+
+func ProvideMasterDataCache() *cache.MasterDataCache {
+	masterDataCacheOnce.Do(func() {
+		cfg := configuration.AppConfig
+		size := cfg.MasterDataCacheSize
+		if size <= 0 {
+			size = 1000
+		}
+		var client redis.UniversalClient
+		if publishers.RDS != nil {
+			client = publishers.RDS
+		}
+		masterDataCacheInstance = cache.NewMasterDataCache(client, cfg.ServiceName+":master-data:invalidate", size,
+			time.Duration(cfg.MasterDataCacheTTL)*time.Millisecond)
+	})
+	return masterDataCacheInstance
+}
+
 func ProvideCurrenciesRepository() *repositories.CurrenciesRepository {
 	if currenciesRepoInstance == nil {
 		currenciesRepoInstance = repositories.NewCurrenciesRepository()
@@ -139,3 +237,75 @@ func ProvidePaymentMethodsRepository() *repositories.PaymentMethodsRepository {
 	}
 	return paymentMethodsRepoInstance
 }
+
+func ProvidePaymentNicepayEWalletsRepository() *repositories.PaymentNicepayEWalletsRepository {
+	if paymentNicepayEWalletsRepoInstance == nil {
+		paymentNicepayEWalletsRepoInstance = repositories.NewPaymentNicepayEWalletsRepository()
+	}
+	return paymentNicepayEWalletsRepoInstance
+}
+
+func ProvideEWalletProvidersRepository() *repositories.EWalletProvidersRepository {
+	if ewalletProvidersRepoInstance == nil {
+		ewalletProvidersRepoInstance = repositories.NewEWalletProvidersRepository()
+	}
+	return ewalletProvidersRepoInstance
+}
+
+func ProvideVAProvidersRepository() *repositories.VAProvidersRepository {
+	if vaProvidersRepoInstance == nil {
+		vaProvidersRepoInstance = repositories.NewVAProvidersRepository()
+	}
+	return vaProvidersRepoInstance
+}
+
+func ProvideMerchantPaymentMethodsRepository() *repositories.MerchantPaymentMethodsRepository {
+	if merchantPaymentMethodsRepoInstance == nil {
+		merchantPaymentMethodsRepoInstance = repositories.NewMerchantPaymentMethodsRepository()
+	}
+	return merchantPaymentMethodsRepoInstance
+}
+
+func ProvideFeeSchedulesRepository() *repositories.FeeSchedulesRepository {
+	if feeSchedulesRepoInstance == nil {
+		feeSchedulesRepoInstance = repositories.NewFeeSchedulesRepository()
+	}
+	return feeSchedulesRepoInstance
+}
+
+func ProvideLedgerRepository() *repositories.LedgerRepository {
+	if ledgerRepoInstance == nil {
+		ledgerRepoInstance = repositories.NewLedgerRepository()
+	}
+	return ledgerRepoInstance
+}
+
+func ProvideReconciliationRepository() *repositories.ReconciliationRepository {
+	if reconciliationRepoInstance == nil {
+		reconciliationRepoInstance = repositories.NewReconciliationRepository()
+	}
+	return reconciliationRepoInstance
+}
+
+func ProvideReconciliationService() *service.ReconciliationService {
+	reconciliationServiceOnce.Do(func() {
+		reconciliationServiceInstance = service.NewReconciliationService(ProvideYugabyteClient().GetDB(), ProvidePaymentRepository(),
+			ProvidePaymentNicepayEWalletsRepository(), ProvideCurrenciesRepository(), ProvideReconciliationRepository())
+	})
+	return reconciliationServiceInstance
+}
+
+func ProvideStatementService() *service.StatementService {
+	statementServiceOnce.Do(func() {
+		statementServiceInstance = service.NewStatementService(ProvideYugabyteClient().GetDB(), ProvideMerchantsRepository(),
+			ProvideCurrenciesRepository(), ProvideLedgerRepository())
+	})
+	return statementServiceInstance
+}
+
+func ProvideScheduledPaymentJobsRepository() *repositories.ScheduledPaymentJobsRepository {
+	if scheduledPaymentJobsRepoInstance == nil {
+		scheduledPaymentJobsRepoInstance = repositories.NewScheduledPaymentJobsRepository()
+	}
+	return scheduledPaymentJobsRepoInstance
+}
@@ -6,7 +6,6 @@ package dependencies
 import (
 	"worker-nicepay/application/services"
 	"worker-nicepay/infrastructure/gateway/nicepay"
-	"worker-nicepay/infrastructure/publishers"
 	"worker-nicepay/infrastructure/service"
 
 	"github.com/google/wire"
@@ -24,6 +23,6 @@ func WireNicepayTransactionService() *service.NicePayTransactionService {
 	panic(wire.Build(ProviderSet))
 }
 
-func WirePublisher() *publishers.PublisherLog {
+func WirePublisher() services.Publisher {
 	panic(wire.Build(ProviderSet))
 }
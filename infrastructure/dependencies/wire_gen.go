@@ -9,16 +9,15 @@ package dependencies
 import (
 	"worker-nicepay/application/services"
 	"worker-nicepay/infrastructure/gateway/nicepay"
-	"worker-nicepay/infrastructure/publishers"
 	"worker-nicepay/infrastructure/service"
 )
 
 // Injectors from wire.go:
 
 func WireCreatePaymentService() *services.CreatePaymentService {
-	nicepayGateway := ProvideNicepayGateway()
+	resilientGateway := ProvideResilientNicepayGateway()
 	nicePayTransactionService := ProvideTransactionService()
-	createPaymentService := services.NewCreatePaymentService(nicepayGateway, nicePayTransactionService)
+	createPaymentService := services.NewCreatePaymentService(resilientGateway, nicePayTransactionService)
 	return createPaymentService
 }
 
@@ -32,7 +31,7 @@ func WireNicepayTransactionService() *service.NicePayTransactionService {
 	return nicePayTransactionService
 }
 
-func WirePublisher() *publishers.PublisherLog {
-	publisherLog := ProvidePublisher()
-	return publisherLog
+func WirePublisher() services.Publisher {
+	publisher := ProvidePublisher()
+	return publisher
 }
@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
-	"strings"
+	"net"
 	"time"
 
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/infrastructure/gateway"
+
 	"github.com/go-resty/resty/v2"
 )
 
@@ -26,11 +28,27 @@ func NewNicepayGateway(url string, apiKey string, timeout time.Duration) *Nicepa
 }
 
 func (g *NicepayGateway) RequestPaymentLink(ctx context.Context, req RequestPaymentLinkDTO, url string) (ResponsePaymentLinkDTO, error) {
-
 	var response ResponsePaymentLinkDTO
-	queries, _ := json.Marshal(req)
+	apiCall, err := g.post(ctx, url, req, &response)
+	response.RequestAPICallResult = apiCall
+	return response, err
+}
+
+// QueryPayment asks Nicepay for the current state of a transaction.
+func (g *NicepayGateway) QueryPayment(ctx context.Context, req RequestQueryPaymentDTO, url string) (ResponseQueryPaymentDTO, error) {
+	var response ResponseQueryPaymentDTO
+	apiCall, err := g.post(ctx, url, req, &response)
+	response.RequestAPICallResult = apiCall
+	return response, err
+}
+
+// post sends body to url and decodes the answer into out. The api call result
+// is returned on errors too, the resilience layer classifies by status code.
+func (g *NicepayGateway) post(ctx context.Context, url string, body interface{}, out interface{}) (gateway.RequestAPICallResult, error) {
+	queries, _ := json.Marshal(body)
 
 	resp, err := g.Client.R().
+		SetContext(ctx).
 		SetHeader("Content-Type", "application/json").
 		SetBody(queries).
 		Post(url)
@@ -38,31 +56,37 @@ func (g *NicepayGateway) RequestPaymentLink(ctx context.Context, req RequestPaym
 	reqHeaders, _ := json.Marshal(resp.Request.Header)
 	respHeaders, _ := json.Marshal(resp.Header())
 
-	response.RequestAPICallResult.RequestURL = url
-	response.RequestAPICallResult.Method = resp.Request.RawRequest.Method
-	response.RequestAPICallResult.RequestLatency = resp.Time().String()
-	response.RequestAPICallResult.RequestBody = string(queries)
-	response.RequestAPICallResult.ResponseBody = string(resp.Body())
-	response.RequestAPICallResult.RequestHeaders = string(reqHeaders)
-	response.RequestAPICallResult.ResponseHeaders = string(respHeaders)
-	response.RequestAPICallResult.ResponseStatusCode = resp.StatusCode()
-
-	if err != nil {
-		if strings.Contains(strings.ToLower(err.Error()), "excedeed") {
-			return ResponsePaymentLinkDTO{}, errors.New("timeout")
-		} else {
-			return ResponsePaymentLinkDTO{}, err
-		}
+	var apiCall gateway.RequestAPICallResult
+	apiCall.RequestURL = url
+	if resp.Request.RawRequest != nil {
+		apiCall.Method = resp.Request.RawRequest.Method
 	}
+	apiCall.RequestLatency = resp.Time().String()
+	apiCall.RequestBody = string(queries)
+	apiCall.ResponseBody = string(resp.Body())
+	apiCall.RequestHeaders = string(reqHeaders)
+	apiCall.ResponseHeaders = string(respHeaders)
+	apiCall.ResponseStatusCode = resp.StatusCode()
 
-	err = json.Unmarshal(resp.Body(), &response)
 	if err != nil {
-		return ResponsePaymentLinkDTO{}, err
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return apiCall, apperror.Timeout(err)
+		}
+		return apiCall, apperror.New(apperror.CodeUpstreamError, "nicepay request failed", err)
 	}
 
+	err = json.Unmarshal(resp.Body(), out)
 	if resp.StatusCode() >= 400 {
-		return ResponsePaymentLinkDTO{}, fmt.Errorf(response.Message)
+		var message struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(resp.Body(), &message)
+		return apiCall, apperror.Upstream(resp.StatusCode(), message.Message)
+	}
+	if err != nil {
+		return apiCall, apperror.New(apperror.CodeUpstreamError, "invalid nicepay response", err)
 	}
 
-	return response, nil
+	return apiCall, nil
 }
@@ -0,0 +1,164 @@
+package nicepay
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/infrastructure/gateway/resilience"
+)
+
+type paymentLinkRequester interface {
+	RequestPaymentLink(ctx context.Context, req RequestPaymentLinkDTO, url string) (ResponsePaymentLinkDTO, error)
+	QueryPayment(ctx context.Context, req RequestQueryPaymentDTO, url string) (ResponseQueryPaymentDTO, error)
+}
+
+type ResilienceConfig struct {
+	RateLimit    float64 // requests per second, 0 disables
+	RateBurst    int
+	MaxInFlight  int // 0 disables the bulkhead
+	BulkheadWait time.Duration
+	Breaker      resilience.BreakerConfig
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
+
+// ResilientGateway guards the Nicepay calls with a rate limit, a bulkhead and
+// a circuit breaker per channel. Creating a payment link is not idempotent,
+// so only failures where Nicepay clearly did not process the request are retried.
+type ResilientGateway struct {
+	next         paymentLinkRequester
+	limiter      *resilience.RateLimiter
+	bulkhead     *resilience.Bulkhead
+	breaker      *resilience.CircuitBreaker
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// GatewayStatus is the monitoring view of the resilience layer.
+type GatewayStatus struct {
+	InFlight    int                        `json:"in_flight"`
+	MaxInFlight int                        `json:"max_in_flight"`
+	Breakers    []resilience.BreakerStatus `json:"breakers"`
+}
+
+func NewResilientGateway(next paymentLinkRequester, cfg ResilienceConfig) *ResilientGateway {
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 200 * time.Millisecond
+	}
+	return &ResilientGateway{
+		next:         next,
+		limiter:      resilience.NewRateLimiter(cfg.RateLimit, cfg.RateBurst),
+		bulkhead:     resilience.NewBulkhead(cfg.MaxInFlight, cfg.BulkheadWait),
+		breaker:      resilience.NewCircuitBreaker(cfg.Breaker),
+		maxRetries:   cfg.MaxRetries,
+		retryBackoff: cfg.RetryBackoff,
+	}
+}
+
+func (g *ResilientGateway) RequestPaymentLink(ctx context.Context, req RequestPaymentLinkDTO, url string) (ResponsePaymentLinkDTO, error) {
+	var res ResponsePaymentLinkDTO
+	err := g.retry(ctx, req.Channel, retryable, func() (int, error) {
+		var err error
+		res, err = g.next.RequestPaymentLink(ctx, req, url)
+		return res.RequestAPICallResult.ResponseStatusCode, err
+	})
+	return res, err
+}
+
+// QueryPayment is read-only, so every upstream failure is retried.
+func (g *ResilientGateway) QueryPayment(ctx context.Context, req RequestQueryPaymentDTO, url string) (ResponseQueryPaymentDTO, error) {
+	var res ResponseQueryPaymentDTO
+	err := g.retry(ctx, req.Channel, func(status int, err error) bool {
+		return upstreamFailure(status, err) != nil
+	}, func() (int, error) {
+		var err error
+		res, err = g.next.QueryPayment(ctx, req, url)
+		return res.RequestAPICallResult.ResponseStatusCode, err
+	})
+	return res, err
+}
+
+// retry runs call through the guards until it succeeds, fails with an error
+// that shouldRetry rejects, or runs out of attempts.
+func (g *ResilientGateway) retry(ctx context.Context, channel string, shouldRetry func(int, error) bool, call func() (int, error)) error {
+	for attempt := 0; ; attempt++ {
+		status, err := g.guard(ctx, channel, call)
+		if err == nil || attempt >= g.maxRetries || !shouldRetry(status, err) {
+			return err
+		}
+
+		// exponential backoff between attempts
+		timer := time.NewTimer(g.retryBackoff << attempt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+func (g *ResilientGateway) guard(ctx context.Context, channel string, call func() (int, error)) (int, error) {
+	if err := g.limiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	if err := g.bulkhead.Acquire(ctx); err != nil {
+		if errors.Is(err, resilience.ErrBulkheadFull) {
+			err = apperror.GatewayUnavailable(err)
+		}
+		return 0, err
+	}
+	defer g.bulkhead.Release()
+
+	if err := g.breaker.Allow(channel); err != nil {
+		return 0, apperror.GatewayUnavailable(err)
+	}
+
+	status, err := call()
+	if err != nil && ctx.Err() != nil {
+		// the caller gave up, this says nothing about Nicepay
+		g.breaker.Abandon(channel)
+		return status, err
+	}
+	g.breaker.Record(channel, upstreamFailure(status, err))
+	return status, err
+}
+
+func (g *ResilientGateway) Status() GatewayStatus {
+	return GatewayStatus{
+		InFlight:    g.bulkhead.InFlight(),
+		MaxInFlight: g.bulkhead.Capacity(),
+		Breakers:    g.breaker.Status(),
+	}
+}
+
+func (g *ResilientGateway) ResetBreaker(channel string) {
+	g.breaker.Reset(channel)
+}
+
+// upstreamFailure returns err when it says Nicepay is unhealthy: transport
+// errors, timeouts, throttling and 5xx. Rejections (4xx) are healthy answers.
+func upstreamFailure(status int, err error) error {
+	if err == nil {
+		return nil
+	}
+	if status == 0 || status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+		return err
+	}
+	return nil
+}
+
+// retryable reports whether the request certainly was not processed: the
+// connection was never established, or Nicepay answered 429/503.
+func retryable(status int, err error) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -1,18 +1,34 @@
 package nicepay
 
-import "worker-nicepay/infrastructure/gateway"
+import (
+	"encoding/json"
+	"strings"
+
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/gateway"
+)
 
 type RequestPaymentLinkDTO struct {
-	CallbackURL string  `json:"url_callback"`
-	ReturnURL   string  `json:"url_return"`
-	MSISDN      string  `json:"msisdn"`
-	Name        string  `json:"name"`
-	Number      string  `json:"number"`
-	Channel     string  `json:"channel"`
-	Amount      float64 `json:"amount"`
-	Email       string  `json:"email"`
-	Description string  `json:"description"`
-	IPAddress   string  `json:"ip_address"`
+	CallbackURL string      `json:"url_callback"`
+	ReturnURL   string      `json:"url_return"`
+	MSISDN      string      `json:"msisdn"`
+	Name        string      `json:"name"`
+	Number      string      `json:"number"`
+	Channel     string      `json:"channel"`
+	Amount      json.Number `json:"amount"`
+	Email       string      `json:"email"`
+	Description string      `json:"description"`
+	IPAddress   string      `json:"ip_address"`
+}
+
+// AmountOf formats m in major units as Nicepay expects it, without a zero
+// fraction: USD 10.00 is sent as 10 and USD 10.50 as 10.5.
+func AmountOf(m money.Money) json.Number {
+	amount := m.String()
+	if strings.Contains(amount, ".") {
+		amount = strings.TrimSuffix(strings.TrimRight(amount, "0"), ".")
+	}
+	return json.Number(amount)
 }
 
 type ResponsePaymentLinkDTO struct {
@@ -21,6 +37,7 @@ type ResponsePaymentLinkDTO struct {
 	Message      string      `json:"message"`
 	ErrorMessage interface{} `json:"error_message"`
 	RedirectURL  string      `json:"redirect_url"`
+	TrxID        string      `json:"trx_id"`
 
 	// APICall Result
 	RequestAPICallResult gateway.RequestAPICallResult `json:"-"`
@@ -29,3 +46,24 @@ type ResponsePaymentLinkDTO struct {
 func (s *ResponsePaymentLinkDTO) GetAPICall() gateway.RequestAPICallResult {
 	return s.RequestAPICallResult
 }
+
+type RequestQueryPaymentDTO struct {
+	Number  string `json:"number"`
+	Channel string `json:"channel"`
+}
+
+type ResponseQueryPaymentDTO struct {
+	Error       bool   `json:"error"`
+	StatusCode  int    `json:"status_code"`
+	Message     string `json:"message"`
+	Status      string `json:"status"`
+	RedirectURL string `json:"redirect_url"`
+	TrxID       string `json:"trx_id"`
+
+	// APICall Result
+	RequestAPICallResult gateway.RequestAPICallResult `json:"-"`
+}
+
+func (s *ResponseQueryPaymentDTO) GetAPICall() gateway.RequestAPICallResult {
+	return s.RequestAPICallResult
+}
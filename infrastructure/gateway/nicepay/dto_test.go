@@ -0,0 +1,29 @@
+package nicepay
+
+import (
+	"testing"
+
+	"worker-nicepay/domain/money"
+)
+
+func TestAmountOf(t *testing.T) {
+	idr := money.Currency{Code: "IDR", Exponent: 0}
+	usd := money.Currency{Code: "USD", Exponent: 2}
+	tests := []struct {
+		money money.Money
+		want  string
+	}{
+		{money.New(150000, idr), "150000"},
+		{money.New(100, idr), "100"},
+		{money.New(1000, usd), "10"},
+		{money.New(1050, usd), "10.5"},
+		{money.New(1055, usd), "10.55"},
+		{money.New(5, usd), "0.05"},
+		{money.New(0, usd), "0"},
+	}
+	for _, tt := range tests {
+		if got := AmountOf(tt.money); string(got) != tt.want {
+			t.Errorf("AmountOf(%d %s) = %s, want %s", tt.money.Minor, tt.money.Currency.Code, got, tt.want)
+		}
+	}
+}
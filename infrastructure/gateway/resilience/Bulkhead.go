@@ -0,0 +1,73 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrBulkheadFull = errors.New("gateway bulkhead full")
+
+// Bulkhead caps the number of concurrent calls. Callers wait at most maxWait
+// for a slot so a slow upstream cannot pin every worker.
+type Bulkhead struct {
+	slots   chan struct{}
+	maxWait time.Duration
+}
+
+// NewBulkhead returns nil when max is not positive, which disables the cap.
+func NewBulkhead(max int, maxWait time.Duration) *Bulkhead {
+	if max <= 0 {
+		return nil
+	}
+	return &Bulkhead{
+		slots:   make(chan struct{}, max),
+		maxWait: maxWait,
+	}
+}
+
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if b.maxWait <= 0 {
+		return ErrBulkheadFull
+	}
+
+	timer := time.NewTimer(b.maxWait)
+	defer timer.Stop()
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrBulkheadFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) Release() {
+	if b == nil {
+		return
+	}
+	<-b.slots
+}
+
+func (b *Bulkhead) InFlight() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.slots)
+}
+
+func (b *Bulkhead) Capacity() int {
+	if b == nil {
+		return 0
+	}
+	return cap(b.slots)
+}
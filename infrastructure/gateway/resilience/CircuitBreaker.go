@@ -0,0 +1,178 @@
+package resilience
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("gateway circuit open")
+
+type BreakerState string
+
+const (
+	StateClosed   BreakerState = "closed"
+	StateOpen     BreakerState = "open"
+	StateHalfOpen BreakerState = "half_open"
+)
+
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures open the circuit
+	FailureThreshold int
+	// OpenTimeout is how long the circuit stays open before probing
+	OpenTimeout time.Duration
+	// HalfOpenProbes successful probes close the circuit again
+	HalfOpenProbes int
+}
+
+// BreakerStatus is the monitoring view of one circuit.
+type BreakerStatus struct {
+	Key         string       `json:"key"`
+	State       BreakerState `json:"state"`
+	Failures    int          `json:"failures"`
+	Successes   int          `json:"successes"`
+	OpenedAt    *time.Time   `json:"opened_at,omitempty"`
+	NextProbeAt *time.Time   `json:"next_probe_at,omitempty"`
+	LastError   string       `json:"last_error,omitempty"`
+}
+
+// CircuitBreaker keeps an independent circuit per key (the payment channel),
+// so one failing channel does not block the others.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	cfg      BreakerConfig
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state     BreakerState
+	failures  int
+	successes int
+	probing   int
+	openedAt  time.Time
+	lastError string
+}
+
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{cfg: cfg, circuits: make(map[string]*circuit)}
+}
+
+// Allow reports whether a call for key may proceed. In half-open state only
+// HalfOpenProbes calls are let through at a time.
+func (b *CircuitBreaker) Allow(key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	switch c.state {
+	case StateOpen:
+		if time.Since(c.openedAt) < b.cfg.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		c.state = StateHalfOpen
+		c.successes = 0
+		c.probing = 0
+		fallthrough
+	case StateHalfOpen:
+		if c.probing >= b.cfg.HalfOpenProbes {
+			return ErrCircuitOpen
+		}
+		c.probing++
+	}
+	return nil
+}
+
+// Record reports the outcome of an allowed call. Failures are only the errors
+// that say something about upstream health.
+func (b *CircuitBreaker) Record(key string, failure error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	if c.state == StateHalfOpen && c.probing > 0 {
+		c.probing--
+	}
+
+	if failure != nil {
+		c.lastError = failure.Error()
+		c.failures++
+		if c.state == StateHalfOpen || c.failures >= b.cfg.FailureThreshold {
+			c.state = StateOpen
+			c.openedAt = time.Now()
+			c.successes = 0
+		}
+		return
+	}
+
+	c.failures = 0
+	if c.state == StateHalfOpen {
+		c.successes++
+		if c.successes >= b.cfg.HalfOpenProbes {
+			c.state = StateClosed
+			c.successes = 0
+		}
+	}
+}
+
+// Abandon frees a half-open probe slot of a call that ended without a verdict,
+// e.g. because the caller gave up.
+func (b *CircuitBreaker) Abandon(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(key)
+	if c.state == StateHalfOpen && c.probing > 0 {
+		c.probing--
+	}
+}
+
+// Reset closes the circuit of key.
+func (b *CircuitBreaker) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.circuits, key)
+}
+
+func (b *CircuitBreaker) Status() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(b.circuits))
+	for key, c := range b.circuits {
+		status := BreakerStatus{
+			Key:       key,
+			State:     c.state,
+			Failures:  c.failures,
+			Successes: c.successes,
+			LastError: c.lastError,
+		}
+		if c.state != StateClosed {
+			openedAt := c.openedAt
+			nextProbeAt := openedAt.Add(b.cfg.OpenTimeout)
+			status.OpenedAt = &openedAt
+			status.NextProbeAt = &nextProbeAt
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Key < statuses[j].Key })
+	return statuses
+}
+
+func (b *CircuitBreaker) circuit(key string) *circuit {
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{state: StateClosed}
+		b.circuits[key] = c
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+package resilience
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// expire moves the circuit of key past its open timeout.
+func expire(b *CircuitBreaker, key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.circuit(key).openedAt = time.Now().Add(-b.cfg.OpenTimeout - time.Second)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	open := func(n int) string { return strings.Repeat("fail ", n) }
+	tests := []struct {
+		name string
+		// steps are run in order on the circuit "QRIS": allow and deny call
+		// Allow and expect it to pass or fail, fail and succeed Record the
+		// outcome of a call, wait lets the open timeout pass
+		steps string
+		state BreakerState
+	}{
+		{name: "failures below the threshold", steps: "allow fail allow fail allow", state: StateClosed},
+		{name: "threshold opens", steps: open(3) + "deny", state: StateOpen},
+		{name: "success resets the failures", steps: "fail fail succeed fail fail allow", state: StateClosed},
+		{name: "open until the timeout", steps: open(3) + "deny deny", state: StateOpen},
+		{name: "probe after the timeout", steps: open(3) + "wait allow", state: StateHalfOpen},
+		{name: "only the probes pass", steps: open(3) + "wait allow allow deny", state: StateHalfOpen},
+		{name: "probes close the circuit", steps: open(3) + "wait allow allow succeed succeed allow allow allow", state: StateClosed},
+		{name: "one probe is not enough", steps: open(3) + "wait allow succeed allow", state: StateHalfOpen},
+		{name: "failed probe opens again", steps: open(3) + "wait allow fail deny", state: StateOpen},
+		{name: "failed probe waits a full timeout", steps: open(3) + "wait allow allow fail succeed deny wait allow", state: StateHalfOpen},
+		{name: "abandoned probe frees its slot", steps: open(3) + "wait allow allow deny abandon allow", state: StateHalfOpen},
+		{name: "abandon outside half-open", steps: "allow abandon abandon allow", state: StateClosed},
+		{name: "reset closes", steps: open(3) + "deny reset allow", state: StateClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 3, OpenTimeout: time.Minute, HalfOpenProbes: 2})
+			for i, step := range strings.Fields(tt.steps) {
+				switch step {
+				case "allow":
+					if err := b.Allow("QRIS"); err != nil {
+						t.Fatalf("step %d: Allow = %v, want nil", i, err)
+					}
+				case "deny":
+					if err := b.Allow("QRIS"); !errors.Is(err, ErrCircuitOpen) {
+						t.Fatalf("step %d: Allow = %v, want %v", i, err, ErrCircuitOpen)
+					}
+				case "fail":
+					b.Record("QRIS", errors.New("timeout"))
+				case "succeed":
+					b.Record("QRIS", nil)
+				case "abandon":
+					b.Abandon("QRIS")
+				case "wait":
+					expire(b, "QRIS")
+				case "reset":
+					b.Reset("QRIS")
+				default:
+					t.Fatalf("unknown step %q", step)
+				}
+			}
+			status := b.Status()
+			if len(status) != 1 || status[0].State != tt.state {
+				t.Errorf("status = %+v, want QRIS %s", status, tt.state)
+			}
+		})
+	}
+}
+
+// A failing channel does not open the circuits of the other channels.
+func TestCircuitBreakerKeysAreIndependent(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	b.Record("QRIS", errors.New("timeout"))
+	if err := b.Allow("QRIS"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow QRIS = %v, want %v", err, ErrCircuitOpen)
+	}
+	if err := b.Allow("OVO"); err != nil {
+		t.Fatalf("Allow OVO = %v, want nil", err)
+	}
+
+	status := b.Status()
+	if len(status) != 2 || status[0].Key != "OVO" || status[1].Key != "QRIS" {
+		t.Fatalf("status = %+v, want OVO and QRIS by key", status)
+	}
+	if status[0].State != StateClosed || status[0].OpenedAt != nil {
+		t.Errorf("OVO = %+v, want closed", status[0])
+	}
+	qris := status[1]
+	if qris.State != StateOpen || qris.LastError != "timeout" || qris.Failures != 1 {
+		t.Errorf("QRIS = %+v, want open after a timeout", qris)
+	}
+	if qris.OpenedAt == nil || qris.NextProbeAt == nil || qris.NextProbeAt.Sub(*qris.OpenedAt) != time.Minute {
+		t.Errorf("QRIS opened at %v, next probe at %v, want a minute apart", qris.OpenedAt, qris.NextProbeAt)
+	}
+}
+
+func TestNewCircuitBreakerDefaults(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{})
+	if b.cfg.FailureThreshold != 5 || b.cfg.OpenTimeout != 30*time.Second || b.cfg.HalfOpenProbes != 1 {
+		t.Errorf("config = %+v, want 5 failures, 30s open and 1 probe", b.cfg)
+	}
+}
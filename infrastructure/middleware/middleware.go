@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"strings"
@@ -12,6 +13,7 @@ import (
 	"worker-nicepay/infrastructure/database/models"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/mileusna/useragent"
 	"github.com/sirupsen/logrus"
 )
@@ -26,13 +28,15 @@ func (h *Middlewares) Incoming() fiber.Handler {
 		// get request body
 		reqBody := c.Body()
 
-		// bind request
+		// bind request; GET requests carry no body and batch requests are JSON arrays
 		var incomingRequest dto.IncomingRequest
-		if err := c.BodyParser(&incomingRequest); err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"status":  "failure",
-				"message": err.Error(),
-			})
+		if body := bytes.TrimSpace(reqBody); len(body) > 0 && body[0] != '[' {
+			if err := c.BodyParser(&incomingRequest); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"status":  "failure",
+					"message": err.Error(),
+				})
+			}
 		}
 
 		reqHeader := c.GetReqHeaders()
@@ -41,7 +45,9 @@ func (h *Middlewares) Incoming() fiber.Handler {
 		timeNow := time.Now()
 		incoming := entities.Incoming{
 			CreatedAt:     timeNow,
+			TransactionID: uuid.NewString(),
 			Path:          c.Path(),
+			Merchant:      incomingRequest.MerchantID,
 			Method:        c.Method(),
 			RequestQuery:  string(c.Request().URI().QueryString()),
 			RequestHeader: string(reqHeaderBytes),
@@ -76,6 +82,10 @@ func (h *Middlewares) Incoming() fiber.Handler {
 
 		incoming.IP = c.IP()
 
+		// X-Actor names the user behind admin changes; merchants act as themselves
+		incoming.Actor = c.Get("X-Actor", incoming.Merchant)
+		c.SetUserContext(database.WithActor(c.UserContext(), incoming.Actor, incoming.IP))
+
 		c.Locals("incoming", &incoming)
 
 		// next to handler
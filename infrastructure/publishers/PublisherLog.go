@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"worker-nicepay/application/events"
 	"worker-nicepay/application/messages"
 	"worker-nicepay/application/services"
 )
@@ -15,15 +16,19 @@ func NewPublisherLog() *PublisherLog {
 }
 
 func (p *PublisherLog) Publish(ctx context.Context, message services.Message) error {
-	payload, err := json.Marshal(message)
+	env, err := events.Wrap(message)
 	if err != nil {
 		return err
 	}
-	switch message.GetMessageName() {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	switch env.GetMessageName() {
 	case messages.PaymentCreatedMessageName:
-		log.Printf("Publishing message: %s - %s", message.GetMessageName(), string(payload))
+		log.Printf("Publishing message: %s - %s", env.GetMessageName(), string(payload))
 	default:
-		log.Printf("Publishing unknown message: %T", message)
+		log.Printf("Publishing unknown message: %s - %s", env.GetMessageName(), string(payload))
 	}
 	return nil
 }
@@ -0,0 +1,75 @@
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"worker-nicepay/application/events"
+	"worker-nicepay/application/services"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisPublishMode string
+
+const (
+	RedisPublishPubSub RedisPublishMode = "pubsub"
+	RedisPublishStream RedisPublishMode = "stream"
+)
+
+// PublisherRedis publishes event envelopes to Redis Pub/Sub channels or
+// Streams. The channel or stream name is the prefix followed by the message
+// name, e.g. "events:payment.created".
+type PublisherRedis struct {
+	client    redis.UniversalClient
+	mode      RedisPublishMode
+	prefix    string
+	streamLen int64
+}
+
+func NewPublisherRedis(client redis.UniversalClient, mode RedisPublishMode, prefix string, streamMaxLen int64) *PublisherRedis {
+	return &PublisherRedis{client: client, mode: mode, prefix: prefix, streamLen: streamMaxLen}
+}
+
+func (p *PublisherRedis) Publish(ctx context.Context, message services.Message) error {
+	if p == nil || p.client == nil {
+		return errors.New("redis client is not initialized; call InitializeRedis first")
+	}
+
+	env, err := events.Wrap(message)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	key := p.prefix + env.GetMessageName()
+	switch p.mode {
+	case RedisPublishStream:
+		args := &redis.XAddArgs{
+			Stream: key,
+			ID:     "*",
+			Values: map[string]interface{}{
+				"id":             env.ID,
+				"type":           env.Type,
+				"schema_version": env.SchemaVersion,
+				"envelope":       payload,
+			},
+		}
+		if p.streamLen > 0 {
+			args.MaxLen = p.streamLen
+			args.Approx = true
+		}
+		err = p.client.XAdd(ctx, args).Err()
+	default:
+		err = p.client.Publish(ctx, key, payload).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish message to %s: %w", key, err)
+	}
+	return nil
+}
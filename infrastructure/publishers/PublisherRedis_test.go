@@ -0,0 +1,107 @@
+package publishers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"worker-nicepay/application/events"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestClient(t *testing.T) *redis.Client {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func paymentCreated() events.PaymentCreatedEvent {
+	return events.PaymentCreatedEvent{
+		PaymentID:     "payment-1",
+		TransactionID: "trx-1",
+		MerchantID:    "merchant-1",
+		ReferenceNo:   "INV-1",
+		Currency:      "IDR",
+		Amount:        "150000",
+		AmountMinor:   150000,
+		Status:        "PENDING",
+	}
+}
+
+func decodeEnvelope(t *testing.T, payload string) events.PaymentCreatedEvent {
+	t.Helper()
+	var env events.Envelope
+	if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		t.Fatalf("unmarshal envelope: %v", err)
+	}
+	if env.Type != events.PaymentCreatedEventName {
+		t.Fatalf("envelope type = %q, want %q", env.Type, events.PaymentCreatedEventName)
+	}
+	var event events.PaymentCreatedEvent
+	if err := env.Decode(&event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	return event
+}
+
+func TestPublisherRedisPubSub(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	sub := client.Subscribe(ctx, "events:"+events.PaymentCreatedEventName)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	publisher := NewPublisherRedis(client, RedisPublishPubSub, "events:", 0)
+	if err := publisher.Publish(ctx, paymentCreated()); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	select {
+	case msg := <-sub.Channel():
+		if event := decodeEnvelope(t, msg.Payload); event.PaymentID != "payment-1" {
+			t.Errorf("payment id = %q, want payment-1", event.PaymentID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no message received")
+	}
+}
+
+func TestPublisherRedisStream(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+	publisher := NewPublisherRedis(client, RedisPublishStream, "events:", 0)
+
+	if err := publisher.Publish(ctx, paymentCreated()); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	entries, err := client.XRange(ctx, "events:"+events.PaymentCreatedEventName, "-", "+").Result()
+	if err != nil {
+		t.Fatalf("XRange: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("stream has %d entries, want 1", len(entries))
+	}
+	values := entries[0].Values
+	if values["type"] != events.PaymentCreatedEventName || values["schema_version"] != events.PaymentCreatedSchemaVersion {
+		t.Errorf("entry fields = %v", values)
+	}
+	envelope, _ := values["envelope"].(string)
+	if event := decodeEnvelope(t, envelope); event.MerchantID != "merchant-1" {
+		t.Errorf("merchant id = %q, want merchant-1", event.MerchantID)
+	}
+}
+
+func TestPublisherRedisWithoutClient(t *testing.T) {
+	publisher := NewPublisherRedis(nil, RedisPublishPubSub, "events:", 0)
+	if err := publisher.Publish(context.Background(), paymentCreated()); err == nil {
+		t.Fatal("Publish succeeded without a client")
+	}
+}
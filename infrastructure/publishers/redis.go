@@ -1,25 +1,41 @@
 package publishers
 
 import (
-	"fmt"
+	"context"
 	"log"
-	"worker-nicepay/infrastructure/configuration"
 	"strconv"
-)
+	"time"
+
+	"worker-nicepay/infrastructure/configuration"
 
-// LogRedisClient simulates a Redis client for demonstration purposes
-type LogRedisClient struct{}
+	"github.com/redis/go-redis/v9"
+)
 
-var RDS *LogRedisClient
+// RDS is the shared Redis client used for caching and pub/sub.
+var RDS *redis.Client
 
 func InitializeRedis() {
-	// Create a simulated Redis client
-	RDS = &LogRedisClient{}
-
 	redisAddr := configuration.AppConfig.RedisHost + ":" + strconv.Itoa(configuration.AppConfig.RedisPort)
 
-	fmt.Println("=== Load Cache, Pub/Sub Redis ===")
-	log.Printf("Simulated Redis connection established to %s (DB: %d)",
-		redisAddr, configuration.AppConfig.RedisDatabase)
-	fmt.Println("=== Load Cache, Pub/Sub Redis ===")
+	RDS = redis.NewClient(&redis.Options{
+		Addr:     redisAddr,
+		Password: configuration.AppConfig.RedisPassword,
+		DB:       configuration.AppConfig.RedisDatabase,
+		PoolSize: configuration.AppConfig.RedisPoolSize,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := RDS.Ping(ctx).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis: ", err)
+	}
+
+	log.Printf("Redis connection established to %s (DB: %d)", redisAddr, configuration.AppConfig.RedisDatabase)
+}
+
+func CloseRedis() {
+	if RDS != nil {
+		RDS.Close()
+	}
 }
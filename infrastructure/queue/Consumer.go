@@ -0,0 +1,275 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"worker-nicepay/application/events"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// HeaderRetryCount tracks how many times a message has been handed back to
+// the queue after a handler failure.
+const HeaderRetryCount = "x-retry-count"
+
+// ErrPoisonMessage marks an error that must not be retried; the message is
+// dead-lettered immediately.
+var ErrPoisonMessage = errors.New("poison message")
+
+// Handler processes one decoded envelope. Returning an error wrapping
+// ErrPoisonMessage dead-letters the message, any other error retries it.
+type Handler func(ctx context.Context, env events.Envelope) error
+
+type ConsumerConfig struct {
+	Queue       string
+	Tag         string
+	Concurrency int
+	MaxRetries  int
+	// RetryBackoff is the delay before the first retry; it doubles on every
+	// attempt up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+}
+
+// Consumer reads envelopes from a queue with manual acks and dispatches them
+// to the handlers registered for their event type. At most Concurrency
+// deliveries are processed at once.
+type Consumer struct {
+	conn     *amqp.Connection
+	cfg      ConsumerConfig
+	handlers map[string][]Handler
+	ch       *amqp.Channel
+	wg       sync.WaitGroup
+}
+
+func NewConsumer(conn *amqp.Connection, cfg ConsumerConfig) *Consumer {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = 5 * time.Minute
+	}
+	if cfg.Tag == "" {
+		cfg.Tag = cfg.Queue + ".consumer"
+	}
+	return &Consumer{
+		conn:     conn,
+		cfg:      cfg,
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// Register adds a handler for an event type. Handlers of the same type run in
+// registration order. Register must be called before Start.
+func (c *Consumer) Register(eventType string, handler Handler) {
+	c.handlers[eventType] = append(c.handlers[eventType], handler)
+}
+
+// Start opens a dedicated channel and consumes until ctx is cancelled or the
+// channel is closed. It returns once consuming has started.
+func (c *Consumer) Start(ctx context.Context) error {
+	if c.conn == nil {
+		return errors.New("rabbitmq connection is not initialized; call InitializeRabbitMQ first")
+	}
+
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open consumer channel: %w", err)
+	}
+	if err := ch.Qos(c.cfg.Concurrency, 0, false); err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to set consumer prefetch: %w", err)
+	}
+
+	deliveries, err := ch.ConsumeWithContext(ctx,
+		c.cfg.Queue, // queue
+		c.cfg.Tag,   // consumer tag
+		false,       // auto-ack
+		false,       // exclusive
+		false,       // no-local
+		false,       // no-wait
+		nil,         // args
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to consume %s: %w", c.cfg.Queue, err)
+	}
+	c.ch = ch
+
+	sem := make(chan struct{}, c.cfg.Concurrency)
+	go func() {
+		for d := range deliveries {
+			sem <- struct{}{}
+			c.wg.Add(1)
+			go func(d amqp.Delivery) {
+				defer func() {
+					<-sem
+					c.wg.Done()
+				}()
+				c.handle(ctx, d)
+			}(d)
+		}
+	}()
+
+	log.Printf("Consumer started on queue %s (concurrency: %d)", c.cfg.Queue, c.cfg.Concurrency)
+	return nil
+}
+
+// Stop waits for in-flight deliveries and closes the consumer channel.
+func (c *Consumer) Stop() {
+	if c.ch == nil {
+		return
+	}
+	c.ch.Cancel(c.cfg.Tag, false)
+	c.wg.Wait()
+	c.ch.Close()
+}
+
+func (c *Consumer) handle(ctx context.Context, d amqp.Delivery) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Consumer %s: handler panicked on message %s: %v", c.cfg.Queue, d.MessageId, r)
+			c.deadLetter(ctx, d)
+		}
+	}()
+
+	env, err := EnvelopeFromDelivery(d)
+	if err != nil {
+		log.Printf("Consumer %s: undecodable message %s: %v", c.cfg.Queue, d.MessageId, err)
+		c.deadLetter(ctx, d)
+		return
+	}
+
+	handlers, ok := c.handlers[env.Type]
+	if !ok {
+		log.Printf("Consumer %s: no handler for event type %s (message %s)", c.cfg.Queue, env.Type, env.ID)
+		c.deadLetter(ctx, d)
+		return
+	}
+
+	for _, h := range handlers {
+		if err := h(ctx, env); err != nil {
+			if errors.Is(err, ErrPoisonMessage) {
+				log.Printf("Consumer %s: poison message %s: %v", c.cfg.Queue, env.ID, err)
+				c.deadLetter(ctx, d)
+				return
+			}
+			c.retry(ctx, d, err)
+			return
+		}
+	}
+
+	if err := d.Ack(false); err != nil {
+		log.Printf("Consumer %s: failed to ack message %s: %v", c.cfg.Queue, env.ID, err)
+	}
+}
+
+// retry parks the message in the delay queue with an incremented retry count
+// and an exponential backoff as its expiration, after which the broker
+// dead-letters it back to the main queue. It dead-letters the message once
+// MaxRetries is exhausted.
+func (c *Consumer) retry(ctx context.Context, d amqp.Delivery, cause error) {
+	attempt := retryCount(d) + 1
+	if attempt > c.cfg.MaxRetries {
+		log.Printf("Consumer %s: message %s failed after %d retries: %v", c.cfg.Queue, d.MessageId, c.cfg.MaxRetries, cause)
+		c.deadLetter(ctx, d)
+		return
+	}
+
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[HeaderRetryCount] = int32(attempt)
+	msg := republish(d, headers)
+	delay := c.backoff(attempt)
+	msg.Expiration = strconv.FormatInt(delay.Milliseconds(), 10)
+
+	err := c.ch.PublishWithContext(ctx,
+		"",                          // default exchange
+		RetryQueueName(c.cfg.Queue), // routing key
+		false,                       // mandatory
+		false,                       // immediate
+		msg,
+	)
+	if err != nil {
+		// Could not hand it back; let the broker redeliver the original.
+		log.Printf("Consumer %s: failed to requeue message %s: %v", c.cfg.Queue, d.MessageId, err)
+		d.Nack(false, true)
+		return
+	}
+
+	log.Printf("Consumer %s: message %s retry %d/%d in %s: %v", c.cfg.Queue, d.MessageId, attempt, c.cfg.MaxRetries, delay, cause)
+	d.Ack(false)
+}
+
+// backoff is the delay before the attempt-th retry.
+func (c *Consumer) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBackoff
+	for i := 1; i < attempt && delay < c.cfg.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > c.cfg.MaxRetryBackoff {
+		delay = c.cfg.MaxRetryBackoff
+	}
+	return delay
+}
+
+// deadLetter publishes the message to the dead-letter exchange of the queue
+// and acks it. The queue itself has no x-dead-letter-exchange, so a message
+// that cannot be moved is requeued rather than dropped.
+func (c *Consumer) deadLetter(ctx context.Context, d amqp.Delivery) {
+	err := c.ch.PublishWithContext(ctx,
+		DeadLetterExchangeName(c.cfg.Queue), // exchange
+		"",                                  // routing key (ignored for fanout)
+		false,                               // mandatory
+		false,                               // immediate
+		republish(d, d.Headers),
+	)
+	if err != nil {
+		log.Printf("Consumer %s: failed to dead-letter message %s: %v", c.cfg.Queue, d.MessageId, err)
+		d.Nack(false, true)
+		return
+	}
+	if err := d.Ack(false); err != nil {
+		log.Printf("Consumer %s: failed to ack dead-lettered message %s: %v", c.cfg.Queue, d.MessageId, err)
+	}
+}
+
+// republish copies a delivery into a new persistent message.
+func republish(d amqp.Delivery, headers amqp.Table) amqp.Publishing {
+	return amqp.Publishing{
+		Headers:      headers,
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    d.MessageId,
+		Type:         d.Type,
+		AppId:        d.AppId,
+		Timestamp:    d.Timestamp,
+		Body:         d.Body,
+	}
+}
+
+func retryCount(d amqp.Delivery) int {
+	switch v := d.Headers[HeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -2,11 +2,9 @@ package queue
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"worker-nicepay/application/events"
 	"worker-nicepay/application/services"
@@ -24,35 +22,26 @@ func NewRabbitMQQueue(channel *amqp.Channel) *RabbitMQQueue {
 	return &RabbitMQQueue{ch: channel}
 }
 
-// Enqueue publishes a task to RabbitMQ. This implementation only supports
-// publishing PaymentCreatedEvent. It uses an exchange named after the event
-// (fanout) and publishes the JSON payload with persistent delivery mode.
+// Enqueue publishes an event to RabbitMQ wrapped in an events.Envelope. It
+// uses an exchange named after the event type (fanout) with persistent
+// delivery mode.
 func (r *RabbitMQQueue) Enqueue(ctx context.Context, event services.Event) error {
 	if r == nil || r.ch == nil {
 		return errors.New("rabbitmq channel is not initialized; call InitializeRabbitMQ first")
 	}
 
-	// Only allow the PaymentCreatedEvent to be published via RabbitMQQueue
-	allowedEventName := (events.PaymentCreatedEvent{}).GetEventName()
-	eventName := event.GetEventName()
-	if eventName != allowedEventName {
-		return fmt.Errorf("unsupported event for RabbitMQQueue: %s (only %s is supported)", eventName, allowedEventName)
+	env, err := events.Wrap(event)
+	if err != nil {
+		return fmt.Errorf("failed to wrap event %s: %w", event.GetEventName(), err)
 	}
 
-	payload, err := json.Marshal(event)
+	pub, err := EnvelopeToPublishing(env)
 	if err != nil {
-		return fmt.Errorf("failed to marshal event payload: %w", err)
+		return fmt.Errorf("failed to encode event %s: %w", env.Type, err)
 	}
 
 	// Publish to the exchange with empty routing key (fanout)
-	pub := amqp.Publishing{
-		ContentType:  "application/json",
-		DeliveryMode: amqp.Persistent,
-		Body:         payload,
-		Timestamp:    time.Now(),
-	}
-
-	exchangeName := strings.ReplaceAll(eventName, "-", ".")
+	exchangeName := strings.ReplaceAll(env.Type, "-", ".")
 	if err := r.ch.PublishWithContext(ctx,
 		exchangeName, // exchange
 		"",           // routing key (ignored for fanout)
@@ -60,7 +49,7 @@ func (r *RabbitMQQueue) Enqueue(ctx context.Context, event services.Event) error
 		false,        // immediate
 		pub,
 	); err != nil {
-		return fmt.Errorf("failed to publish message to %s: %w", eventName, err)
+		return fmt.Errorf("failed to publish message to %s: %w", env.Type, err)
 	}
 
 	return nil
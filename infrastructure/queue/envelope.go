@@ -0,0 +1,71 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"worker-nicepay/application/events"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// CloudEvents attributes are duplicated into AMQP headers with this prefix so
+// consumers can route and filter without parsing the body.
+const cloudEventsHeaderPrefix = "cloudEvents:"
+
+const (
+	HeaderSpecVersion   = cloudEventsHeaderPrefix + "specversion"
+	HeaderID            = cloudEventsHeaderPrefix + "id"
+	HeaderType          = cloudEventsHeaderPrefix + "type"
+	HeaderSource        = cloudEventsHeaderPrefix + "source"
+	HeaderTime          = cloudEventsHeaderPrefix + "time"
+	HeaderSchemaVersion = cloudEventsHeaderPrefix + "schemaversion"
+	HeaderTransactionID = cloudEventsHeaderPrefix + "transactionid"
+	HeaderMerchantID    = cloudEventsHeaderPrefix + "merchantid"
+)
+
+// EnvelopeToPublishing encodes env in CloudEvents structured mode: the whole
+// envelope is the body and the attributes are mirrored into the headers.
+func EnvelopeToPublishing(env events.Envelope) (amqp.Publishing, error) {
+	if err := env.Validate(); err != nil {
+		return amqp.Publishing{}, err
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return amqp.Publishing{}, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	return amqp.Publishing{
+		ContentType:  events.ContentTypeCloudEventsJSON,
+		DeliveryMode: amqp.Persistent,
+		MessageId:    env.ID,
+		Type:         env.Type,
+		AppId:        env.Source,
+		Timestamp:    env.Time,
+		Headers: amqp.Table{
+			HeaderSpecVersion:   env.SpecVersion,
+			HeaderID:            env.ID,
+			HeaderType:          env.Type,
+			HeaderSource:        env.Source,
+			HeaderTime:          env.Time.Format(time.RFC3339Nano),
+			HeaderSchemaVersion: env.SchemaVersion,
+			HeaderTransactionID: env.TransactionID,
+			HeaderMerchantID:    env.MerchantID,
+		},
+		Body: body,
+	}, nil
+}
+
+// EnvelopeFromDelivery decodes a message written by EnvelopeToPublishing.
+func EnvelopeFromDelivery(d amqp.Delivery) (events.Envelope, error) {
+	var env events.Envelope
+	if err := json.Unmarshal(d.Body, &env); err != nil {
+		return events.Envelope{}, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+	}
+	if err := env.Validate(); err != nil {
+		return events.Envelope{}, err
+	}
+	return env, nil
+}
@@ -38,8 +38,65 @@ func InitializeRabbitMQ() {
 		log.Fatal("Failed to declare exchange: ", err)
 	}
 
-	// Declare queue to ensure it exists
+	// Declare the dead-letter exchange and queue that consumers publish
+	// rejected messages to. The main queue keeps its original arguments:
+	// redeclaring a live queue with an x-dead-letter-exchange fails with
+	// PRECONDITION_FAILED.
 	queueName := exchangeName
+	deadLetterExchange := DeadLetterExchangeName(queueName)
+	err = RabbitChan.ExchangeDeclare(
+		deadLetterExchange, // name
+		"fanout",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		log.Fatal("Failed to declare dead-letter exchange: ", err)
+	}
+
+	_, err = RabbitChan.QueueDeclare(
+		DeadLetterQueueName(queueName), // name
+		true,                           // durable
+		false,                          // delete when unused
+		false,                          // exclusive
+		false,                          // no-wait
+		nil,                            // arguments
+	)
+	if err != nil {
+		log.Fatal("Failed to declare dead-letter queue: ", err)
+	}
+
+	if err := RabbitChan.QueueBind(
+		DeadLetterQueueName(queueName), // queue name
+		"",                             // routing key (ignored for fanout)
+		deadLetterExchange,             // exchange
+		false,                          // no-wait
+		nil,                            // args
+	); err != nil {
+		log.Fatal("Failed to bind dead-letter queue: ", err)
+	}
+
+	// Declare the delay queue retries wait in; expired messages are
+	// dead-lettered back to the main queue through the default exchange
+	_, err = RabbitChan.QueueDeclare(
+		RetryQueueName(queueName), // name
+		true,                      // durable
+		false,                     // delete when unused
+		false,                     // exclusive
+		false,                     // no-wait
+		amqp.Table{
+			"x-dead-letter-exchange":    "",
+			"x-dead-letter-routing-key": queueName,
+		},
+	)
+	if err != nil {
+		log.Fatal("Failed to declare retry queue: ", err)
+	}
+
+	// Declare queue to ensure it exists
 	_, err = RabbitChan.QueueDeclare(
 		queueName, // name
 		true,      // durable
@@ -64,6 +121,18 @@ func InitializeRabbitMQ() {
 	}
 }
 
+func DeadLetterExchangeName(queueName string) string {
+	return queueName + ".dlx"
+}
+
+func DeadLetterQueueName(queueName string) string {
+	return queueName + ".dead"
+}
+
+func RetryQueueName(queueName string) string {
+	return queueName + ".retry"
+}
+
 func CloseRabbitMQ() {
 	if RabbitConn != nil {
 		RabbitConn.Close()
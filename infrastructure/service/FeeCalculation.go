@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"worker-nicepay/application/services"
+	"worker-nicepay/domain/fee"
+	"worker-nicepay/domain/money"
+
+	"github.com/google/uuid"
+)
+
+// calculateFees prices gross under the fee schedule of the merchant's
+// channel in force at the given time. Payments of channels without a
+// schedule carry no fee.
+func (s *NicePayTransactionService) calculateFees(ctx context.Context, merchantID uuid.UUID, paymentMethodID uuid.UUID, currencyID uuid.UUID, gross money.Money, at time.Time) (services.PaymentFees, error) {
+	fees := services.PaymentFees{CalculatedAt: at}
+
+	row, err := s.Repos.FeeSchedules.FindEffective(ctx, merchantID, paymentMethodID, currencyID, at.UnixMilli())
+	if err != nil {
+		return fees, err
+	}
+	breakdown := fee.None(gross)
+	if row != nil {
+		schedule, err := row.Schedule()
+		if err == nil {
+			breakdown, err = schedule.Calculate(gross)
+		}
+		if err != nil {
+			return fees, fmt.Errorf("fee schedule %s: %w", row.ID, err)
+		}
+		fees.ScheduleID = &row.ID
+	}
+
+	fees.Fee, fees.Tax, fees.Net = breakdown.Fee.Minor, breakdown.Tax.Minor, breakdown.Net.Minor
+	return fees, nil
+}
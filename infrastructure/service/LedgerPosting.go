@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"worker-nicepay/application/services"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/domain/money"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+)
+
+// postPaid journals a paid payment: the capture of its gross amount and the
+// fee charged on it. fees are those recorded with the payment outcome, or nil
+// to keep the fees of its creation. Must run in the transaction that marks
+// the payment paid.
+func (s *NicePayTransactionService) postPaid(ctx context.Context, payment models.PaymentsDataModel, currency *models.CurrenciesDataModel, fees *services.PaymentFees, paidAt time.Time) error {
+	if payment.MerchantID == nil || payment.Amount == nil {
+		return fmt.Errorf("payment %s has no merchant or amount to journal", payment.ID)
+	}
+	unit := currencyOf(currency)
+
+	entries := []ledger.Entry{ledger.CapturePayment(payment.ID, *payment.MerchantID, money.New(*payment.Amount, unit), paidAt)}
+	var fee, tax int64
+	switch {
+	case fees != nil:
+		fee, tax = fees.Fee, fees.Tax
+	case payment.FeeAmount != nil && payment.FeeTaxAmount != nil:
+		fee, tax = *payment.FeeAmount, *payment.FeeTaxAmount
+	}
+	if entry, ok := ledger.AssessFee(payment.ID, *payment.MerchantID, money.New(fee, unit), money.New(tax, unit), paidAt); ok {
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		if _, err := s.Repos.Ledger.Post(ctx, entry); err != nil {
+			return fmt.Errorf("failed to post %s: %w", entry.Key, err)
+		}
+	}
+	return nil
+}
+
+// CompleteRefund journals a refund Nicepay completed for the paid payment of
+// a merchant transaction. amount is in major units. The refunds of a payment
+// never add up to more than its gross amount. Completing the same refundID
+// again has no effect and returns false.
+func (s *NicePayTransactionService) CompleteRefund(ctx context.Context, transactionID string, refundID string, amount string, completedAt time.Time) (bool, error) {
+	var posted bool
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		payment, err := s.Repos.Payments.FindForUpdate(ctx, transactionID)
+		if err != nil {
+			return err
+		}
+		if payment.Status == nil || *payment.Status != constant.PAYMENT_STATUS_SUCCESS {
+			return apperror.Conflict(fmt.Sprintf("payment %s is not paid", transactionID), nil)
+		}
+		if payment.MerchantID == nil || payment.CurrencyID == nil || payment.Amount == nil {
+			return fmt.Errorf("payment %s has no merchant, currency or amount to journal", payment.ID)
+		}
+		currency, err := s.Repos.Currencies.FindByID(ctx, *payment.CurrencyID)
+		if err != nil {
+			return err
+		}
+		unit := currencyOf(currency)
+		refund, err := money.Parse(amount, unit)
+		if err != nil {
+			return apperror.InvalidRequest(err)
+		}
+		if !refund.IsPositive() {
+			return apperror.InvalidRequest(fmt.Errorf("refund amount %s is not positive", amount))
+		}
+
+		refunds, err := s.Repos.Ledger.Refunds(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+		if previous, ok := refunds[ledger.RefundKey(payment.ID, refundID)]; ok {
+			if previous != refund.Minor {
+				return apperror.Conflict(fmt.Sprintf("refund %s was completed for %s", refundID, money.New(previous, unit)), nil)
+			}
+			return nil
+		}
+		total := refund.Minor
+		for _, refunded := range refunds {
+			total += refunded
+		}
+		if total > *payment.Amount {
+			return apperror.Conflict(fmt.Sprintf("refunds of %s would exceed the payment amount %s", money.New(total, unit), money.New(*payment.Amount, unit)), nil)
+		}
+
+		posted, err = s.Repos.Ledger.Post(ctx, ledger.CompleteRefund(payment.ID, *payment.MerchantID, refundID, refund, completedAt))
+		return err
+	})
+	return posted, err
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	constant "worker-nicepay/infrastructure/const"
+)
+
+// createPayment creates a payment of amount, leaves it with status and
+// returns its transaction ID.
+func (f *fixture) createPayment(t *testing.T, amount string, status string) string {
+	t.Helper()
+	if _, _, err := f.service().Execute(context.Background(), f.request(amount), incoming()); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	payment := f.onlyPayment(t)
+	payment.Status = &status
+	f.store.Payments[payment.ID] = payment
+	return *payment.TransactionID
+}
+
+func TestCompleteRefund(t *testing.T) {
+	type refund struct {
+		id     string
+		amount string
+	}
+	tests := []struct {
+		name    string
+		status  string
+		earlier []refund
+		refund  refund
+		posted  bool
+		code    apperror.Code
+		// refunded is the total journaled afterwards, in minor units
+		refunded int64
+	}{
+		{name: "full refund", status: constant.PAYMENT_STATUS_SUCCESS, refund: refund{"R1", "150000"}, posted: true, refunded: 150000},
+		{name: "partial refunds", status: constant.PAYMENT_STATUS_SUCCESS, earlier: []refund{{"R1", "100000"}}, refund: refund{"R2", "50000"}, posted: true, refunded: 150000},
+		{name: "same refund again", status: constant.PAYMENT_STATUS_SUCCESS, earlier: []refund{{"R1", "150000"}}, refund: refund{"R1", "150000"}, refunded: 150000},
+		{name: "same refund with another amount", status: constant.PAYMENT_STATUS_SUCCESS, earlier: []refund{{"R1", "100000"}}, refund: refund{"R1", "50000"}, code: apperror.CodeConflict, refunded: 100000},
+		{name: "more than paid", status: constant.PAYMENT_STATUS_SUCCESS, refund: refund{"R1", "150001"}, code: apperror.CodeConflict},
+		{name: "more than left", status: constant.PAYMENT_STATUS_SUCCESS, earlier: []refund{{"R1", "100000"}}, refund: refund{"R2", "50001"}, code: apperror.CodeConflict, refunded: 100000},
+		{name: "too many decimals", status: constant.PAYMENT_STATUS_SUCCESS, refund: refund{"R1", "100.5"}, code: apperror.CodeInvalidRequest},
+		{name: "unpaid payment", status: constant.PAYMENT_STATUS_PENDING, refund: refund{"R1", "150000"}, code: apperror.CodeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			transactionID := f.createPayment(t, "150000", tt.status)
+			txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+			for _, earlier := range tt.earlier {
+				if _, err := txSvc.CompleteRefund(context.Background(), transactionID, earlier.id, earlier.amount, time.Now()); err != nil {
+					t.Fatalf("CompleteRefund %s: %v", earlier.id, err)
+				}
+			}
+
+			posted, err := txSvc.CompleteRefund(context.Background(), transactionID, tt.refund.id, tt.refund.amount, time.Now())
+			switch {
+			case tt.code != "" && apperror.CodeOf(err) != tt.code:
+				t.Fatalf("CompleteRefund error = %v, want code %s", err, tt.code)
+			case tt.code == "" && err != nil:
+				t.Fatalf("CompleteRefund: %v", err)
+			}
+			if posted != tt.posted {
+				t.Errorf("posted = %v, want %v", posted, tt.posted)
+			}
+
+			refunds, err := f.store.Repositories().Ledger.Refunds(context.Background(), f.onlyPayment(t).ID)
+			if err != nil {
+				t.Fatalf("Refunds: %v", err)
+			}
+			var refunded int64
+			for _, amount := range refunds {
+				refunded += amount
+			}
+			if refunded != tt.refunded {
+				t.Errorf("refunded %d, want %d", refunded, tt.refunded)
+			}
+		})
+	}
+}
+
+func TestCompleteRefundOfUnknownPayment(t *testing.T) {
+	f := newFixture()
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+	_, err := txSvc.CompleteRefund(context.Background(), "missing", "R1", "1000", time.Now())
+	if apperror.CodeOf(err) != apperror.CodeNotFound {
+		t.Errorf("CompleteRefund error = %v, want not found", err)
+	}
+}
+
+func TestCompleteRefundJournalsMerchantDebit(t *testing.T) {
+	f := newFixture()
+	transactionID := f.createPayment(t, "150000", constant.PAYMENT_STATUS_SUCCESS)
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+	if _, err := txSvc.CompleteRefund(context.Background(), transactionID, "R1", "40000", time.Now()); err != nil {
+		t.Fatalf("CompleteRefund: %v", err)
+	}
+
+	if len(f.store.Journal) != 1 {
+		t.Fatalf("journaled %d entries, want 1", len(f.store.Journal))
+	}
+	entry := f.store.Journal[0]
+	if entry.Type != ledger.RefundCompleted || entry.Currency != "IDR" {
+		t.Errorf("entry = %s in %s, want %s in IDR", entry.Type, entry.Currency, ledger.RefundCompleted)
+	}
+	for _, line := range entry.Lines {
+		want := ledger.Credit
+		if line.Account.Type == ledger.MerchantPayable {
+			want = ledger.Debit
+			if line.Account.MerchantID == nil || *line.Account.MerchantID != f.merchant.ID {
+				t.Errorf("merchant payable of %v, want %s", line.Account.MerchantID, f.merchant.ID)
+			}
+		}
+		if line.Direction != want || line.Amount != 40000 {
+			t.Errorf("%s line = %s %d, want %s 40000", line.Account.Type, line.Direction, line.Amount, want)
+		}
+	}
+}
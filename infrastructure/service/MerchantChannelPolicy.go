@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/database/models"
+)
+
+const activeHoursLayout = "15:04"
+
+// checkChannelPolicy enforces the merchant's configuration of the channel in
+// the payment currency: enablement, amount limits, active hours and daily
+// caps. Merchants without any configured channel are not restricted, so they
+// keep working until their channels are set up. Must run inside the payment
+// transaction, which holds the configuration row lock until the payment is
+// inserted.
+func (s *NicePayTransactionService) checkChannelPolicy(ctx context.Context, merchant *models.MerchantsDataModel, method *models.PaymentMethodsDataModel, currency *models.CurrenciesDataModel, amount money.Money, now time.Time) error {
+	config, err := s.Repos.MerchantPaymentMethods.FindForUpdate(ctx, merchant.ID, method.ID, currency.ID)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		restricted, err := s.Repos.MerchantPaymentMethods.HasAny(ctx, merchant.ID)
+		if err != nil || !restricted {
+			return err
+		}
+		return apperror.ChannelNotAllowed(fmt.Sprintf("channel %s is not enabled for merchant %s in %s", method.Name, merchant.Name, amount.Currency.Code))
+	}
+	if !config.Enabled {
+		return apperror.ChannelNotAllowed(fmt.Sprintf("channel %s is disabled for merchant %s", method.Name, merchant.Name))
+	}
+
+	limit := func(minor int64) string {
+		return money.New(minor, amount.Currency).String() + " " + amount.Currency.Code
+	}
+	if config.MinAmount != nil && amount.Minor < *config.MinAmount {
+		return apperror.LimitExceeded(fmt.Sprintf("amount %s is below the minimum %s of channel %s", limit(amount.Minor), limit(*config.MinAmount), method.Name))
+	}
+	if config.MaxAmount != nil && amount.Minor > *config.MaxAmount {
+		return apperror.LimitExceeded(fmt.Sprintf("amount %s is above the maximum %s of channel %s", limit(amount.Minor), limit(*config.MaxAmount), method.Name))
+	}
+
+	loc, err := time.LoadLocation(config.Timezone)
+	if err != nil {
+		return fmt.Errorf("channel %s of merchant %s has invalid timezone %q: %w", method.Name, merchant.Name, config.Timezone, err)
+	}
+	local := now.In(loc)
+	if config.ActiveFrom != nil && config.ActiveUntil != nil {
+		open, err := withinActiveHours(local, *config.ActiveFrom, *config.ActiveUntil)
+		if err != nil {
+			return fmt.Errorf("channel %s of merchant %s has invalid active hours: %w", method.Name, merchant.Name, err)
+		}
+		if !open {
+			return apperror.ChannelNotAllowed(fmt.Sprintf("channel %s is only available from %s to %s %s", method.Name, *config.ActiveFrom, *config.ActiveUntil, config.Timezone))
+		}
+	}
+
+	if config.DailyCountCap == nil && config.DailyAmountCap == nil {
+		return nil
+	}
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	count, total, err := s.Repos.Payments.DailyUsage(ctx, merchant.ID, method.ID, currency.ID, dayStart.UnixMilli())
+	if err != nil {
+		return err
+	}
+	if config.DailyCountCap != nil && count >= *config.DailyCountCap {
+		return apperror.LimitExceeded(fmt.Sprintf("daily cap of %d payments of channel %s is reached", *config.DailyCountCap, method.Name))
+	}
+	if config.DailyAmountCap != nil && total+amount.Minor > *config.DailyAmountCap {
+		remaining := max(*config.DailyAmountCap-total, 0)
+		return apperror.LimitExceeded(fmt.Sprintf("amount %s exceeds the remaining daily volume %s of channel %s", limit(amount.Minor), limit(remaining), method.Name))
+	}
+	return nil
+}
+
+// withinActiveHours reports whether the wall clock time of t lies in
+// [from, until). A window with until before from wraps past midnight.
+func withinActiveHours(t time.Time, from string, until string) (bool, error) {
+	start, err := time.Parse(activeHoursLayout, from)
+	if err != nil {
+		return false, err
+	}
+	end, err := time.Parse(activeHoursLayout, until)
+	if err != nil {
+		return false, err
+	}
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	if startMinute <= endMinute {
+		return minute >= startMinute && minute < endMinute, nil
+	}
+	return minute >= startMinute || minute < endMinute, nil
+}
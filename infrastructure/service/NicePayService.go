@@ -2,52 +2,143 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
 	"time"
 
 	"worker-nicepay/application/dto"
+	"worker-nicepay/application/events"
 	"worker-nicepay/application/services"
+	"worker-nicepay/domain/apperror"
 	"worker-nicepay/domain/entities"
+	"worker-nicepay/domain/money"
 	"worker-nicepay/infrastructure/configuration"
 	constant "worker-nicepay/infrastructure/const"
 	"worker-nicepay/infrastructure/database/models"
-	"worker-nicepay/infrastructure/database/repositories"
 	"worker-nicepay/infrastructure/gateway/nicepay"
 
-	"gorm.io/gorm"
+	"github.com/google/uuid"
 )
 
+// resolveLease bounds how long a replica may query Nicepay for a payment
+// before another replica may claim it again; it covers the gateway retries.
+const resolveLease = 2 * time.Minute
+
 type NicePayTransactionService struct {
-	db                *gorm.DB
-	TransactionRepo   *repositories.PaymentRepositoryYugabyteDB
-	CurrencyRepo      *repositories.CurrenciesRepository
-	CountryRepo       *repositories.CountriesRepository
-	PaymentMethodRepo *repositories.PaymentMethodsRepository
-	MerchantRepo      *repositories.MerchantsRepository
-	Gateway           services.PaymentGateway
+	uow     services.UnitOfWork
+	Repos   services.PaymentRepositories
+	Gateway services.PaymentGateway
+	Queue   services.EventQueue
 }
 
-func NewNicePayTransactionService(db *gorm.DB, transactionRepo *repositories.PaymentRepositoryYugabyteDB, currencyRepo *repositories.CurrenciesRepository, countryRepo *repositories.CountriesRepository, paymentMethodRepo *repositories.PaymentMethodsRepository, merchantRepo *repositories.MerchantsRepository, gateway services.PaymentGateway) *NicePayTransactionService {
-	return &NicePayTransactionService{db: db, TransactionRepo: transactionRepo, CurrencyRepo: currencyRepo, CountryRepo: countryRepo, PaymentMethodRepo: paymentMethodRepo, MerchantRepo: merchantRepo, Gateway: gateway}
+func NewNicePayTransactionService(uow services.UnitOfWork, repos services.PaymentRepositories, gateway services.PaymentGateway, queue services.EventQueue) *NicePayTransactionService {
+	return &NicePayTransactionService{uow: uow, Repos: repos, Gateway: gateway, Queue: queue}
 }
 
 func (s *NicePayTransactionService) Save(ctx context.Context, param dto.CreatePaymentRequest, incoming entities.Incoming) (string, entities.Payment, error) {
-
-	// find payment method
-	paymentMethod, err := s.PaymentMethodRepo.FindOne(s.db, models.PaymentMethodsDataModel{Name: param.ChannelCode})
-	if err != nil {
-		return "", entities.Payment{}, err
+	// payments.transaction_id is unique; callers outside the Incoming middleware may not set it
+	if incoming.TransactionID == "" {
+		incoming.TransactionID = uuid.NewString()
 	}
 
-	currency, err := s.CurrencyRepo.FindByCode(s.db, param.Currency)
-	if err != nil {
-		return "", entities.Payment{}, err
-	}
+	// Record the payment before calling Nicepay, so no Nicepay transaction
+	// can exist without a local row. Lookups and inserts share one transaction.
+	var payment models.PaymentsDataModel
+	var currency *models.CurrenciesDataModel
+	var amount money.Money
+	err := s.uow.Do(ctx, func(ctx context.Context) error {
+		// find payment method
+		paymentMethod, err := s.Repos.PaymentMethods.FindByName(ctx, param.ChannelCode)
+		if err != nil {
+			return err
+		}
 
-	country, err := s.CountryRepo.FindByCountryID(s.db, param.Country)
-	if err != nil {
-		return "", entities.Payment{}, err
-	}
-	merchant, err := s.MerchantRepo.FindOne(s.db, models.MerchantsDataModel{Name: incoming.Merchant})
+		currency, err = s.Repos.Currencies.FindByCode(ctx, param.Currency)
+		if err != nil {
+			return err
+		}
+		amount, err = money.Parse(param.Amount.String(), currencyOf(currency))
+		if err != nil {
+			return apperror.InvalidRequest(err)
+		}
+		if !amount.IsPositive() {
+			return apperror.InvalidRequest(fmt.Errorf("amount must be positive, got %s", param.Amount))
+		}
+
+		country, err := s.Repos.Countries.FindByCountryID(ctx, param.Country)
+		if err != nil {
+			return err
+		}
+		merchantID, err := uuid.Parse(param.MerchantID)
+		if err != nil {
+			return apperror.MerchantNotFound(param.MerchantID)
+		}
+		merchant, err := s.Repos.Merchants.FindByID(ctx, merchantID)
+		if err != nil {
+			return err
+		}
+		provider, err := s.Repos.EWalletProviders.FindByProviderName(ctx, param.ChannelCode)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := s.checkChannelPolicy(ctx, merchant, paymentMethod, currency, amount, now); err != nil {
+			return err
+		}
+		fees, err := s.calculateFees(ctx, merchant.ID, paymentMethod.ID, currency.ID, amount, now)
+		if err != nil {
+			return err
+		}
+
+		// Last point where an aborted job leaves nothing behind at Nicepay
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		statusInitiated := constant.PAYMENT_STATUS_INITIATED
+		expiredAt := now.Add(24 * time.Hour)
+		feeCalculatedDate := fees.CalculatedAt.UnixMilli()
+		payment = models.PaymentsDataModel{
+			TransactionID:     &incoming.TransactionID,
+			ReferenceNo:       &param.ReferenceNo,
+			PaymentGateway:    &param.ChannelCode,
+			PaymentMethodID:   &paymentMethod.ID,
+			CurrencyID:        &currency.ID,
+			Amount:            &amount.Minor,
+			Description:       &param.Description,
+			Status:            &statusInitiated,
+			ExpiredPayment:    &expiredAt,
+			CallbackURL:       &param.CallbackUrl,
+			MerchantID:        &merchant.ID,
+			CountryID:         &country.ID,
+			ResponseJson:      nil,
+			FeeScheduleID:     fees.ScheduleID,
+			FeeAmount:         &fees.Fee,
+			FeeTaxAmount:      &fees.Tax,
+			NetAmount:         &fees.Net,
+			FeeCalculatedDate: &feeCalculatedDate,
+		}
+		if err := s.Repos.Payments.Insert(ctx, &payment); err != nil {
+			return err
+		}
+
+		ewallet := models.PaymentNicepayEWalletsDataModel{
+			PaymentID:      &payment.ID,
+			TransactionID:  &incoming.TransactionID,
+			URLReturn:      configuration.AppConfig.ReturnURLNicepay,
+			CustomerMSISDN: &param.CustomerPhone,
+			CustomerEmail:  &param.CustomerEmail,
+		}
+		if provider != nil {
+			ewallet.EWalletProviderID = &provider.ID
+		}
+		return s.Repos.EWallets.Insert(ctx, &ewallet)
+	})
 	if err != nil {
 		return "", entities.Payment{}, err
 	}
@@ -59,39 +150,229 @@ func (s *NicePayTransactionService) Save(ctx context.Context, param dto.CreatePa
 		Name:        param.CustomerName,
 		Number:      param.ReferenceNo,
 		Channel:     param.ChannelCode,
-		Amount:      param.Amount,
+		Amount:      nicepay.AmountOf(amount),
 		Email:       param.CustomerEmail,
 		Description: param.Description,
 		IPAddress:   incoming.IP,
 	}, configuration.AppConfig.NicepayURL)
-	if err != nil {
-		return "", entities.Payment{}, err
-	}
 
 	go SaveAPICall(context.Background(), &res, incoming.Merchant, err, param.ChannelCode, incoming.Path, param.CustomerPhone, incoming.Webtype, incoming.TransactionID)
 
-	statusPending := constant.PAYMENT_STATUS_PENDING
-	expiredAt := time.Now().Add(24 * time.Hour)
-	err = s.TransactionRepo.Insert(s.db, &models.PaymentsDataModel{
-		TransactionID:   &incoming.TransactionID,
-		ReferenceNo:     &param.ReferenceNo,
-		PaymentGateway:  &param.ChannelCode,
-		PaymentMethodID: &paymentMethod.ID,
-		CurrencyID:      &currency.ID,
-		Amount:          &param.Amount,
-		Description:     &param.Description,
-		Status:          &statusPending,
-		ExpiredPayment:  &expiredAt,
-		CallbackURL:     &param.CallbackUrl,
-		MerchantID:      &merchant.ID,
-		CountryID:       &country.ID,
-		ResponseJson:    nil,
-	})
+	// Nicepay may hold the transaction now, so record the outcome even if the job was aborted meanwhile
+	persist := context.WithoutCancel(ctx)
 	if err != nil {
+		status := gatewayOutcome(err)
+		if uerr := s.recordOutcome(persist, payment.ID, paymentOutcome(status, "", "", res.RequestAPICallResult.ResponseBody)); uerr != nil {
+			log.Printf("Failed to mark payment %s %s: %v", payment.ID, status, uerr)
+		}
 		return "", entities.Payment{}, err
 	}
+
+	outcome := paymentOutcome(constant.PAYMENT_STATUS_PENDING, res.RedirectURL, res.TrxID, res.RequestAPICallResult.ResponseBody)
+	if err := s.recordOutcome(persist, payment.ID, outcome); err != nil {
+		// the row stays INITIATED and is settled by the resolver
+		log.Printf("Failed to mark payment %s PENDING: %v", payment.ID, err)
+	}
+
+	statusPending := constant.PAYMENT_STATUS_PENDING
+	payment.Status = &statusPending
+	s.publishPaymentCreated(ctx, payment, currency, res.RedirectURL)
+
 	// Assuming res.PaymentURL or similar exists, or just return success string?
 	// Nicepay response DTO has RedirectURL
 	return res.RedirectURL, entities.Payment{}, nil
 
 }
+
+// Resolve settles up to limit payments whose gateway outcome is unknown, and
+// moves PENDING payments that were paid, failed or expired, by querying
+// Nicepay. It returns how many changed status. Each payment is leased in a
+// short transaction, queried with no transaction open and settled in a second
+// transaction. A payment that becomes SUCCESS is priced again and journaled
+// there, so this is the paid transition of every payment.
+func (s *NicePayTransactionService) Resolve(ctx context.Context, staleAfter time.Duration, limit int) (int, error) {
+	passStart := time.Now().UnixMilli()
+	staleBefore := time.Now().Add(-staleAfter).UnixMilli()
+
+	settled := 0
+	for i := 0; i < limit; i++ {
+		payment, err := s.Repos.Payments.ClaimUnresolved(ctx, staleBefore, passStart, time.Now(), resolveLease)
+		if err != nil {
+			return settled, err
+		}
+		if payment == nil {
+			break
+		}
+
+		previous := *payment.Status
+		res, err := s.Gateway.QueryPayment(ctx, nicepay.RequestQueryPaymentDTO{
+			Number:  *payment.ReferenceNo,
+			Channel: *payment.PaymentGateway,
+		}, configuration.AppConfig.NicepayStatusURL)
+		status := resolvedStatus(res, err)
+		if status == "" || status == previous {
+			// still unknown or still waiting for the payer, try again on a later pass
+			if previous != constant.PAYMENT_STATUS_PENDING {
+				status = constant.PAYMENT_STATUS_UNKNOWN
+			}
+			err := s.Repos.Payments.UpdateOutcome(ctx, payment.ID, services.PaymentOutcome{
+				Status:    status,
+				UpdatedAt: time.Now(),
+			})
+			if err != nil {
+				return settled, err
+			}
+			continue
+		}
+
+		var currency *models.CurrenciesDataModel
+		outcome := paymentOutcome(status, res.RedirectURL, res.TrxID, res.RequestAPICallResult.ResponseBody)
+		err = s.uow.Do(ctx, func(ctx context.Context) error {
+			if status == constant.PAYMENT_STATUS_PENDING || status == constant.PAYMENT_STATUS_SUCCESS {
+				var err error
+				if currency, err = s.Repos.Currencies.FindByID(ctx, *payment.CurrencyID); err != nil {
+					return err
+				}
+			}
+			if status == constant.PAYMENT_STATUS_SUCCESS {
+				outcome.Fees = s.paidFees(ctx, *payment, currency, outcome.UpdatedAt)
+			}
+			if err := s.recordOutcome(ctx, payment.ID, outcome); err != nil {
+				return err
+			}
+			if status == constant.PAYMENT_STATUS_SUCCESS {
+				return s.postPaid(ctx, *payment, currency, outcome.Fees, outcome.UpdatedAt)
+			}
+			return nil
+		})
+		if err != nil {
+			return settled, err
+		}
+		settled++
+		log.Printf("Resolved payment %s to %s", payment.ID, status)
+
+		if status == constant.PAYMENT_STATUS_PENDING {
+			// the link request of an UNKNOWN or INITIATED payment went through
+			payment.Status = &status
+			s.publishPaymentCreated(ctx, *payment, currency, res.RedirectURL)
+		}
+	}
+	return settled, nil
+}
+
+// paidFees prices a payment again under the fee schedule in force when it was
+// paid. A failure keeps the fees of its creation rather than holding back the
+// payment outcome, so it is only logged.
+func (s *NicePayTransactionService) paidFees(ctx context.Context, payment models.PaymentsDataModel, currency *models.CurrenciesDataModel, paidAt time.Time) *services.PaymentFees {
+	if payment.MerchantID == nil || payment.PaymentMethodID == nil || payment.Amount == nil {
+		return nil
+	}
+	gross := money.New(*payment.Amount, currencyOf(currency))
+	fees, err := s.calculateFees(ctx, *payment.MerchantID, *payment.PaymentMethodID, currency.ID, gross, paidAt)
+	if err != nil {
+		log.Printf("Failed to calculate fees of paid payment %s: %v", payment.ID, err)
+		return nil
+	}
+	return &fees
+}
+
+// gatewayOutcome maps a failed payment link request to the payment status.
+// Only errors where Nicepay certainly did not create the transaction are FAILED.
+func gatewayOutcome(err error) string {
+	switch apperror.CodeOf(err) {
+	case apperror.CodeUpstreamRejected, apperror.CodeGatewayUnavailable, apperror.CodeInvalidRequest:
+		return constant.PAYMENT_STATUS_FAILED
+	case apperror.CodeInternal:
+		// raw context errors come from the guards, before anything was sent
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return constant.PAYMENT_STATUS_FAILED
+		}
+	}
+	return constant.PAYMENT_STATUS_UNKNOWN
+}
+
+// resolvedStatus maps a Nicepay status query to the payment status, or ""
+// when the outcome is still unknown.
+func resolvedStatus(res nicepay.ResponseQueryPaymentDTO, err error) string {
+	if err != nil {
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) && appErr.UpstreamStatus == http.StatusNotFound {
+			// Nicepay never created the transaction
+			return constant.PAYMENT_STATUS_FAILED
+		}
+		return ""
+	}
+	return nicepayStatus(res.Status)
+}
+
+// nicepayStatus maps a Nicepay transaction status to the payment status, or
+// "" when it is not known.
+func nicepayStatus(status string) string {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "PENDING", "CREATED", "WAITING":
+		return constant.PAYMENT_STATUS_PENDING
+	case "SUCCESS", "PAID", "SETTLED":
+		return constant.PAYMENT_STATUS_SUCCESS
+	case "FAILED", "REJECTED":
+		return constant.PAYMENT_STATUS_FAILED
+	case "CANCEL", "CANCELLED":
+		return constant.PAYMENT_STATUS_CANCEL
+	case "EXPIRED":
+		return constant.PAYMENT_STATUS_EXPIRED
+	}
+	return ""
+}
+
+// recordOutcome stores the gateway outcome on the payment and its e-wallet
+// details in one transaction.
+func (s *NicePayTransactionService) recordOutcome(ctx context.Context, paymentID uuid.UUID, outcome services.PaymentOutcome) error {
+	return s.uow.Do(ctx, func(ctx context.Context) error {
+		if err := s.Repos.Payments.UpdateOutcome(ctx, paymentID, outcome); err != nil {
+			return err
+		}
+		return s.Repos.EWallets.UpdateOutcome(ctx, paymentID, outcome)
+	})
+}
+
+func paymentOutcome(status string, redirectURL string, trxID string, responseBody string) services.PaymentOutcome {
+	outcome := services.PaymentOutcome{
+		Status:               status,
+		RedirectURL:          redirectURL,
+		NicepayTransactionID: trxID,
+		UpdatedAt:            time.Now(),
+	}
+	if json.Valid([]byte(responseBody)) {
+		outcome.ResponseJson = json.RawMessage(responseBody)
+	}
+	return outcome
+}
+
+// publishPaymentCreated enqueues the payment.created event. The payment is
+// already committed, so a publish failure is logged and not returned.
+func (s *NicePayTransactionService) publishPaymentCreated(ctx context.Context, payment models.PaymentsDataModel, currency *models.CurrenciesDataModel, redirectURL string) {
+	if s.Queue == nil {
+		return
+	}
+	amount := money.New(*payment.Amount, currencyOf(currency))
+	event := events.PaymentCreatedEvent{
+		PaymentID:     payment.ID.String(),
+		TransactionID: *payment.TransactionID,
+		MerchantID:    payment.MerchantID.String(),
+		ReferenceNo:   *payment.ReferenceNo,
+		ChannelCode:   *payment.PaymentGateway,
+		Currency:      currency.Code,
+		Amount:        json.Number(amount.String()),
+		AmountMinor:   amount.Minor,
+		Status:        *payment.Status,
+		RedirectURL:   redirectURL,
+		CallbackURL:   *payment.CallbackURL,
+		ExpiredAt:     *payment.ExpiredPayment,
+	}
+	if err := s.Queue.Enqueue(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to publish %s for transaction %s: %v", event.GetEventName(), event.TransactionID, err)
+	}
+}
+
+func currencyOf(currency *models.CurrenciesDataModel) money.Currency {
+	return money.Currency{Code: currency.Code, Exponent: currency.MinorUnit}
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/application/services"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/entities"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/infrastructure/configuration"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/memory"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/gateway/nicepay"
+	"worker-nicepay/infrastructure/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestMain(m *testing.M) {
+	configuration.InitializeAppConfig()
+	os.Exit(m.Run())
+}
+
+type fakeGateway struct {
+	err      error
+	requests []nicepay.RequestPaymentLinkDTO
+	// status answers QueryPayment
+	status string
+}
+
+func (g *fakeGateway) RequestPaymentLink(ctx context.Context, req nicepay.RequestPaymentLinkDTO, url string) (nicepay.ResponsePaymentLinkDTO, error) {
+	g.requests = append(g.requests, req)
+	if g.err != nil {
+		return nicepay.ResponsePaymentLinkDTO{}, g.err
+	}
+	return nicepay.ResponsePaymentLinkDTO{RedirectURL: "https://pay.example/" + req.Number, TrxID: "NP-" + req.Number}, nil
+}
+
+func (g *fakeGateway) QueryPayment(ctx context.Context, req nicepay.RequestQueryPaymentDTO, url string) (nicepay.ResponseQueryPaymentDTO, error) {
+	if g.status == "" {
+		return nicepay.ResponseQueryPaymentDTO{}, apperror.Timeout(errors.New("no answer"))
+	}
+	return nicepay.ResponseQueryPaymentDTO{Status: g.status, TrxID: "NP-" + req.Number}, nil
+}
+
+// failingEWallets fails the second insert of payment creation.
+type failingEWallets struct {
+	services.PaymentEWalletRepository
+}
+
+func (failingEWallets) Insert(ctx context.Context, ewallet *models.PaymentNicepayEWalletsDataModel) error {
+	return errors.New("insert failed")
+}
+
+type fixture struct {
+	store    *memory.Store
+	gateway  *fakeGateway
+	merchant models.MerchantsDataModel
+	method   models.PaymentMethodsDataModel
+	currency models.CurrenciesDataModel
+}
+
+func newFixture() *fixture {
+	f := &fixture{
+		store:    memory.NewStore(),
+		gateway:  &fakeGateway{},
+		merchant: models.MerchantsDataModel{ID: uuid.New(), Name: "Acme", Code: "ACME"},
+		method:   models.PaymentMethodsDataModel{ID: uuid.New(), Name: "dana"},
+		currency: models.CurrenciesDataModel{ID: uuid.New(), Code: "IDR", Name: "Rupiah", MinorUnit: 0},
+	}
+	f.store.Merchants = []models.MerchantsDataModel{f.merchant}
+	f.store.PaymentMethods = []models.PaymentMethodsDataModel{f.method}
+	f.store.Currencies = []models.CurrenciesDataModel{f.currency}
+	f.store.Countries = []models.CountriesDataModel{{ID: uuid.New(), Code: "ID", Name: "Indonesia"}}
+	return f
+}
+
+func (f *fixture) service() *services.CreatePaymentService {
+	return f.serviceWith(f.store.Repositories())
+}
+
+func (f *fixture) serviceWith(repos services.PaymentRepositories) *services.CreatePaymentService {
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), repos, f.gateway, nil)
+	return services.NewCreatePaymentService(f.gateway, txSvc)
+}
+
+func (f *fixture) request(amount string) dto.CreatePaymentRequest {
+	return dto.CreatePaymentRequest{
+		ReferenceNo:   "INV-1",
+		Amount:        json.Number(amount),
+		MerchantID:    f.merchant.ID.String(),
+		ProductID:     "product",
+		ChannelCode:   f.method.Name,
+		Currency:      f.currency.Code,
+		Country:       "ID",
+		CustomerName:  "Budi",
+		CustomerPhone: "08123456789",
+		CallbackUrl:   "https://merchant.example/callback",
+	}
+}
+
+// incoming leaves the transaction ID to Save, as for jobs not made by the
+// Incoming middleware.
+func incoming() entities.Incoming {
+	return entities.Incoming{IP: "127.0.0.1"}
+}
+
+// onlyPayment returns the single stored payment.
+func (f *fixture) onlyPayment(t *testing.T) models.PaymentsDataModel {
+	t.Helper()
+	if len(f.store.Payments) != 1 {
+		t.Fatalf("stored %d payments, want 1", len(f.store.Payments))
+	}
+	for _, payment := range f.store.Payments {
+		return payment
+	}
+	panic("unreachable")
+}
+
+func TestCreatePaymentStoresPendingPayment(t *testing.T) {
+	f := newFixture()
+
+	url, _, err := f.service().Execute(context.Background(), f.request("150000"), incoming())
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if url != "https://pay.example/INV-1" {
+		t.Errorf("redirect url = %q", url)
+	}
+
+	payment := f.onlyPayment(t)
+	if *payment.Status != constant.PAYMENT_STATUS_PENDING {
+		t.Errorf("status = %s, want %s", *payment.Status, constant.PAYMENT_STATUS_PENDING)
+	}
+	if *payment.Amount != 150000 || *payment.MerchantID != f.merchant.ID || *payment.CurrencyID != f.currency.ID {
+		t.Errorf("payment = amount %d merchant %s currency %s", *payment.Amount, *payment.MerchantID, *payment.CurrencyID)
+	}
+	ewallet, ok := f.store.EWallets[payment.ID]
+	if !ok || ewallet.NicepayTransactionID == nil || *ewallet.NicepayTransactionID != "NP-INV-1" {
+		t.Errorf("e-wallet row = %+v, want the Nicepay transaction id", ewallet)
+	}
+	if len(f.gateway.requests) != 1 || f.gateway.requests[0].Amount != "150000" {
+		t.Errorf("gateway requests = %+v", f.gateway.requests)
+	}
+}
+
+func TestCreatePaymentUnknownMerchant(t *testing.T) {
+	f := newFixture()
+	req := f.request("150000")
+	req.MerchantID = uuid.NewString()
+
+	_, _, err := f.service().Execute(context.Background(), req, incoming())
+	if apperror.CodeOf(err) != apperror.CodeMerchantNotFound {
+		t.Fatalf("err = %v, want %s", err, apperror.CodeMerchantNotFound)
+	}
+	if len(f.store.Payments) != 0 || len(f.gateway.requests) != 0 {
+		t.Errorf("stored %d payments and sent %d gateway requests, want none", len(f.store.Payments), len(f.gateway.requests))
+	}
+}
+
+func TestCreatePaymentRollsBackFailedInsert(t *testing.T) {
+	f := newFixture()
+	repos := f.store.Repositories()
+	repos.EWallets = failingEWallets{repos.EWallets}
+
+	if _, _, err := f.serviceWith(repos).Execute(context.Background(), f.request("150000"), incoming()); err == nil {
+		t.Fatal("Execute succeeded, want the insert error")
+	}
+	if len(f.store.Payments) != 0 {
+		t.Errorf("stored %d payments, want the payment insert rolled back", len(f.store.Payments))
+	}
+	if len(f.gateway.requests) != 0 {
+		t.Errorf("sent %d gateway requests, want none", len(f.gateway.requests))
+	}
+}
+
+// The payment is committed before Nicepay is called, so a rejection is
+// recorded on it rather than rolled back.
+func TestCreatePaymentRecordsGatewayRejection(t *testing.T) {
+	f := newFixture()
+	f.gateway.err = apperror.Upstream(http.StatusBadRequest, "invalid msisdn")
+
+	_, _, err := f.service().Execute(context.Background(), f.request("150000"), incoming())
+	if apperror.CodeOf(err) != apperror.CodeUpstreamRejected {
+		t.Fatalf("err = %v, want %s", err, apperror.CodeUpstreamRejected)
+	}
+	if payment := f.onlyPayment(t); *payment.Status != constant.PAYMENT_STATUS_FAILED {
+		t.Errorf("status = %s, want %s", *payment.Status, constant.PAYMENT_STATUS_FAILED)
+	}
+	if len(f.store.Journal) != 0 {
+		t.Errorf("journaled %d entries for a failed payment", len(f.store.Journal))
+	}
+}
+
+func TestCreatePaymentChannelPolicy(t *testing.T) {
+	maxAmount := int64(100000)
+	tests := []struct {
+		name   string
+		config models.MerchantPaymentMethodsDataModel
+		code   apperror.Code
+	}{
+		{"disabled", models.MerchantPaymentMethodsDataModel{Enabled: false}, apperror.CodeChannelNotAllowed},
+		{"above maximum", models.MerchantPaymentMethodsDataModel{Enabled: true, MaxAmount: &maxAmount}, apperror.CodeLimitExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			config := tt.config
+			config.ID, config.MerchantID, config.PaymentMethodID, config.CurrencyID = uuid.New(), f.merchant.ID, f.method.ID, f.currency.ID
+			config.Timezone = "Asia/Jakarta"
+			f.store.MerchantPaymentMethods = []models.MerchantPaymentMethodsDataModel{config}
+
+			_, _, err := f.service().Execute(context.Background(), f.request("150000"), incoming())
+			if apperror.CodeOf(err) != tt.code {
+				t.Fatalf("err = %v, want %s", err, tt.code)
+			}
+			if len(f.store.Payments) != 0 || len(f.gateway.requests) != 0 {
+				t.Errorf("stored %d payments and sent %d gateway requests, want none", len(f.store.Payments), len(f.gateway.requests))
+			}
+		})
+	}
+}
+
+func TestCreatePaymentStoresFees(t *testing.T) {
+	f := newFixture()
+	percentage, tax := "2.5", "11"
+	f.store.FeeSchedules = []models.FeeSchedulesDataModel{{
+		ID:              uuid.New(),
+		MerchantID:      f.merchant.ID,
+		PaymentMethodID: f.method.ID,
+		CurrencyID:      f.currency.ID,
+		FeeType:         "PERCENTAGE",
+		Percentage:      &percentage,
+		TaxPercentage:   &tax,
+	}}
+
+	if _, _, err := f.service().Execute(context.Background(), f.request("200000"), incoming()); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	payment := f.onlyPayment(t)
+	if payment.FeeScheduleID == nil || *payment.FeeScheduleID != f.store.FeeSchedules[0].ID {
+		t.Errorf("fee schedule = %v, want %s", payment.FeeScheduleID, f.store.FeeSchedules[0].ID)
+	}
+	// 2.5% of 200000 is 5000, with 11% tax of 550
+	if *payment.FeeAmount != 5000 || *payment.FeeTaxAmount != 550 || *payment.NetAmount != 194450 {
+		t.Errorf("fee %d tax %d net %d, want 5000 550 194450", *payment.FeeAmount, *payment.FeeTaxAmount, *payment.NetAmount)
+	}
+}
+
+// Payments are stored under the transaction ID of their request, which is
+// unique, so each request through the Incoming middleware must get its own.
+func TestCreatePaymentThroughIncomingMiddleware(t *testing.T) {
+	f := newFixture()
+	assigned := map[string]bool{}
+	app := fiber.New()
+	app.Use((&middleware.Middlewares{}).Incoming())
+	app.Post("/payment/nicepay", func(c *fiber.Ctx) error {
+		var req dto.CreatePaymentRequest
+		if err := c.BodyParser(&req); err != nil {
+			return err
+		}
+		incoming := c.Locals("incoming").(*entities.Incoming)
+		assigned[incoming.TransactionID] = true
+		if _, _, err := f.service().Execute(c.UserContext(), req, *incoming); err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, referenceNo := range []string{"INV-1", "INV-2"} {
+		req := f.request("150000")
+		req.ReferenceNo = referenceNo
+		body, _ := json.Marshal(req)
+		httpReq := httptest.NewRequest(http.MethodPost, "/payment/nicepay", bytes.NewReader(body))
+		httpReq.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		res, err := app.Test(httpReq)
+		if err != nil {
+			t.Fatalf("POST %s: %v", referenceNo, err)
+		}
+		if res.StatusCode != fiber.StatusOK {
+			msg, _ := io.ReadAll(res.Body)
+			t.Fatalf("POST %s = %d: %s", referenceNo, res.StatusCode, msg)
+		}
+	}
+
+	if len(assigned) != 2 || assigned[""] {
+		t.Fatalf("middleware assigned transaction ids %v, want 2 distinct ones", assigned)
+	}
+	for _, payment := range f.store.Payments {
+		if payment.TransactionID == nil || !assigned[*payment.TransactionID] {
+			t.Errorf("payment %s has transaction id %v, want the one of its request", payment.ID, payment.TransactionID)
+		}
+	}
+	if len(f.store.Payments) != 2 {
+		t.Errorf("stored %d payments, want 2", len(f.store.Payments))
+	}
+}
+
+// checkedAgo makes the payments look last checked d ago.
+func (f *fixture) checkedAgo(d time.Duration) {
+	for id, payment := range f.store.Payments {
+		checked := time.Now().Add(-d).UnixMilli()
+		payment.CreatedDate, payment.UpdatedDate = &checked, &checked
+		f.store.Payments[id] = payment
+	}
+}
+
+func TestResolvePaidPendingPayment(t *testing.T) {
+	f := newFixture()
+	if _, _, err := f.service().Execute(context.Background(), f.request("150000"), incoming()); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	f.checkedAgo(10 * time.Minute)
+	f.gateway.status = "PAID"
+
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+	settled, err := txSvc.Resolve(context.Background(), 5*time.Minute, 10)
+	if err != nil || settled != 1 {
+		t.Fatalf("Resolve = %d, %v, want 1 settled", settled, err)
+	}
+	if payment := f.onlyPayment(t); *payment.Status != constant.PAYMENT_STATUS_SUCCESS {
+		t.Errorf("status = %s, want %s", *payment.Status, constant.PAYMENT_STATUS_SUCCESS)
+	}
+	if len(f.store.Journal) != 1 || f.store.Journal[0].Type != ledger.PaymentCaptured {
+		t.Errorf("journal = %+v, want the capture", f.store.Journal)
+	}
+
+	// a second pass neither claims nor journals the payment again
+	if settled, err := txSvc.Resolve(context.Background(), 5*time.Minute, 10); err != nil || settled != 0 {
+		t.Errorf("second Resolve = %d, %v, want nothing settled", settled, err)
+	}
+	if len(f.store.Journal) != 1 {
+		t.Errorf("journaled %d entries after the second pass, want 1", len(f.store.Journal))
+	}
+}
+
+// A payment is charged the fee in force when it is paid, not when it was
+// created, and that fee is journaled with the capture.
+func TestResolvePricesPaymentAtPaidTime(t *testing.T) {
+	f := newFixture()
+	percentage := "2.5"
+	f.store.FeeSchedules = []models.FeeSchedulesDataModel{{
+		ID:              uuid.New(),
+		MerchantID:      f.merchant.ID,
+		PaymentMethodID: f.method.ID,
+		CurrencyID:      f.currency.ID,
+		FeeType:         "PERCENTAGE",
+		Percentage:      &percentage,
+	}}
+	if _, _, err := f.service().Execute(context.Background(), f.request("200000"), incoming()); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	f.checkedAgo(10 * time.Minute)
+	// a flat fee takes over after the payment was created
+	flat := models.FeeSchedulesDataModel{
+		ID:              uuid.New(),
+		MerchantID:      f.merchant.ID,
+		PaymentMethodID: f.method.ID,
+		CurrencyID:      f.currency.ID,
+		FeeType:         "FLAT",
+		FlatAmount:      3000,
+		EffectiveFrom:   time.Now().Add(-time.Minute).UnixMilli(),
+	}
+	f.store.FeeSchedules = append(f.store.FeeSchedules, flat)
+	f.gateway.status = "PAID"
+
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+	if settled, err := txSvc.Resolve(context.Background(), 5*time.Minute, 10); err != nil || settled != 1 {
+		t.Fatalf("Resolve = %d, %v, want 1 settled", settled, err)
+	}
+	payment := f.onlyPayment(t)
+	if payment.FeeScheduleID == nil || *payment.FeeScheduleID != flat.ID {
+		t.Errorf("fee schedule = %v, want %s", payment.FeeScheduleID, flat.ID)
+	}
+	if *payment.FeeAmount != 3000 || *payment.NetAmount != 197000 {
+		t.Errorf("fee %d net %d, want 3000 197000", *payment.FeeAmount, *payment.NetAmount)
+	}
+
+	var fee int64
+	for _, entry := range f.store.Journal {
+		if entry.Type != ledger.FeeAssessed {
+			continue
+		}
+		for _, line := range entry.Lines {
+			if line.Account.Type == ledger.FeeRevenue {
+				fee += line.Amount
+			}
+		}
+	}
+	if len(f.store.Journal) != 2 || fee != 3000 {
+		t.Errorf("journal = %+v, want the capture and a fee of 3000", f.store.Journal)
+	}
+}
+
+func TestResolveKeepsUnpaidPendingPayment(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		checked time.Duration
+	}{
+		{"still pending", "PENDING", 10 * time.Minute},
+		{"no answer", "", 10 * time.Minute},
+		{"checked recently", "PAID", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFixture()
+			if _, _, err := f.service().Execute(context.Background(), f.request("150000"), incoming()); err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			f.checkedAgo(tt.checked)
+			f.gateway.status = tt.status
+
+			txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+			if settled, err := txSvc.Resolve(context.Background(), 5*time.Minute, 10); err != nil || settled != 0 {
+				t.Fatalf("Resolve = %d, %v, want nothing settled", settled, err)
+			}
+			if payment := f.onlyPayment(t); *payment.Status != constant.PAYMENT_STATUS_PENDING {
+				t.Errorf("status = %s, want %s", *payment.Status, constant.PAYMENT_STATUS_PENDING)
+			}
+			if len(f.store.Journal) != 0 {
+				t.Errorf("journaled %d entries for an unpaid payment", len(f.store.Journal))
+			}
+		})
+	}
+}
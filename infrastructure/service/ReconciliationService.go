@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/domain/settlement"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const reconciliationBatch = 500
+
+// SettlementImport is a settlement file to reconcile. When the period is
+// set, successful payments created in [PeriodFrom, PeriodTo) that the file
+// does not list are reported as missing from the settlement.
+type SettlementImport struct {
+	FileName   string
+	File       io.Reader
+	Mapping    settlement.Mapping
+	Comma      rune
+	PeriodFrom *time.Time
+	PeriodTo   *time.Time
+}
+
+// The repositories the reconciliation reads and writes; the gorm
+// repositories implement them.
+type (
+	settlementPayments interface {
+		FindByReferenceNos(tx *gorm.DB, referenceNos []string) ([]models.PaymentsDataModel, error)
+		FindSucceededBetween(tx *gorm.DB, from int64, to int64, afterID uuid.UUID, limit int) ([]models.PaymentsDataModel, error)
+	}
+	settlementEWallets interface {
+		FindByNicepayTransactionIDs(tx *gorm.DB, nicepayTransactionIDs []string) ([]models.PaymentNicepayEWalletsDataModel, error)
+	}
+	currenciesByCode interface {
+		FindByCode(tx *gorm.DB, code string, opts ...repositories.QueryOption) (*models.CurrenciesDataModel, error)
+	}
+	reconciliationRuns interface {
+		Insert(tx *gorm.DB, run *models.ReconciliationRunsDataModel) error
+		Update(tx *gorm.DB, id uuid.UUID, values map[string]interface{}) error
+		InsertItems(tx *gorm.DB, items []models.ReconciliationItemsDataModel) error
+	}
+)
+
+type ReconciliationService struct {
+	db         *gorm.DB
+	payments   settlementPayments
+	ewallets   settlementEWallets
+	currencies currenciesByCode
+	runs       reconciliationRuns
+}
+
+func NewReconciliationService(db *gorm.DB, payments settlementPayments, ewallets settlementEWallets, currencies currenciesByCode, runs reconciliationRuns) *ReconciliationService {
+	return &ReconciliationService{db: db, payments: payments, ewallets: ewallets, currencies: currencies, runs: runs}
+}
+
+// reconciliation is the state of one run while its file is read.
+type reconciliation struct {
+	run   models.ReconciliationRunsDataModel
+	units map[string]money.Currency
+	// settled maps the payments listed in the file to their first line
+	settled map[uuid.UUID]int
+}
+
+// Import reconciles a settlement file against the payments and saves the
+// outcome as a reconciliation run. The file is read in batches, so its size
+// is not bounded by memory. A run that fails midway is kept as FAILED.
+func (s *ReconciliationService) Import(ctx context.Context, in SettlementImport) (*models.ReconciliationRunsDataModel, error) {
+	if (in.PeriodFrom == nil) != (in.PeriodTo == nil) || (in.PeriodFrom != nil && !in.PeriodFrom.Before(*in.PeriodTo)) {
+		return nil, apperror.InvalidRequest(errors.New("period_from must come before period_to, and both are needed"))
+	}
+	reader, err := settlement.NewReader(in.File, in.Mapping, in.Comma)
+	if err != nil {
+		return nil, apperror.InvalidRequest(err)
+	}
+	db := s.db.WithContext(ctx)
+
+	r := &reconciliation{
+		run: models.ReconciliationRunsDataModel{
+			FileName: in.FileName,
+			Status:   constant.RECONCILIATION_STATUS_RUNNING,
+			Mapping:  in.Mapping.String(),
+		},
+		units:   map[string]money.Currency{},
+		settled: map[uuid.UUID]int{},
+	}
+	if in.PeriodFrom != nil {
+		from, to := in.PeriodFrom.UnixMilli(), in.PeriodTo.UnixMilli()
+		r.run.PeriodFrom, r.run.PeriodTo = &from, &to
+	}
+	if err := s.runs.Insert(db, &r.run); err != nil {
+		return nil, err
+	}
+
+	err = s.reconcileFile(db, r, reader)
+	if err == nil && r.run.PeriodFrom != nil {
+		err = s.reconcileMissing(db, r)
+	}
+	return s.finish(db, r, err)
+}
+
+func (s *ReconciliationService) reconcileFile(db *gorm.DB, r *reconciliation, reader *settlement.Reader) error {
+	batch := make([]settlement.Row, 0, reconciliationBatch)
+	var items []models.ReconciliationItemsDataModel
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			// the reader resumes after a malformed record
+			r.run.TotalRows++
+			items = append(items, r.item(settlement.Row{Line: parseErr.Line}, nil, settlement.Invalid, nil, parseErr.Error()))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read settlement file: %w", err)
+		}
+		r.run.TotalRows++
+		batch = append(batch, row)
+		if len(batch) < reconciliationBatch {
+			continue
+		}
+		if err := s.reconcileBatch(db, r, batch, items); err != nil {
+			return err
+		}
+		batch, items = batch[:0], nil
+	}
+	return s.reconcileBatch(db, r, batch, items)
+}
+
+// reconcileBatch matches rows to payments by the Nicepay transaction ID of
+// their e-wallet details, or by reference number when the row has no
+// transaction ID or no payment has it, and saves their items.
+func (s *ReconciliationService) reconcileBatch(db *gorm.DB, r *reconciliation, rows []settlement.Row, items []models.ReconciliationItemsDataModel) error {
+	var transactionIDs []string
+	for _, row := range rows {
+		if row.TransactionID != "" {
+			transactionIDs = append(transactionIDs, row.TransactionID)
+		}
+	}
+	ewallets, err := s.ewallets.FindByNicepayTransactionIDs(db, transactionIDs)
+	if err != nil {
+		return err
+	}
+	byTransaction := map[string]*models.PaymentsDataModel{}
+	for _, ewallet := range ewallets {
+		if ewallet.NicepayTransactionID != nil && ewallet.Payment != nil {
+			byTransaction[*ewallet.NicepayTransactionID] = ewallet.Payment
+		}
+	}
+
+	var referenceNos []string
+	for _, row := range rows {
+		if byTransaction[row.TransactionID] == nil && row.ReferenceNo != "" {
+			referenceNos = append(referenceNos, row.ReferenceNo)
+		}
+	}
+	payments, err := s.payments.FindByReferenceNos(db, referenceNos)
+	if err != nil {
+		return err
+	}
+	byReference := map[string][]*models.PaymentsDataModel{}
+	for i := range payments {
+		if payment := &payments[i]; payment.ReferenceNo != nil {
+			byReference[*payment.ReferenceNo] = append(byReference[*payment.ReferenceNo], payment)
+		}
+	}
+
+	for _, row := range rows {
+		switch {
+		case row.TransactionID != "" && byTransaction[row.TransactionID] != nil:
+			items = append(items, s.reconcileRow(db, r, row, byTransaction[row.TransactionID]))
+		case row.TransactionID == "" && row.ReferenceNo == "":
+			items = append(items, r.item(row, nil, settlement.Invalid, nil, "row has no transaction ID or reference number"))
+		case len(byReference[row.ReferenceNo]) > 1:
+			items = append(items, r.item(row, nil, settlement.Invalid, nil, fmt.Sprintf("reference number matches %d payments", len(byReference[row.ReferenceNo]))))
+		case len(byReference[row.ReferenceNo]) == 1:
+			items = append(items, s.reconcileRow(db, r, row, byReference[row.ReferenceNo][0]))
+		default:
+			items = append(items, s.reconcileRow(db, r, row, nil))
+		}
+	}
+	return s.runs.InsertItems(db, items)
+}
+
+// reconcileRow compares a row with its payment. Rows without a status are
+// expected to be successful payments.
+func (s *ReconciliationService) reconcileRow(db *gorm.DB, r *reconciliation, row settlement.Row, payment *models.PaymentsDataModel) models.ReconciliationItemsDataModel {
+	if payment == nil {
+		item := r.item(row, nil, settlement.MissingPayment, nil, "no payment matches the row")
+		if row.Currency == "" {
+			return item
+		}
+		if unit, err := s.unit(db, r, row.Currency); err == nil {
+			if amount, err := money.Parse(row.Amount, unit); err == nil {
+				item.SettlementAmount = &amount.Minor
+			}
+		}
+		return item
+	}
+
+	if line, ok := r.settled[payment.ID]; ok {
+		return r.item(row, payment, settlement.Invalid, nil, fmt.Sprintf("payment is already settled on line %d", line))
+	}
+	r.settled[payment.ID] = row.Line
+
+	if payment.Currency == nil || payment.Amount == nil {
+		return r.item(row, payment, settlement.Invalid, nil, "payment has no amount or currency")
+	}
+	amount, err := money.Parse(row.Amount, currencyOf(payment.Currency))
+	if err != nil {
+		return r.item(row, payment, settlement.Invalid, nil, err.Error())
+	}
+
+	var mismatches []settlement.Mismatch
+	var details []string
+	if amount.Minor != *payment.Amount {
+		mismatches = append(mismatches, settlement.AmountMismatch)
+		details = append(details, fmt.Sprintf("settled %s, paid %s", amount, money.New(*payment.Amount, amount.Currency)))
+	}
+	if row.Currency != "" && row.Currency != payment.Currency.Code {
+		mismatches = append(mismatches, settlement.CurrencyMismatch)
+		details = append(details, fmt.Sprintf("settled in %s, paid in %s", row.Currency, payment.Currency.Code))
+	}
+	expected := constant.PAYMENT_STATUS_SUCCESS
+	if row.Status != "" {
+		expected = nicepayStatus(row.Status)
+	}
+	if payment.Status == nil || expected != *payment.Status {
+		mismatches = append(mismatches, settlement.StatusMismatch)
+		details = append(details, fmt.Sprintf("settlement status %q, payment status %q", row.Status, value(payment.Status)))
+	}
+
+	result := settlement.Matched
+	if len(mismatches) > 0 {
+		result = settlement.Mismatched
+	}
+	item := r.item(row, payment, result, mismatches, strings.Join(details, "; "))
+	item.SettlementAmount = &amount.Minor
+	return item
+}
+
+// reconcileMissing reports the successful payments of the run period that
+// the file does not list.
+func (s *ReconciliationService) reconcileMissing(db *gorm.DB, r *reconciliation) error {
+	after := uuid.Nil
+	for {
+		payments, err := s.payments.FindSucceededBetween(db, *r.run.PeriodFrom, *r.run.PeriodTo, after, reconciliationBatch)
+		if err != nil {
+			return err
+		}
+		if len(payments) == 0 {
+			return nil
+		}
+		var items []models.ReconciliationItemsDataModel
+		for i := range payments {
+			if _, ok := r.settled[payments[i].ID]; !ok {
+				items = append(items, r.item(settlement.Row{}, &payments[i], settlement.MissingSettlement, nil, "payment is not in the settlement file"))
+			}
+		}
+		if err := s.runs.InsertItems(db, items); err != nil {
+			return err
+		}
+		after = payments[len(payments)-1].ID
+	}
+}
+
+func (s *ReconciliationService) finish(db *gorm.DB, r *reconciliation, failure error) (*models.ReconciliationRunsDataModel, error) {
+	now := time.Now().UnixMilli()
+	r.run.Status, r.run.FinishedDate = constant.RECONCILIATION_STATUS_COMPLETED, &now
+	if failure != nil {
+		message := failure.Error()
+		r.run.Status, r.run.Error = constant.RECONCILIATION_STATUS_FAILED, &message
+	}
+	err := s.runs.Update(db, r.run.ID, map[string]interface{}{
+		"status":             r.run.Status,
+		"total_rows":         r.run.TotalRows,
+		"matched":            r.run.Matched,
+		"mismatched":         r.run.Mismatched,
+		"missing_payment":    r.run.MissingPayment,
+		"missing_settlement": r.run.MissingSettlement,
+		"invalid":            r.run.Invalid,
+		"error":              r.run.Error,
+		"finished_date":      now,
+	})
+	if failure != nil {
+		return &r.run, failure
+	}
+	return &r.run, err
+}
+
+// unit returns the currency of a settlement row by its code.
+func (s *ReconciliationService) unit(db *gorm.DB, r *reconciliation, code string) (money.Currency, error) {
+	if unit, ok := r.units[code]; ok {
+		return unit, nil
+	}
+	currency, err := s.currencies.FindByCode(db, code)
+	if err != nil {
+		return money.Currency{}, err
+	}
+	r.units[code] = currencyOf(currency)
+	return r.units[code], nil
+}
+
+// item records a reconciled row and counts it on the run.
+func (r *reconciliation) item(row settlement.Row, payment *models.PaymentsDataModel, result settlement.Result, mismatches []settlement.Mismatch, detail string) models.ReconciliationItemsDataModel {
+	switch result {
+	case settlement.Matched:
+		r.run.Matched++
+	case settlement.Mismatched:
+		r.run.Mismatched++
+	case settlement.MissingPayment:
+		r.run.MissingPayment++
+	case settlement.MissingSettlement:
+		r.run.MissingSettlement++
+	case settlement.Invalid:
+		r.run.Invalid++
+	}
+
+	item := models.ReconciliationItemsDataModel{
+		RunID:         r.run.ID,
+		TransactionID: optional(row.TransactionID),
+		ReferenceNo:   optional(row.ReferenceNo),
+		Currency:      optional(row.Currency),
+		Result:        string(result),
+		Detail:        optional(detail),
+	}
+	if row.Line > 0 {
+		item.LineNo = &row.Line
+	}
+	if row.Status != "" {
+		item.SettlementStatus = &row.Status
+	}
+	if len(mismatches) > 0 {
+		names := make([]string, len(mismatches))
+		for i, mismatch := range mismatches {
+			names[i] = string(mismatch)
+		}
+		item.Mismatches = optional(strings.Join(names, ","))
+	}
+	if payment != nil {
+		item.PaymentID = &payment.ID
+		item.PaymentAmount = payment.Amount
+		item.PaymentStatus = payment.Status
+		if item.TransactionID == nil {
+			item.TransactionID = payment.TransactionID
+		}
+		if item.ReferenceNo == nil {
+			item.ReferenceNo = payment.ReferenceNo
+		}
+		if payment.Currency != nil {
+			item.Currency = &payment.Currency.Code
+		}
+	}
+	return item
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
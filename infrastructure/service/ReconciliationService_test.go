@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/settlement"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// settlementStore keeps the payments and e-wallet details a reconciliation
+// reads, and the runs and items it writes.
+type settlementStore struct {
+	payments []models.PaymentsDataModel
+	ewallets []models.PaymentNicepayEWalletsDataModel
+	currency models.CurrenciesDataModel
+	run      *models.ReconciliationRunsDataModel
+	items    []models.ReconciliationItemsDataModel
+}
+
+func (s *settlementStore) FindByReferenceNos(tx *gorm.DB, referenceNos []string) ([]models.PaymentsDataModel, error) {
+	var found []models.PaymentsDataModel
+	for _, payment := range s.payments {
+		for _, referenceNo := range referenceNos {
+			if *payment.ReferenceNo == referenceNo {
+				found = append(found, payment)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *settlementStore) FindSucceededBetween(tx *gorm.DB, from int64, to int64, afterID uuid.UUID, limit int) ([]models.PaymentsDataModel, error) {
+	var found []models.PaymentsDataModel
+	for _, payment := range s.payments {
+		if *payment.Status == constant.PAYMENT_STATUS_SUCCESS && *payment.CreatedDate >= from && *payment.CreatedDate < to &&
+			payment.ID.String() > afterID.String() {
+			found = append(found, payment)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID.String() < found[j].ID.String() })
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found, nil
+}
+
+func (s *settlementStore) FindByNicepayTransactionIDs(tx *gorm.DB, nicepayTransactionIDs []string) ([]models.PaymentNicepayEWalletsDataModel, error) {
+	var found []models.PaymentNicepayEWalletsDataModel
+	for _, ewallet := range s.ewallets {
+		for _, id := range nicepayTransactionIDs {
+			if *ewallet.NicepayTransactionID == id {
+				found = append(found, ewallet)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (s *settlementStore) FindByCode(tx *gorm.DB, code string, opts ...repositories.QueryOption) (*models.CurrenciesDataModel, error) {
+	if code != s.currency.Code {
+		return nil, apperror.NotFound("currency", code)
+	}
+	return &s.currency, nil
+}
+
+func (s *settlementStore) Insert(tx *gorm.DB, run *models.ReconciliationRunsDataModel) error {
+	run.ID = uuid.New()
+	s.run = run
+	return nil
+}
+
+func (s *settlementStore) Update(tx *gorm.DB, id uuid.UUID, values map[string]interface{}) error {
+	return nil
+}
+
+func (s *settlementStore) InsertItems(tx *gorm.DB, items []models.ReconciliationItemsDataModel) error {
+	s.items = append(s.items, items...)
+	return nil
+}
+
+// addPayment stores a successful payment created at createdAt, and its
+// e-wallet details under nicepayTransactionID when that is set.
+func (s *settlementStore) addPayment(referenceNo string, amount int64, nicepayTransactionID string, createdAt time.Time) {
+	status, transactionID, created := constant.PAYMENT_STATUS_SUCCESS, uuid.NewString(), createdAt.UnixMilli()
+	payment := models.PaymentsDataModel{
+		ID:            uuid.New(),
+		TransactionID: &transactionID,
+		ReferenceNo:   &referenceNo,
+		Amount:        &amount,
+		Status:        &status,
+		CurrencyID:    &s.currency.ID,
+		Currency:      &s.currency,
+		CreatedDate:   &created,
+	}
+	s.payments = append(s.payments, payment)
+	if nicepayTransactionID != "" {
+		s.ewallets = append(s.ewallets, models.PaymentNicepayEWalletsDataModel{
+			ID:                   uuid.New(),
+			PaymentID:            &payment.ID,
+			NicepayTransactionID: &nicepayTransactionID,
+			Payment:              &payment,
+		})
+	}
+}
+
+// offlineDB is a gorm handle that never connects; the fakes ignore it.
+func offlineDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("open gorm: %v", err)
+	}
+	return db
+}
+
+func TestReconciliationImport(t *testing.T) {
+	periodFrom := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	periodTo := periodFrom.AddDate(0, 0, 1)
+	paidAt := periodFrom.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		payments func(s *settlementStore)
+		file     string
+		// results lists the result of each item: the file rows in order, then
+		// the payments missing from the file
+		results []settlement.Result
+		details []string
+	}{
+		{
+			name:     "matched on the Nicepay transaction ID",
+			payments: func(s *settlementStore) { s.addPayment("INV-1", 150000, "NP-1", paidAt) },
+			file:     "transaction_id,reference_no,amount,currency\nNP-1,INV-1,150000,IDR\n",
+			results:  []settlement.Result{settlement.Matched},
+		},
+		{
+			name: "Nicepay transaction ID before reference number",
+			payments: func(s *settlementStore) {
+				s.addPayment("INV-1", 150000, "NP-1", paidAt)
+				s.addPayment("INV-1", 99000, "NP-2", paidAt)
+			},
+			file:    "transaction_id,reference_no,amount,currency\nNP-2,INV-1,99000,IDR\nNP-1,INV-1,150000,IDR\n",
+			results: []settlement.Result{settlement.Matched, settlement.Matched},
+		},
+		{
+			name:     "unknown transaction ID falls back to the reference number",
+			payments: func(s *settlementStore) { s.addPayment("INV-1", 150000, "", paidAt) },
+			file:     "transaction_id,reference_no,amount,currency\nNP-9,INV-1,150000,IDR\n",
+			results:  []settlement.Result{settlement.Matched},
+		},
+		{
+			name:     "matched on the reference number alone",
+			payments: func(s *settlementStore) { s.addPayment("INV-1", 150000, "NP-1", paidAt) },
+			file:     "transaction_id,reference_no,amount,currency\n,INV-1,150000,IDR\n",
+			results:  []settlement.Result{settlement.Matched},
+		},
+		{
+			name:     "amount mismatch",
+			payments: func(s *settlementStore) { s.addPayment("INV-1", 150000, "NP-1", paidAt) },
+			file:     "transaction_id,reference_no,amount,currency\nNP-1,INV-1,149000,IDR\n",
+			results:  []settlement.Result{settlement.Mismatched},
+			details:  []string{"settled 149000, paid 150000"},
+		},
+		{
+			name:     "missing on our side",
+			payments: func(s *settlementStore) {},
+			file:     "transaction_id,reference_no,amount,currency\nNP-1,INV-1,150000,IDR\n",
+			results:  []settlement.Result{settlement.MissingPayment},
+		},
+		{
+			name: "missing in the file",
+			payments: func(s *settlementStore) {
+				s.addPayment("INV-1", 150000, "NP-1", paidAt)
+				s.addPayment("INV-2", 99000, "NP-2", paidAt)
+				s.addPayment("INV-3", 99000, "NP-3", periodTo)
+			},
+			file:    "transaction_id,reference_no,amount,currency\nNP-1,INV-1,150000,IDR\n",
+			results: []settlement.Result{settlement.Matched, settlement.MissingSettlement},
+		},
+		{
+			name: "reference number of two payments",
+			payments: func(s *settlementStore) {
+				s.addPayment("INV-1", 150000, "", paidAt)
+				s.addPayment("INV-1", 99000, "", paidAt)
+			},
+			file:    "transaction_id,reference_no,amount,currency\n,INV-1,150000,IDR\n",
+			results: []settlement.Result{settlement.Invalid, settlement.MissingSettlement, settlement.MissingSettlement},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &settlementStore{currency: models.CurrenciesDataModel{ID: uuid.New(), Code: "IDR", MinorUnit: 0}}
+			tt.payments(store)
+			svc := NewReconciliationService(offlineDB(t), store, store, store, store)
+
+			run, err := svc.Import(context.Background(), SettlementImport{
+				FileName:   "settlement.csv",
+				File:       strings.NewReader(tt.file),
+				Mapping:    settlement.DefaultMapping(),
+				PeriodFrom: &periodFrom,
+				PeriodTo:   &periodTo,
+			})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if run.Status != constant.RECONCILIATION_STATUS_COMPLETED {
+				t.Errorf("run status = %s, want %s", run.Status, constant.RECONCILIATION_STATUS_COMPLETED)
+			}
+			if len(store.items) != len(tt.results) {
+				t.Fatalf("%d items, want %d: %+v", len(store.items), len(tt.results), store.items)
+			}
+			for i, item := range store.items {
+				if item.Result != string(tt.results[i]) {
+					t.Errorf("item %d result = %s (%s), want %s", i, item.Result, value(item.Detail), tt.results[i])
+				}
+				if i < len(tt.details) && value(item.Detail) != tt.details[i] {
+					t.Errorf("item %d detail = %q, want %q", i, value(item.Detail), tt.details[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/domain/statement"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// StatementRequest asks for the statement of a merchant for [From, To).
+type StatementRequest struct {
+	MerchantID uuid.UUID
+	From       time.Time
+	To         time.Time
+	Format     statement.Format
+}
+
+func (r StatementRequest) Validate() error {
+	if !r.From.Before(r.To) {
+		return apperror.InvalidRequest(errors.New("from must come before to"))
+	}
+	return nil
+}
+
+type (
+	statementMerchants interface {
+		FindByID(tx *gorm.DB, id uuid.UUID, opts ...repositories.QueryOption) (*models.MerchantsDataModel, error)
+	}
+	statementLedger interface {
+		Balances(tx *gorm.DB, merchantID *uuid.UUID, asOf int64) ([]ledger.Balance, error)
+		EachStatementEntry(tx *gorm.DB, merchantID uuid.UUID, from int64, to int64, fn func(entry repositories.StatementEntry) error) error
+	}
+)
+
+type StatementService struct {
+	db         *gorm.DB
+	merchants  statementMerchants
+	currencies currenciesByCode
+	ledger     statementLedger
+}
+
+func NewStatementService(db *gorm.DB, merchants statementMerchants, currencies currenciesByCode, ledger statementLedger) *StatementService {
+	return &StatementService{db: db, merchants: merchants, currencies: currencies, ledger: ledger}
+}
+
+// Generate writes the statement of the merchant to w: the payments, fees and
+// refunds journaled in the period, then the opening balance, totals and net
+// settlement per currency. Lines are streamed from the ledger as they are
+// written. Payments are journaled when Resolve sees them paid, so a payment
+// is listed from then on.
+func (s *StatementService) Generate(ctx context.Context, req StatementRequest, w io.Writer) (*statement.Summary, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	db := s.db.WithContext(ctx)
+	merchant, err := s.merchants.FindByID(db, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+	summary := statement.NewSummary(merchant.Name+" ("+merchant.Code+")", req.From, req.To)
+
+	units := map[string]money.Currency{}
+	unit := func(code string) (money.Currency, error) {
+		if u, ok := units[code]; ok {
+			return u, nil
+		}
+		currency, err := s.currencies.FindByCode(db, code)
+		if err != nil {
+			return money.Currency{}, err
+		}
+		units[code] = currencyOf(currency)
+		return units[code], nil
+	}
+
+	balances, err := s.ledger.Balances(db, &req.MerchantID, req.From.UnixMilli()-1)
+	if err != nil {
+		return nil, err
+	}
+	for _, balance := range balances {
+		if balance.Account.Type != ledger.MerchantPayable {
+			continue
+		}
+		u, err := unit(balance.Account.Currency)
+		if err != nil {
+			return nil, err
+		}
+		summary.Open(u, balance.Balance)
+	}
+
+	out, err := statement.NewWriter(req.Format, w)
+	if err != nil {
+		return nil, apperror.InvalidRequest(err)
+	}
+	err = s.ledger.EachStatementEntry(db, req.MerchantID, req.From.UnixMilli(), req.To.UnixMilli(), func(entry repositories.StatementEntry) error {
+		u, err := unit(entry.Currency)
+		if err != nil {
+			return err
+		}
+		line := statement.Line{
+			PostedAt:      time.UnixMilli(entry.PostedDate),
+			EntryType:     ledger.EntryType(entry.EntryType),
+			TransactionID: value(entry.TransactionID),
+			ReferenceNo:   value(entry.ReferenceNo),
+			Channel:       value(entry.Channel),
+			Currency:      u,
+			Credit:        entry.Credit,
+			Debit:         entry.Debit,
+			Fee:           entry.Fee,
+			Tax:           entry.Tax,
+		}
+		summary.Add(line)
+		return out.Write(line)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, out.Close(summary)
+}
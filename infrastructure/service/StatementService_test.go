@@ -0,0 +1,128 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/domain/statement"
+	"worker-nicepay/infrastructure/database/memory"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// journalStatements reads statements from the journal of a memory store, as
+// the ledger repository reads them from Yugabyte.
+type journalStatements struct{ store *memory.Store }
+
+func (j journalStatements) FindByID(tx *gorm.DB, id uuid.UUID, opts ...repositories.QueryOption) (*models.MerchantsDataModel, error) {
+	for _, merchant := range j.store.Merchants {
+		if merchant.ID == id {
+			return &merchant, nil
+		}
+	}
+	return nil, apperror.NotFound("merchant", id.String())
+}
+
+func (j journalStatements) FindByCode(tx *gorm.DB, code string, opts ...repositories.QueryOption) (*models.CurrenciesDataModel, error) {
+	for _, currency := range j.store.Currencies {
+		if currency.Code == code {
+			return &currency, nil
+		}
+	}
+	return nil, apperror.NotFound("currency", code)
+}
+
+func (j journalStatements) Balances(tx *gorm.DB, merchantID *uuid.UUID, asOf int64) ([]ledger.Balance, error) {
+	return nil, nil
+}
+
+func (j journalStatements) EachStatementEntry(tx *gorm.DB, merchantID uuid.UUID, from int64, to int64, fn func(entry repositories.StatementEntry) error) error {
+	for _, entry := range j.store.Journal {
+		if posted := entry.PostedAt.UnixMilli(); posted < from || posted >= to {
+			continue
+		}
+		row := repositories.StatementEntry{EntryType: string(entry.Type), PostedDate: entry.PostedAt.UnixMilli(), Currency: entry.Currency}
+		merchants := false
+		for _, line := range entry.Lines {
+			switch {
+			case line.Account.MerchantID != nil && *line.Account.MerchantID == merchantID:
+				merchants = true
+				if line.Direction == ledger.Credit {
+					row.Credit += line.Amount
+				} else {
+					row.Debit += line.Amount
+				}
+			case line.Account.Type == ledger.FeeRevenue:
+				row.Fee += line.Amount
+			case line.Account.Type == ledger.TaxPayable:
+				row.Tax += line.Amount
+			}
+		}
+		if !merchants {
+			continue
+		}
+		payment := j.store.Payments[entry.PaymentID]
+		row.TransactionID, row.ReferenceNo = payment.TransactionID, payment.ReferenceNo
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A payment paid after it was created is journaled when Resolve sees it paid,
+// and from then on is on the merchant's statement.
+func TestStatementListsPaymentPaidWhilePending(t *testing.T) {
+	f := newFixture()
+	percentage := "2.5"
+	f.store.FeeSchedules = []models.FeeSchedulesDataModel{{
+		ID:              uuid.New(),
+		MerchantID:      f.merchant.ID,
+		PaymentMethodID: f.method.ID,
+		CurrencyID:      f.currency.ID,
+		FeeType:         "PERCENTAGE",
+		Percentage:      &percentage,
+	}}
+	if _, _, err := f.service().Execute(context.Background(), f.request("200000"), incoming()); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	f.checkedAgo(10 * time.Minute)
+	f.gateway.status = "PAID"
+	txSvc := NewNicePayTransactionService(f.store.UnitOfWork(), f.store.Repositories(), f.gateway, nil)
+	if _, err := txSvc.Resolve(context.Background(), 5*time.Minute, 10); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+
+	statements := journalStatements{f.store}
+	svc := NewStatementService(offlineDB(t), statements, statements, statements)
+	var out bytes.Buffer
+	summary, err := svc.Generate(context.Background(), StatementRequest{
+		MerchantID: f.merchant.ID,
+		From:       time.Now().Add(-time.Hour),
+		To:         time.Now().Add(time.Hour),
+		Format:     statement.FormatCSV,
+	}, &out)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	totals := summary.Totals()
+	if len(totals) != 1 {
+		t.Fatalf("statement has %d currencies, want 1", len(totals))
+	}
+	// 2.5% of 200000 is 5000
+	if total := totals[0]; total.Payments != 1 || total.Gross != 200000 || total.Fee != 5000 || total.Net != 195000 {
+		t.Errorf("totals = %+v, want 1 payment of 200000 with 5000 fee and 195000 net", total)
+	}
+	if transactionID := *f.onlyPayment(t).TransactionID; !strings.Contains(out.String(), transactionID) {
+		t.Errorf("statement does not list transaction %s:\n%s", transactionID, out.String())
+	}
+}
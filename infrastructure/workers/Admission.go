@@ -0,0 +1,118 @@
+package workers
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrQueueFull = errors.New("job queue is full")
+var ErrMerchantQuotaExceeded = errors.New("merchant in-flight job quota exceeded")
+var ErrLaneFull = errors.New("merchant job lane is full")
+
+// Admission enforces per-merchant in-flight quotas and records the queue
+// metrics exposed by MetricsHandler. A job is in flight from admission until
+// it finishes processing.
+type Admission struct {
+	mu             sync.Mutex
+	maxPerMerchant int
+	inFlight       map[string]int
+	admitted       uint64
+	rejectedFull   uint64
+	rejectedQuota  uint64
+	processed      uint64
+	totalWait      time.Duration
+	maxWait        time.Duration
+	lastWait       time.Duration
+}
+
+func NewAdmission(maxPerMerchant int) *Admission {
+	return &Admission{
+		maxPerMerchant: maxPerMerchant,
+		inFlight:       make(map[string]int),
+	}
+}
+
+// Acquire reserves an in-flight slot for the merchant. A limit of zero or less
+// disables the quota.
+func (a *Admission) Acquire(merchantID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.maxPerMerchant > 0 && a.inFlight[merchantID] >= a.maxPerMerchant {
+		a.rejectedQuota++
+		return ErrMerchantQuotaExceeded
+	}
+	a.inFlight[merchantID]++
+	a.admitted++
+	return nil
+}
+
+// Release frees a slot taken by Acquire.
+func (a *Admission) Release(merchantID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.inFlight[merchantID] <= 1 {
+		delete(a.inFlight, merchantID)
+		return
+	}
+	a.inFlight[merchantID]--
+}
+
+// Rejected undoes an Acquire for a job that could not be queued.
+func (a *Admission) Rejected(merchantID string) {
+	a.Release(merchantID)
+
+	a.mu.Lock()
+	a.admitted--
+	a.rejectedFull++
+	a.mu.Unlock()
+}
+
+// ObserveWait records how long a job sat in the queue before processing.
+func (a *Admission) ObserveWait(wait time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.processed++
+	a.totalWait += wait
+	a.lastWait = wait
+	if wait > a.maxWait {
+		a.maxWait = wait
+	}
+}
+
+type AdmissionMetrics struct {
+	InFlight      map[string]int `json:"in_flight"`
+	Admitted      uint64         `json:"admitted"`
+	RejectedFull  uint64         `json:"rejected_queue_full"`
+	RejectedQuota uint64         `json:"rejected_merchant_quota"`
+	Processed     uint64         `json:"processed"`
+	AvgWaitMs     float64        `json:"avg_wait_ms"`
+	MaxWaitMs     float64        `json:"max_wait_ms"`
+	LastWaitMs    float64        `json:"last_wait_ms"`
+}
+
+func (a *Admission) Metrics() AdmissionMetrics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	inFlight := make(map[string]int, len(a.inFlight))
+	for k, v := range a.inFlight {
+		inFlight[k] = v
+	}
+	m := AdmissionMetrics{
+		InFlight:      inFlight,
+		Admitted:      a.admitted,
+		RejectedFull:  a.rejectedFull,
+		RejectedQuota: a.rejectedQuota,
+		Processed:     a.processed,
+		MaxWaitMs:     float64(a.maxWait) / float64(time.Millisecond),
+		LastWaitMs:    float64(a.lastWait) / float64(time.Millisecond),
+	}
+	if a.processed > 0 {
+		m.AvgWaitMs = float64(a.totalWait) / float64(a.processed) / float64(time.Millisecond)
+	}
+	return m
+}
@@ -0,0 +1,212 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/entities"
+	"worker-nicepay/infrastructure/configuration"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const defaultBatchMaxItems = 500
+
+// Batch groups the jobs created by one bulk request. Items are fixed at
+// creation; their progress is read from the job results.
+type Batch struct {
+	ID         string      `json:"id"`
+	MerchantID string      `json:"merchant_id"`
+	Total      int         `json:"total"`
+	Accepted   int         `json:"accepted"`
+	Rejected   int         `json:"rejected"`
+	Items      []BatchItem `json:"items"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// BatchItem is one request of a batch. Rejected items have no job.
+type BatchItem struct {
+	Index       int    `json:"index"`
+	JobID       string `json:"job_id,omitempty"`
+	ReferenceNo string `json:"reference_no"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BatchItemResult is a BatchItem with the current state of its job.
+type BatchItemResult struct {
+	BatchItem
+	Status JobStatus  `json:"status"`
+	Result *JobResult `json:"result,omitempty"`
+}
+
+type BatchProgress struct {
+	ID         string            `json:"id"`
+	MerchantID string            `json:"merchant_id"`
+	Total      int               `json:"total"`
+	Counts     map[JobStatus]int `json:"counts"`
+	Completed  bool              `json:"completed"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Items      []BatchItemResult `json:"items"`
+}
+
+// StatusRejected marks batch items that failed validation and were never queued.
+const StatusRejected JobStatus = "rejected"
+
+// BatchEnqueueHandler validates every request of the array and queues the
+// valid ones as jobs of a new batch. Invalid items are reported per item and
+// do not fail the batch.
+func BatchEnqueueHandler(c *fiber.Ctx) error {
+	incoming, ok := c.Locals("incoming").(*entities.Incoming)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Incoming context missing"})
+	}
+
+	var reqs []dto.CreatePaymentRequest
+	if err := c.BodyParser(&reqs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request payload",
+		})
+	}
+
+	maxItems := configuration.AppConfig.WorkerBatchMaxItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	if len(reqs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Batch must contain at least one item",
+		})
+	}
+	if len(reqs) > maxItems {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Batch exceeds %d items", maxItems),
+		})
+	}
+
+	batch := &Batch{
+		ID:         "batch-" + uuid.NewString(),
+		MerchantID: reqs[0].MerchantID,
+		Total:      len(reqs),
+		Items:      make([]BatchItem, len(reqs)),
+		CreatedAt:  time.Now(),
+	}
+
+	jobs := make([]*Job, 0, len(reqs))
+	seen := make(map[string]int, len(reqs))
+	for i, req := range reqs {
+		item := BatchItem{Index: i, ReferenceNo: req.ReferenceNo}
+
+		err := req.Validate()
+		if err == nil && req.MerchantID != batch.MerchantID {
+			err = errors.New("all items of a batch must belong to the same merchant")
+		}
+		if first, dup := seen[req.ReferenceNo]; err == nil && dup {
+			err = fmt.Errorf("duplicate reference_no of item %d", first)
+		}
+		if err != nil {
+			item.Error = err.Error()
+			batch.Rejected++
+			batch.Items[i] = item
+			continue
+		}
+		seen[req.ReferenceNo] = i
+
+		// each payment needs a transaction ID of its own
+		itemIncoming := *incoming
+		itemIncoming.TransactionID = fmt.Sprintf("%s-%d", incoming.TransactionID, i)
+
+		item.JobID = fmt.Sprintf("%s-%d", batch.ID, i)
+		batch.Items[i] = item
+		batch.Accepted++
+		jobs = append(jobs, &Job{
+			ID:         item.JobID,
+			BatchID:    batch.ID,
+			MerchantID: req.MerchantID,
+			Priority:   c.QueryBool("priority"),
+			Request:    req,
+			Incoming:   itemIncoming,
+		})
+	}
+
+	if err := workerInstance.store.SaveBatch(c.Context(), batch); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	for _, job := range jobs {
+		workerInstance.setResult(job, JobResult{
+			Status:  StatusQueued,
+			Message: "Job queued",
+		})
+	}
+
+	// Feed the jobs in the background so a large batch waits for admission
+	// instead of being shed or holding the request open.
+	go func() {
+		for _, job := range jobs {
+			if err := workerInstance.EnqueueWait(context.Background(), job); err != nil {
+				log.Printf("Failed to enqueue job %s of batch %s: %v", job.ID, job.BatchID, err)
+				workerInstance.setResult(job, JobResult{
+					Status: StatusError,
+					Error:  err.Error(),
+				})
+			}
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(batch)
+}
+
+// BatchStatusHandler reports the progress and per-item results of a batch.
+func BatchStatusHandler(c *fiber.Ctx) error {
+	batch, err := workerInstance.store.GetBatch(c.Context(), c.Params("id"))
+	if errors.Is(err, ErrBatchNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Batch not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	progress := BatchProgress{
+		ID:         batch.ID,
+		MerchantID: batch.MerchantID,
+		Total:      batch.Total,
+		Counts:     make(map[JobStatus]int),
+		Completed:  true,
+		CreatedAt:  batch.CreatedAt,
+		Items:      make([]BatchItemResult, 0, len(batch.Items)),
+	}
+	for _, item := range batch.Items {
+		itemResult := BatchItemResult{BatchItem: item, Status: StatusRejected}
+		if item.JobID != "" {
+			result, err := workerInstance.store.Get(c.Context(), item.JobID)
+			switch {
+			case err == nil:
+				itemResult.Status = result.Status
+				itemResult.Result = result
+			case errors.Is(err, ErrJobNotFound):
+				itemResult.Status = StatusQueued
+			default:
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+		}
+		progress.Counts[itemResult.Status]++
+		if itemResult.Status != StatusRejected && !itemResult.Status.IsTerminal() {
+			progress.Completed = false
+		}
+		progress.Items = append(progress.Items, itemResult)
+	}
+
+	return c.JSON(progress)
+}
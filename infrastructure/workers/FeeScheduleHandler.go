@@ -0,0 +1,278 @@
+package workers
+
+import (
+	"encoding/json"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/fee"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// feeScheduleModel resolves the channel and currency of req and converts its
+// amounts to minor units.
+func feeScheduleModel(db *gorm.DB, merchantID uuid.UUID, req dto.FeeScheduleRequest) (models.FeeSchedulesDataModel, error) {
+	var row models.FeeSchedulesDataModel
+
+	method, err := dependencies.ProvidePaymentMethodsRepository().FindOne(db, models.PaymentMethodsDataModel{Name: req.ChannelCode})
+	if err != nil {
+		return row, err
+	}
+	currency, err := dependencies.ProvideCurrenciesRepository().FindByCode(db, req.Currency)
+	if err != nil {
+		return row, err
+	}
+	unit := money.Currency{Code: currency.Code, Exponent: currency.MinorUnit}
+	minor := func(amount json.Number) (*int64, error) {
+		if amount == "" {
+			return nil, nil
+		}
+		m, err := money.Parse(amount.String(), unit)
+		if err != nil {
+			return nil, apperror.InvalidRequest(err)
+		}
+		return &m.Minor, nil
+	}
+	percent := func(p json.Number) *string {
+		if p == "" {
+			return nil
+		}
+		s := p.String()
+		return &s
+	}
+
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+	row = models.FeeSchedulesDataModel{
+		MerchantID:      merchantID,
+		PaymentMethodID: method.ID,
+		CurrencyID:      currency.ID,
+		FeeType:         req.FeeType,
+		Percentage:      percent(req.Percentage),
+		TaxPercentage:   percent(req.TaxPercentage),
+		EffectiveFrom:   effectiveFrom.UnixMilli(),
+		PaymentMethod:   &method,
+		Currency:        currency,
+	}
+	if flat, err := minor(req.FlatAmount); err != nil {
+		return row, err
+	} else if flat != nil {
+		row.FlatAmount = *flat
+	}
+	if row.MinFee, err = minor(req.MinFee); err != nil {
+		return row, err
+	}
+	if row.MaxFee, err = minor(req.MaxFee); err != nil {
+		return row, err
+	}
+
+	if req.FeeType == string(fee.TypeTiered) {
+		tiers := make([]fee.Tier, 0, len(req.Tiers))
+		for _, t := range req.Tiers {
+			tier := fee.Tier{Percentage: t.Percentage.String()}
+			if tier.UpTo, err = minor(t.UpTo); err != nil {
+				return row, err
+			}
+			if flat, err := minor(t.FlatAmount); err != nil {
+				return row, err
+			} else if flat != nil {
+				tier.FlatAmount = *flat
+			}
+			tiers = append(tiers, tier)
+		}
+		if row.Tiers, err = json.Marshal(tiers); err != nil {
+			return row, err
+		}
+	}
+
+	schedule, err := row.Schedule()
+	if err == nil {
+		err = schedule.Validate()
+	}
+	if err != nil {
+		return row, apperror.InvalidRequest(err)
+	}
+	return row, nil
+}
+
+func feeScheduleResponse(row models.FeeSchedulesDataModel) dto.FeeScheduleResponse {
+	res := dto.FeeScheduleResponse{
+		ID:            row.ID.String(),
+		MerchantID:    row.MerchantID.String(),
+		FeeType:       row.FeeType,
+		Percentage:    row.Percentage,
+		TaxPercentage: row.TaxPercentage,
+		EffectiveFrom: time.UnixMilli(row.EffectiveFrom),
+		CreatedDate:   row.CreatedDate,
+		UpdatedDate:   row.UpdatedDate,
+		DeletedDate:   row.DeletedDate,
+		DataStatus:    row.DataStatus,
+	}
+	if row.PaymentMethod != nil {
+		res.ChannelCode = row.PaymentMethod.Name
+	}
+	var unit money.Currency
+	if row.Currency != nil {
+		res.Currency = row.Currency.Code
+		unit = money.Currency{Code: row.Currency.Code, Exponent: row.Currency.MinorUnit}
+	}
+	major := func(minor *int64) json.Number {
+		if minor == nil || *minor == 0 {
+			return ""
+		}
+		return json.Number(money.New(*minor, unit).String())
+	}
+	res.FlatAmount = major(&row.FlatAmount)
+	res.MinFee = major(row.MinFee)
+	res.MaxFee = major(row.MaxFee)
+	if schedule, err := row.Schedule(); err == nil {
+		for _, tier := range schedule.Tiers {
+			res.Tiers = append(res.Tiers, dto.FeeTierResponse{
+				UpTo:       major(tier.UpTo),
+				Percentage: tier.Percentage,
+				FlatAmount: major(&tier.FlatAmount),
+			})
+		}
+	}
+	return res
+}
+
+// feeScheduleOf returns the fee schedule of the :id path parameter, which
+// must belong to merchant.
+func feeScheduleOf(c *fiber.Ctx, db *gorm.DB, merchant *models.MerchantsDataModel) (*models.FeeSchedulesDataModel, error) {
+	id, err := masterDataID(c)
+	if err != nil {
+		return nil, err
+	}
+	row, err := dependencies.ProvideFeeSchedulesRepository().FindByID(db, id, repositories.IncludeInactive())
+	if err != nil {
+		return nil, err
+	}
+	if row.MerchantID != merchant.ID {
+		return nil, apperror.NotFound("fee schedule", id.String())
+	}
+	return row, nil
+}
+
+// ListFeeSchedulesHandler handles GET
+// /admin/merchants/:merchant_id/fee-schedules. Soft-deleted schedules are
+// listed with ?include_deleted=true.
+func ListFeeSchedulesHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	var opts []repositories.QueryOption
+	if c.QueryBool("include_deleted") {
+		opts = append(opts, repositories.IncludeDeleted())
+	}
+	rows, err := dependencies.ProvideFeeSchedulesRepository().FindByMerchant(db, merchant.ID, opts...)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	res := make([]dto.FeeScheduleResponse, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, feeScheduleResponse(row))
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Success", res, "")
+}
+
+// CreateFeeScheduleHandler handles POST
+// /admin/merchants/:merchant_id/fee-schedules. A new contract of a channel
+// is added with a later effective_from; payments keep the fees of the
+// schedule in force when they were created or paid.
+func CreateFeeScheduleHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	req, err := parseMasterDataRequest[dto.FeeScheduleRequest](c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := feeScheduleModel(db, merchant.ID, req)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	if err := dependencies.ProvideFeeSchedulesRepository().Insert(db, &row); err != nil {
+		return masterDataError(c, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusCreated, "Created", feeScheduleResponse(row), "")
+}
+
+// UpdateFeeScheduleHandler handles PUT
+// /admin/merchants/:merchant_id/fee-schedules/:id and replaces the whole
+// schedule; omitted fields are cleared.
+func UpdateFeeScheduleHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	existing, err := feeScheduleOf(c, db, merchant)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	req, err := parseMasterDataRequest[dto.FeeScheduleRequest](c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := feeScheduleModel(db, merchant.ID, req)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+
+	repo := dependencies.ProvideFeeSchedulesRepository()
+	err = repo.Update(db, existing.ID, map[string]interface{}{
+		"payment_method_id": row.PaymentMethodID,
+		"currency_id":       row.CurrencyID,
+		"fee_type":          row.FeeType,
+		"percentage":        row.Percentage,
+		"flat_amount":       row.FlatAmount,
+		"tiers":             row.Tiers,
+		"min_fee":           row.MinFee,
+		"max_fee":           row.MaxFee,
+		"tax_percentage":    row.TaxPercentage,
+		"effective_from":    row.EffectiveFrom,
+	})
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	updated, err := repo.FindByID(db, existing.ID, repositories.IncludeInactive())
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	updated.PaymentMethod, updated.Currency = row.PaymentMethod, row.Currency
+	return common.SuccessResponse(c, fiber.StatusOK, "Updated", feeScheduleResponse(*updated), "")
+}
+
+// DeleteFeeScheduleHandler handles DELETE
+// /admin/merchants/:merchant_id/fee-schedules/:id
+func DeleteFeeScheduleHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	existing, err := feeScheduleOf(c, db, merchant)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	if err := dependencies.ProvideFeeSchedulesRepository().SoftDelete(db, existing.ID); err != nil {
+		return masterDataError(c, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Deleted", nil, "")
+}
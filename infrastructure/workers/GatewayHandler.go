@@ -0,0 +1,19 @@
+package workers
+
+import (
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GatewayStatusHandler handles GET /admin/gateway/breakers
+func GatewayStatusHandler(c *fiber.Ctx) error {
+	return c.JSON(dependencies.ProvideResilientNicepayGateway().Status())
+}
+
+// ResetGatewayBreakerHandler handles POST /admin/gateway/breakers/:channel/reset
+func ResetGatewayBreakerHandler(c *fiber.Ctx) error {
+	gateway := dependencies.ProvideResilientNicepayGateway()
+	gateway.ResetBreaker(c.Params("channel"))
+	return c.JSON(gateway.Status())
+}
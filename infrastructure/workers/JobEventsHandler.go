@@ -0,0 +1,93 @@
+package workers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const jobEventsKeepAlive = 15 * time.Second
+
+// JobEventsHandler streams job status transitions as Server-Sent Events and
+// closes the stream once the job reaches a terminal state.
+func JobEventsHandler(c *fiber.Ctx) error {
+	jobID := c.Params("id")
+
+	// Subscribe before reading the current state so no transition in between is lost.
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := workerInstance.store.Subscribe(ctx, jobID)
+	if err != nil {
+		cancel()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	current, err := workerInstance.store.Get(c.Context(), jobID)
+	if err != nil {
+		cancel()
+		if errors.Is(err, ErrJobNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		last := current.Status
+		if err := writeJobEvent(w, current); err != nil || last.IsTerminal() {
+			return
+		}
+
+		keepAlive := time.NewTicker(jobEventsKeepAlive)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case result, ok := <-updates:
+				if !ok {
+					return
+				}
+				if result.Status == last {
+					continue
+				}
+				last = result.Status
+				if err := writeJobEvent(w, result); err != nil || last.IsTerminal() {
+					return
+				}
+			case <-keepAlive.C:
+				// A failed flush means the client went away.
+				fmt.Fprint(w, ": keep-alive\n\n")
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+func writeJobEvent(w *bufio.Writer, result *JobResult) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", result.UpdatedAt.UnixNano(), result.Status, data)
+	return w.Flush()
+}
@@ -0,0 +1,62 @@
+package workers
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrJobNotFound = errors.New("job not found")
+var ErrBatchNotFound = errors.New("batch not found")
+
+// JobStore keeps job results and broadcasts every change, so status reads and
+// event streams work no matter which replica processed the job.
+type JobStore interface {
+	Save(ctx context.Context, result *JobResult) error
+	Get(ctx context.Context, id string) (*JobResult, error)
+	// Update reads the result of the job, lets fn change it and saves it
+	// unless fn returns false, failing over to a fresh read if the result
+	// changed in between; fn may therefore run more than once. It returns the
+	// result as fn left it, or ErrJobNotFound.
+	Update(ctx context.Context, id string, fn func(result *JobResult) bool) (*JobResult, error)
+	// Subscribe delivers the results saved for the job until ctx is done or
+	// the job reaches a terminal status. A slow subscriber may miss
+	// intermediate results but always gets the terminal one. The channel is
+	// closed when the subscription ends.
+	Subscribe(ctx context.Context, id string) (<-chan *JobResult, error)
+
+	// List returns one page of job results, newest first, and the total
+	// number of results matching the filter.
+	List(ctx context.Context, filter JobFilter) ([]*JobResult, int, error)
+
+	SaveBatch(ctx context.Context, batch *Batch) error
+	GetBatch(ctx context.Context, id string) (*Batch, error)
+}
+
+type JobFilter struct {
+	MerchantID string
+	Status     JobStatus
+	Offset     int
+	Limit      int
+}
+
+func (f JobFilter) Match(result *JobResult) bool {
+	if f.MerchantID != "" && result.MerchantID != f.MerchantID {
+		return false
+	}
+	if f.Status != "" && result.Status != f.Status {
+		return false
+	}
+	return true
+}
+
+// page cuts the filtered results down to the requested window.
+func (f JobFilter) page(results []*JobResult) []*JobResult {
+	if f.Offset >= len(results) {
+		return []*JobResult{}
+	}
+	end := len(results)
+	if f.Limit > 0 && f.Offset+f.Limit < end {
+		end = f.Offset + f.Limit
+	}
+	return results[f.Offset:end]
+}
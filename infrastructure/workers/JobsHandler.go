@@ -0,0 +1,135 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"worker-nicepay/infrastructure/common"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultJobsPageLimit = 20
+	maxJobsPageLimit     = 100
+)
+
+var ErrJobNotCancellable = errors.New("job already finished")
+
+// Cancel cancels a merchant's scheduled or queued job, or asks the replica
+// processing it to abort. Jobs of other merchants are not found. The result
+// is changed with a conditional update, so a worker that starts or finishes
+// the job at the same time is never overwritten.
+func (w *Worker) Cancel(ctx context.Context, merchantID string, jobID string) (*JobResult, error) {
+	scheduled, err := w.cancelScheduled(ctx, merchantID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	finished, foreign := false, false
+	current, err := w.store.Update(ctx, jobID, func(result *JobResult) bool {
+		if foreign = result.MerchantID != merchantID; foreign {
+			return false
+		}
+		finished = !scheduled && result.Status.IsTerminal()
+		if finished {
+			return false
+		}
+		if scheduled || result.Status == StatusQueued {
+			result.Status = StatusCancelled
+			result.Message = "Job cancelled"
+		} else {
+			result.CancelRequested = true
+			result.Message = "Job cancellation requested"
+		}
+		result.UpdatedAt = time.Now()
+		return true
+	})
+	if scheduled && errors.Is(err, ErrJobNotFound) {
+		// the result expired while the job was waiting for its run_at
+		current = &JobResult{ID: jobID, Status: StatusCancelled, Message: "Job cancelled", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		return current, w.store.Save(ctx, current)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if foreign {
+		return nil, ErrJobNotFound
+	}
+	if finished {
+		return current, ErrJobNotCancellable
+	}
+	return current, nil
+}
+
+// CancelJobHandler handles DELETE /jobs/:id?merchant_id=
+func CancelJobHandler(c *fiber.Ctx) error {
+	merchantID := c.Query("merchant_id")
+	if merchantID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant_id is required",
+		})
+	}
+	// jobs of other merchants are not found rather than forbidden, so their IDs cannot be probed
+	result, err := workerInstance.Cancel(c.Context(), merchantID, c.Params("id"))
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Job not found",
+		})
+	case errors.Is(err, ErrJobNotCancellable):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  err.Error(),
+			"status": result.Status,
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// ListJobsHandler handles GET /jobs?merchant_id=&status=&page=&limit= and
+// lists the jobs of one merchant.
+func ListJobsHandler(c *fiber.Ctx) error {
+	merchantID := c.Query("merchant_id")
+	if merchantID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant_id is required",
+		})
+	}
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultJobsPageLimit)))
+	if limit < 1 {
+		limit = defaultJobsPageLimit
+	}
+	if limit > maxJobsPageLimit {
+		limit = maxJobsPageLimit
+	}
+
+	results, total, err := workerInstance.store.List(c.Context(), JobFilter{
+		MerchantID: merchantID,
+		Status:     JobStatus(c.Query("status")),
+		Offset:     (page - 1) * limit,
+		Limit:      limit,
+	})
+	if err != nil {
+		return common.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to list jobs", err, nil, "")
+	}
+
+	resp := common.BuildSuccessResponse("Success", fiber.StatusOK, results, "")
+	resp.Meta = &common.MetaData{
+		Page:      page,
+		TotalPage: (total + limit - 1) / limit,
+		TotalRows: total,
+		Limit:     limit,
+	}
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
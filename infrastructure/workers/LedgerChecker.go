@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"log"
+	"time"
+
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/dependencies"
+)
+
+// InitializeLedgerChecker periodically verifies that the ledger debits equal
+// its credits and logs the entries that do not. It is disabled without a
+// LEDGER_CHECK_INTERVAL.
+func InitializeLedgerChecker() {
+	interval := time.Duration(configuration.AppConfig.LedgerCheckInterval) * time.Millisecond
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			report, err := dependencies.ProvideLedgerRepository().Check(dependencies.ProvideYugabyteClient().GetDB())
+			if err != nil {
+				log.Printf("Failed to check ledger: %v", err)
+				continue
+			}
+			if report.Balanced {
+				continue
+			}
+			for _, entry := range report.Unbalanced {
+				log.Printf("Ledger entry %s (%s) is unbalanced: debit %d, credit %d", entry.EntryID, entry.Key, entry.Debit, entry.Credit)
+			}
+			for _, total := range report.Totals {
+				if total.Debit != total.Credit {
+					log.Printf("Ledger is unbalanced in %s: debit %d, credit %d", total.Currency, total.Debit, total.Credit)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package workers
+
+import (
+	"encoding/json"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/ledger"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// asOfOf returns the ?as_of=RFC3339 query parameter, or now.
+func asOfOf(c *fiber.Ctx) (time.Time, error) {
+	asOf := c.Query("as_of")
+	if asOf == "" {
+		return time.Now(), nil
+	}
+	t, err := time.Parse(time.RFC3339, asOf)
+	if err != nil {
+		return t, apperror.InvalidRequest(err)
+	}
+	return t, nil
+}
+
+// ledgerBalances lists the balances of the merchant's accounts, or of the
+// whole ledger for a nil merchantID, in major units.
+func ledgerBalances(c *fiber.Ctx, db *gorm.DB, merchantID *uuid.UUID) error {
+	asOf, err := asOfOf(c)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	balances, err := dependencies.ProvideLedgerRepository().Balances(db, merchantID, asOf.UnixMilli())
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+
+	units := map[string]money.Currency{}
+	res := make([]dto.LedgerBalanceResponse, 0, len(balances))
+	for _, balance := range balances {
+		unit, err := currencyUnits(db, units, balance.Account.Currency)
+		if err != nil {
+			return common.AppErrorResponse(c, err, nil, "")
+		}
+		res = append(res, ledgerBalanceResponse(balance, unit))
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Success", res, "")
+}
+
+func ledgerBalanceResponse(balance ledger.Balance, unit money.Currency) dto.LedgerBalanceResponse {
+	major := func(minor int64) json.Number {
+		return json.Number(money.New(minor, unit).String())
+	}
+	res := dto.LedgerBalanceResponse{
+		Account:     balance.Account.Code(),
+		AccountType: string(balance.Account.Type),
+		Currency:    balance.Account.Currency,
+		Debit:       major(balance.Debit),
+		Credit:      major(balance.Credit),
+		Balance:     major(balance.Balance),
+	}
+	if balance.Account.MerchantID != nil {
+		id := balance.Account.MerchantID.String()
+		res.MerchantID = &id
+	}
+	return res
+}
+
+// MerchantBalancesHandler handles GET /admin/merchants/:merchant_id/balances.
+// The payable balance is what the gateway owes the merchant; ?as_of=RFC3339
+// only counts entries posted until then.
+func MerchantBalancesHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	return ledgerBalances(c, db, &merchant.ID)
+}
+
+// LedgerBalancesHandler handles GET /admin/ledger/balances, the balances of
+// every ledger account.
+func LedgerBalancesHandler(c *fiber.Ctx) error {
+	return ledgerBalances(c, dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()), nil)
+}
+
+// LedgerCheckHandler handles GET /admin/ledger/check and reports journal
+// entries whose debits differ from their credits.
+func LedgerCheckHandler(c *fiber.Ctx) error {
+	report, err := dependencies.ProvideLedgerRepository().Check(dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()))
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	message := "Balanced"
+	if !report.Balanced {
+		message = "Unbalanced"
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, message, report, "")
+}
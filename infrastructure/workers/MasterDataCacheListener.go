@@ -0,0 +1,24 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"worker-nicepay/infrastructure/dependencies"
+)
+
+const masterDataCacheResubscribe = 5 * time.Second
+
+// InitializeMasterDataCacheListener applies master data invalidations
+// published by other replicas to the local cache.
+func InitializeMasterDataCacheListener() {
+	go func() {
+		for {
+			if err := dependencies.ProvideMasterDataCache().Listen(context.Background()); err != nil {
+				log.Printf("Master data cache invalidation listener stopped: %v", err)
+			}
+			time.Sleep(masterDataCacheResubscribe)
+		}
+	}()
+}
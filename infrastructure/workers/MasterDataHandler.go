@@ -0,0 +1,245 @@
+package workers
+
+import (
+	"errors"
+	"log"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type masterDataRepository[M any] interface {
+	FindAll(tx *gorm.DB, opts ...repositories.QueryOption) ([]M, error)
+	FindByID(tx *gorm.DB, id uuid.UUID, opts ...repositories.QueryOption) (*M, error)
+	Insert(tx *gorm.DB, model *M) error
+	Update(tx *gorm.DB, id uuid.UUID, values map[string]interface{}) error
+	SoftDelete(tx *gorm.DB, id uuid.UUID) error
+}
+
+type masterDataKind interface {
+	list(db *gorm.DB, opts ...repositories.QueryOption) (interface{}, error)
+	create(db *gorm.DB, c *fiber.Ctx) (interface{}, error)
+	update(db *gorm.DB, c *fiber.Ctx, id uuid.UUID) (interface{}, error)
+	remove(db *gorm.DB, id uuid.UUID) error
+}
+
+// masterData serves one master data table. R is the request body, newModel
+// builds a row from it and values the columns an update overwrites. Audit
+// columns are filled by the database callbacks.
+type masterData[M any, R interface{ Validate() error }] struct {
+	repo     func() masterDataRepository[M]
+	newModel func(req R) M
+	values   func(req R) map[string]interface{}
+}
+
+func (k masterData[M, R]) list(db *gorm.DB, opts ...repositories.QueryOption) (interface{}, error) {
+	return k.repo().FindAll(db, opts...)
+}
+
+func (k masterData[M, R]) create(db *gorm.DB, c *fiber.Ctx) (interface{}, error) {
+	req, err := parseMasterDataRequest[R](c)
+	if err != nil {
+		return nil, err
+	}
+	model := k.newModel(req)
+	if err := k.repo().Insert(db, &model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (k masterData[M, R]) update(db *gorm.DB, c *fiber.Ctx, id uuid.UUID) (interface{}, error) {
+	req, err := parseMasterDataRequest[R](c)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.repo().Update(db, id, k.values(req)); err != nil {
+		return nil, err
+	}
+	return k.repo().FindByID(db, id, repositories.IncludeInactive())
+}
+
+func (k masterData[M, R]) remove(db *gorm.DB, id uuid.UUID) error {
+	return k.repo().SoftDelete(db, id)
+}
+
+func parseMasterDataRequest[R interface{ Validate() error }](c *fiber.Ctx) (R, error) {
+	var req R
+	if err := c.BodyParser(&req); err != nil {
+		return req, apperror.InvalidRequest(err)
+	}
+	if err := req.Validate(); err != nil {
+		return req, apperror.InvalidRequest(err)
+	}
+	return req, nil
+}
+
+var masterDataKinds = map[string]masterDataKind{
+	"currencies": masterData[models.CurrenciesDataModel, dto.CurrencyRequest]{
+		repo: func() masterDataRepository[models.CurrenciesDataModel] {
+			return dependencies.ProvideCurrenciesRepository()
+		},
+		newModel: func(req dto.CurrencyRequest) models.CurrenciesDataModel {
+			minorUnit := 2
+			if req.MinorUnit != nil {
+				minorUnit = *req.MinorUnit
+			}
+			return models.CurrenciesDataModel{Code: req.Code, Name: req.Name, MinorUnit: minorUnit}
+		},
+		values: func(req dto.CurrencyRequest) map[string]interface{} {
+			return map[string]interface{}{"code": req.Code, "name": req.Name}
+		},
+	},
+	"countries": masterData[models.CountriesDataModel, dto.CountryRequest]{
+		repo: func() masterDataRepository[models.CountriesDataModel] {
+			return dependencies.ProvideCountriesRepository()
+		},
+		newModel: func(req dto.CountryRequest) models.CountriesDataModel {
+			return models.CountriesDataModel{Code: req.Code, Name: req.Name}
+		},
+		values: func(req dto.CountryRequest) map[string]interface{} {
+			return map[string]interface{}{"code": req.Code, "name": req.Name}
+		},
+	},
+	"payment-methods": masterData[models.PaymentMethodsDataModel, dto.PaymentMethodRequest]{
+		repo: func() masterDataRepository[models.PaymentMethodsDataModel] {
+			return dependencies.ProvidePaymentMethodsRepository()
+		},
+		newModel: func(req dto.PaymentMethodRequest) models.PaymentMethodsDataModel {
+			return models.PaymentMethodsDataModel{Name: req.Name}
+		},
+		values: func(req dto.PaymentMethodRequest) map[string]interface{} {
+			return map[string]interface{}{"name": req.Name}
+		},
+	},
+	"ewallet-providers": masterData[models.EWalletProvidersDataModel, dto.ProviderRequest]{
+		repo: func() masterDataRepository[models.EWalletProvidersDataModel] {
+			return dependencies.ProvideEWalletProvidersRepository()
+		},
+		newModel: func(req dto.ProviderRequest) models.EWalletProvidersDataModel {
+			return models.EWalletProvidersDataModel{Name: req.Name, ProviderName: req.ProviderName}
+		},
+		values: func(req dto.ProviderRequest) map[string]interface{} {
+			return map[string]interface{}{"name": req.Name, "provider_name": req.ProviderName}
+		},
+	},
+	"va-providers": masterData[models.VAProvidersDataModel, dto.ProviderRequest]{
+		repo: func() masterDataRepository[models.VAProvidersDataModel] {
+			return dependencies.ProvideVAProvidersRepository()
+		},
+		newModel: func(req dto.ProviderRequest) models.VAProvidersDataModel {
+			return models.VAProvidersDataModel{Name: req.Name, ProviderName: req.ProviderName}
+		},
+		values: func(req dto.ProviderRequest) map[string]interface{} {
+			return map[string]interface{}{"name": req.Name, "provider_name": req.ProviderName}
+		},
+	},
+}
+
+func masterDataKindOf(c *fiber.Ctx) (masterDataKind, error) {
+	kind, ok := masterDataKinds[c.Params("kind")]
+	if !ok {
+		return nil, apperror.NotFound("master data kind", c.Params("kind"))
+	}
+	return kind, nil
+}
+
+func masterDataID(c *fiber.Ctx) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, apperror.InvalidRequest(err)
+	}
+	return id, nil
+}
+
+// masterDataError maps unique violations to CONFLICT.
+func masterDataError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		err = apperror.Conflict("master data already exists", err)
+	}
+	return common.AppErrorResponse(c, err, nil, "")
+}
+
+// invalidateMasterData drops the cached rows of the kind on every replica.
+// A failed publish only delays the other replicas until the cache TTL.
+func invalidateMasterData(c *fiber.Ctx) {
+	if err := dependencies.ProvideMasterDataCache().Invalidate(c.UserContext(), c.Params("kind")); err != nil {
+		log.Printf("Failed to publish master data invalidation of %s: %v", c.Params("kind"), err)
+	}
+}
+
+// ListMasterDataHandler handles GET /admin/master-data/:kind. Soft-deleted
+// and inactive rows are listed with ?include_deleted=true.
+func ListMasterDataHandler(c *fiber.Ctx) error {
+	kind, err := masterDataKindOf(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	var opts []repositories.QueryOption
+	if c.QueryBool("include_deleted") {
+		opts = append(opts, repositories.IncludeDeleted())
+	}
+	rows, err := kind.list(dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()), opts...)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Success", rows, "")
+}
+
+// CreateMasterDataHandler handles POST /admin/master-data/:kind
+func CreateMasterDataHandler(c *fiber.Ctx) error {
+	kind, err := masterDataKindOf(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := kind.create(dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()), c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	invalidateMasterData(c)
+	return common.SuccessResponse(c, fiber.StatusCreated, "Created", row, "")
+}
+
+// UpdateMasterDataHandler handles PUT /admin/master-data/:kind/:id
+func UpdateMasterDataHandler(c *fiber.Ctx) error {
+	kind, err := masterDataKindOf(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	id, err := masterDataID(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := kind.update(dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()), c, id)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	invalidateMasterData(c)
+	return common.SuccessResponse(c, fiber.StatusOK, "Updated", row, "")
+}
+
+// DeleteMasterDataHandler handles DELETE /admin/master-data/:kind/:id. Rows
+// are soft-deleted so payments keep their references.
+func DeleteMasterDataHandler(c *fiber.Ctx) error {
+	kind, err := masterDataKindOf(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	id, err := masterDataID(c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	if err := kind.remove(dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext()), id); err != nil {
+		return masterDataError(c, err)
+	}
+	invalidateMasterData(c)
+	return common.SuccessResponse(c, fiber.StatusOK, "Deleted", nil, "")
+}
@@ -0,0 +1,211 @@
+package workers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+// MemoryJobStore is a single-replica JobStore. Like the Redis store, results
+// and batches expire ttl after they were last saved.
+type MemoryJobStore struct {
+	mu           sync.RWMutex
+	ttl          time.Duration
+	results      map[string]*JobResult
+	batches      map[string]*Batch
+	expiries     map[string]time.Time
+	batchExpires map[string]time.Time
+	nextSweep    time.Time
+	subscribers  map[string]map[chan *JobResult]struct{}
+}
+
+func NewMemoryJobStore(ttl time.Duration) *MemoryJobStore {
+	return &MemoryJobStore{
+		ttl:          ttl,
+		results:      make(map[string]*JobResult),
+		batches:      make(map[string]*Batch),
+		expiries:     make(map[string]time.Time),
+		batchExpires: make(map[string]time.Time),
+		subscribers:  make(map[string]map[chan *JobResult]struct{}),
+	}
+}
+
+func (s *MemoryJobStore) Save(ctx context.Context, result *JobResult) error {
+	copied := *result
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.save(&copied)
+	return nil
+}
+
+// save stores result and notifies its subscribers; s.mu must be held. The
+// terminal result is always delivered, after which the subscriptions of the
+// job are closed.
+func (s *MemoryJobStore) save(result *JobResult) {
+	now := time.Now()
+	s.sweep(now)
+	s.results[result.ID] = result
+	s.expiries[result.ID] = now.Add(s.ttl)
+
+	terminal := result.Status.IsTerminal()
+	for ch := range s.subscribers[result.ID] {
+		snapshot := *result
+		select {
+		case ch <- &snapshot:
+			continue
+		default:
+		}
+		if !terminal {
+			// slow subscriber; it will catch up on the next transition
+			continue
+		}
+		// make room for the terminal result; this is the only sender
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- &snapshot
+	}
+	if terminal {
+		for ch := range s.subscribers[result.ID] {
+			close(ch)
+		}
+		delete(s.subscribers, result.ID)
+	}
+}
+
+// sweep drops expired results and batches at most once per
+// memorySweepInterval; s.mu must be held.
+func (s *MemoryJobStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	s.nextSweep = now.Add(memorySweepInterval)
+	for id, expires := range s.expiries {
+		if now.After(expires) {
+			delete(s.results, id)
+			delete(s.expiries, id)
+		}
+	}
+	for id, expires := range s.batchExpires {
+		if now.After(expires) {
+			delete(s.batches, id)
+			delete(s.batchExpires, id)
+		}
+	}
+}
+
+// get returns the result of the job unless it expired; s.mu must be held.
+func (s *MemoryJobStore) get(id string) (*JobResult, bool) {
+	result, ok := s.results[id]
+	if !ok || time.Now().After(s.expiries[id]) {
+		return nil, false
+	}
+	return result, true
+}
+
+// Update holds the lock across fn, so no other change can interleave.
+func (s *MemoryJobStore) Update(ctx context.Context, id string, fn func(result *JobResult) bool) (*JobResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.get(id)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	copied := *current
+	if fn(&copied) {
+		updated := copied
+		s.save(&updated)
+	}
+	return &copied, nil
+}
+
+func (s *MemoryJobStore) Get(ctx context.Context, id string) (*JobResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result, ok := s.get(id)
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	copied := *result
+	return &copied, nil
+}
+
+func (s *MemoryJobStore) Subscribe(ctx context.Context, id string) (<-chan *JobResult, error) {
+	ch := make(chan *JobResult, 8)
+
+	s.mu.Lock()
+	if s.subscribers[id] == nil {
+		s.subscribers[id] = make(map[chan *JobResult]struct{})
+	}
+	s.subscribers[id][ch] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[id][ch]; !ok {
+			return // closed after the terminal result
+		}
+		delete(s.subscribers[id], ch)
+		if len(s.subscribers[id]) == 0 {
+			delete(s.subscribers, id)
+		}
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+func (s *MemoryJobStore) List(ctx context.Context, filter JobFilter) ([]*JobResult, int, error) {
+	s.mu.RLock()
+	matched := make([]*JobResult, 0)
+	for id := range s.results {
+		result, ok := s.get(id)
+		if ok && filter.Match(result) {
+			copied := *result
+			matched = append(matched, &copied)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(matched[j].CreatedAt)
+	})
+	return filter.page(matched), len(matched), nil
+}
+
+func (s *MemoryJobStore) SaveBatch(ctx context.Context, batch *Batch) error {
+	copied := *batch
+	copied.Items = append([]BatchItem(nil), batch.Items...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+	s.batches[batch.ID] = &copied
+	s.batchExpires[batch.ID] = now.Add(s.ttl)
+	return nil
+}
+
+func (s *MemoryJobStore) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batch, ok := s.batches[id]
+	if !ok || time.Now().After(s.batchExpires[id]) {
+		return nil, ErrBatchNotFound
+	}
+	copied := *batch
+	copied.Items = append([]BatchItem(nil), batch.Items...)
+	return &copied, nil
+}
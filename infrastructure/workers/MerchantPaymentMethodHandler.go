@@ -0,0 +1,241 @@
+package workers
+
+import (
+	"encoding/json"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/database/repositories"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultMerchantChannelTimezone = "Asia/Jakarta"
+
+// merchantPaymentMethodModel resolves the channel and currency of req and
+// converts its amounts to minor units.
+func merchantPaymentMethodModel(db *gorm.DB, merchantID uuid.UUID, req dto.MerchantPaymentMethodRequest) (models.MerchantPaymentMethodsDataModel, error) {
+	var row models.MerchantPaymentMethodsDataModel
+
+	method, err := dependencies.ProvidePaymentMethodsRepository().FindOne(db, models.PaymentMethodsDataModel{Name: req.ChannelCode})
+	if err != nil {
+		return row, err
+	}
+	currency, err := dependencies.ProvideCurrenciesRepository().FindByCode(db, req.Currency)
+	if err != nil {
+		return row, err
+	}
+	unit := money.Currency{Code: currency.Code, Exponent: currency.MinorUnit}
+
+	row = models.MerchantPaymentMethodsDataModel{
+		MerchantID:      merchantID,
+		PaymentMethodID: method.ID,
+		CurrencyID:      currency.ID,
+		Enabled:         req.Enabled == nil || *req.Enabled,
+		DailyCountCap:   req.DailyCountCap,
+		Timezone:        req.Timezone,
+		PaymentMethod:   &method,
+		Currency:        currency,
+	}
+	if row.Timezone == "" {
+		row.Timezone = defaultMerchantChannelTimezone
+	}
+	if req.ActiveFrom != "" {
+		row.ActiveFrom, row.ActiveUntil = &req.ActiveFrom, &req.ActiveUntil
+	}
+	for _, amount := range []struct {
+		value json.Number
+		dest  **int64
+	}{
+		{req.MinAmount, &row.MinAmount},
+		{req.MaxAmount, &row.MaxAmount},
+		{req.DailyAmountCap, &row.DailyAmountCap},
+	} {
+		if amount.value == "" {
+			continue
+		}
+		m, err := money.Parse(amount.value.String(), unit)
+		if err != nil {
+			return row, apperror.InvalidRequest(err)
+		}
+		*amount.dest = &m.Minor
+	}
+	return row, nil
+}
+
+func merchantPaymentMethodResponse(row models.MerchantPaymentMethodsDataModel) dto.MerchantPaymentMethodResponse {
+	res := dto.MerchantPaymentMethodResponse{
+		ID:            row.ID.String(),
+		MerchantID:    row.MerchantID.String(),
+		Enabled:       row.Enabled,
+		DailyCountCap: row.DailyCountCap,
+		ActiveFrom:    row.ActiveFrom,
+		ActiveUntil:   row.ActiveUntil,
+		Timezone:      row.Timezone,
+		CreatedDate:   row.CreatedDate,
+		UpdatedDate:   row.UpdatedDate,
+		DeletedDate:   row.DeletedDate,
+		DataStatus:    row.DataStatus,
+	}
+	if row.PaymentMethod != nil {
+		res.ChannelCode = row.PaymentMethod.Name
+	}
+	var unit money.Currency
+	if row.Currency != nil {
+		res.Currency = row.Currency.Code
+		unit = money.Currency{Code: row.Currency.Code, Exponent: row.Currency.MinorUnit}
+	}
+	major := func(minor *int64) json.Number {
+		if minor == nil {
+			return ""
+		}
+		return json.Number(money.New(*minor, unit).String())
+	}
+	res.MinAmount = major(row.MinAmount)
+	res.MaxAmount = major(row.MaxAmount)
+	res.DailyAmountCap = major(row.DailyAmountCap)
+	return res
+}
+
+// merchantOf returns the merchant of the :merchant_id path parameter.
+func merchantOf(c *fiber.Ctx, db *gorm.DB) (*models.MerchantsDataModel, error) {
+	id, err := uuid.Parse(c.Params("merchant_id"))
+	if err != nil {
+		return nil, apperror.InvalidRequest(err)
+	}
+	return dependencies.ProvideMerchantsRepository().FindByID(db, id)
+}
+
+// merchantPaymentMethodOf returns the channel of the :id path parameter,
+// which must belong to merchant.
+func merchantPaymentMethodOf(c *fiber.Ctx, db *gorm.DB, merchant *models.MerchantsDataModel) (*models.MerchantPaymentMethodsDataModel, error) {
+	id, err := masterDataID(c)
+	if err != nil {
+		return nil, err
+	}
+	row, err := dependencies.ProvideMerchantPaymentMethodsRepository().FindByID(db, id, repositories.IncludeInactive())
+	if err != nil {
+		return nil, err
+	}
+	if row.MerchantID != merchant.ID {
+		return nil, apperror.NotFound("merchant payment method", id.String())
+	}
+	return row, nil
+}
+
+// ListMerchantPaymentMethodsHandler handles GET
+// /admin/merchants/:merchant_id/payment-methods. Soft-deleted channels are
+// listed with ?include_deleted=true.
+func ListMerchantPaymentMethodsHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	var opts []repositories.QueryOption
+	if c.QueryBool("include_deleted") {
+		opts = append(opts, repositories.IncludeDeleted())
+	}
+	rows, err := dependencies.ProvideMerchantPaymentMethodsRepository().FindByMerchant(db, merchant.ID, opts...)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	res := make([]dto.MerchantPaymentMethodResponse, 0, len(rows))
+	for _, row := range rows {
+		res = append(res, merchantPaymentMethodResponse(row))
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Success", res, "")
+}
+
+// CreateMerchantPaymentMethodHandler handles POST
+// /admin/merchants/:merchant_id/payment-methods. Once a merchant has a
+// channel configured, it may only use its configured channels.
+func CreateMerchantPaymentMethodHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	req, err := parseMasterDataRequest[dto.MerchantPaymentMethodRequest](c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := merchantPaymentMethodModel(db, merchant.ID, req)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	if err := dependencies.ProvideMerchantPaymentMethodsRepository().Insert(db, &row); err != nil {
+		return masterDataError(c, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusCreated, "Created", merchantPaymentMethodResponse(row), "")
+}
+
+// UpdateMerchantPaymentMethodHandler handles PUT
+// /admin/merchants/:merchant_id/payment-methods/:id and replaces the whole
+// configuration; omitted limits are cleared.
+func UpdateMerchantPaymentMethodHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	existing, err := merchantPaymentMethodOf(c, db, merchant)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	req, err := parseMasterDataRequest[dto.MerchantPaymentMethodRequest](c)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	row, err := merchantPaymentMethodModel(db, merchant.ID, req)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+
+	repo := dependencies.ProvideMerchantPaymentMethodsRepository()
+	err = repo.Update(db, existing.ID, map[string]interface{}{
+		"payment_method_id": row.PaymentMethodID,
+		"currency_id":       row.CurrencyID,
+		"enabled":           row.Enabled,
+		"min_amount":        row.MinAmount,
+		"max_amount":        row.MaxAmount,
+		"daily_amount_cap":  row.DailyAmountCap,
+		"daily_count_cap":   row.DailyCountCap,
+		"active_from":       row.ActiveFrom,
+		"active_until":      row.ActiveUntil,
+		"timezone":          row.Timezone,
+	})
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	updated, err := repo.FindByID(db, existing.ID, repositories.IncludeInactive())
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	updated.PaymentMethod, updated.Currency = row.PaymentMethod, row.Currency
+	return common.SuccessResponse(c, fiber.StatusOK, "Updated", merchantPaymentMethodResponse(*updated), "")
+}
+
+// DeleteMerchantPaymentMethodHandler handles DELETE
+// /admin/merchants/:merchant_id/payment-methods/:id
+func DeleteMerchantPaymentMethodHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	existing, err := merchantPaymentMethodOf(c, db, merchant)
+	if err != nil {
+		return masterDataError(c, err)
+	}
+	if err := dependencies.ProvideMerchantPaymentMethodsRepository().SoftDelete(db, existing.ID); err != nil {
+		return masterDataError(c, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Deleted", nil, "")
+}
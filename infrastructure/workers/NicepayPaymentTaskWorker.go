@@ -3,93 +3,304 @@ package workers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
 	"worker-nicepay/domain/entities"
 	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database"
 	"worker-nicepay/infrastructure/dependencies"
+	"worker-nicepay/infrastructure/publishers"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // JobStatus represents the current status of a job
 type JobStatus string
 
 const (
+	StatusScheduled  JobStatus = "scheduled"
 	StatusQueued     JobStatus = "queued"
 	StatusProcessing JobStatus = "processing"
 	StatusDone       JobStatus = "done"
 	StatusError      JobStatus = "error"
+	StatusCancelled  JobStatus = "cancelled"
 )
 
+// IsTerminal reports whether the job will not change status anymore.
+func (s JobStatus) IsTerminal() bool {
+	return s == StatusDone || s == StatusError || s == StatusCancelled
+}
+
 // JobResult contains the result and status of a job
 type JobResult struct {
-	ID      string          `json:"id"`
-	Status  JobStatus       `json:"status"`
-	Message string          `json:"message,omitempty"`
-	Data    interface{}     `json:"data,omitempty"`
-	Error   string          `json:"error,omitempty"`
-	Raw     json.RawMessage `json:"-"`
-	Code    int             `json:"-"`
+	ID         string      `json:"id"`
+	BatchID    string      `json:"batch_id,omitempty"`
+	MerchantID string      `json:"merchant_id,omitempty"`
+	Status     JobStatus   `json:"status"`
+	Message    string      `json:"message,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	ErrorCode  string      `json:"error_code,omitempty"`
+	// CancelRequested asks the replica processing the job to abort it
+	CancelRequested bool            `json:"cancel_requested,omitempty"`
+	RunAt           *time.Time      `json:"run_at,omitempty"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Raw             json.RawMessage `json:"-"`
+	Code            int             `json:"-"`
+}
+
+// Job is a queued payment creation request
+type Job struct {
+	ID         string
+	BatchID    string
+	MerchantID string
+	Priority   bool
+	Request    dto.CreatePaymentRequest
+	Incoming   entities.Incoming
+	RunAt      *time.Time
+	CreatedAt  time.Time
+	EnqueuedAt time.Time
 }
 
 // Worker manages job processing
 type Worker struct {
-	queue   chan map[string]interface{}
-	results map[string]*JobResult
+	queue     *Scheduler
+	store     JobStore
+	admission *Admission
 }
 
 var workerInstance *Worker
 
 func InitializePaymentXenditTaskWorker() {
+	queueSize := configuration.AppConfig.WorkerQueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	// by default a merchant may take a quarter of the queue
+	laneCapacity := configuration.AppConfig.WorkerLaneCapacity
+	if laneCapacity <= 0 {
+		laneCapacity = max(queueSize/4, 1)
+	}
+
 	workerInstance = &Worker{
-		queue:   make(chan map[string]interface{}, 100),
-		results: make(map[string]*JobResult),
+		queue:     NewScheduler(queueSize, laneCapacity, parseLaneWeights(configuration.AppConfig.WorkerLaneWeights), configuration.AppConfig.WorkerPriorityBurst),
+		store:     newJobStore(),
+		admission: NewAdmission(configuration.AppConfig.WorkerMerchantMaxInFlight),
 	}
 
 	// Start the worker goroutine
 	go workerInstance.processQueue()
+
+	// Release scheduled jobs when they are due
+	go workerInstance.releaseScheduled()
 }
 
-func (w *Worker) processQueue() {
-	for payload := range w.queue {
-		// Get dependencies
-		uc := dependencies.WireCreatePaymentService()
+func newJobStore() JobStore {
+	ttl := time.Duration(configuration.AppConfig.JobResultTTL) * time.Second
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	switch configuration.AppConfig.JobStoreDriver {
+	case "redis":
+		return NewRedisJobStore(publishers.RDS, configuration.AppConfig.ServiceName+":jobs:", ttl)
+	default:
+		return NewMemoryJobStore(ttl)
+	}
+}
 
-		// Process the payment
-		ctx := context.Background()
+// setResult stamps and stores the job result; store failures are only logged
+// because the job itself has already moved on.
+func (w *Worker) setResult(job *Job, result JobResult) {
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+	result.ID = job.ID
+	result.BatchID = job.BatchID
+	result.MerchantID = job.MerchantID
+	result.CreatedAt = job.CreatedAt
+	result.RunAt = job.RunAt
+	result.UpdatedAt = time.Now()
+	if err := w.store.Save(context.Background(), &result); err != nil {
+		log.Printf("Failed to save job %s status %s: %v", result.ID, result.Status, err)
+	}
+}
+
+// Enqueue admits a job without blocking. It fails with ErrMerchantQuotaExceeded,
+// ErrLaneFull or ErrQueueFull when the job has to be shed.
+func (w *Worker) Enqueue(job *Job) error {
+	if err := w.admission.Acquire(job.MerchantID); err != nil {
+		return err
+	}
 
-		// Convert payload map to DTO
-		jsonBody, _ := json.Marshal(payload)
-		var req dto.CreatePaymentRequest
-		json.Unmarshal(jsonBody, &req)
+	w.setResult(job, JobResult{
+		Status:  StatusQueued,
+		Message: "Job queued",
+	})
 
-		_, result, err := uc.Execute(ctx, req, entities.Incoming{
-			IP: payload["ip"].(string),
+	if err := w.push(job); err != nil {
+		w.setResult(job, JobResult{
+			Status: StatusError,
+			Error:  err.Error(),
 		})
+		return err
+	}
+	return nil
+}
 
-		// Handle the result
-		jobID := payload["job_id"].(string)
-		if err != nil {
-			log.Printf("Error processing job %s: %v", jobID, err)
-			w.results[jobID] = &JobResult{
-				ID:     jobID,
-				Status: StatusError,
-				Error:  err.Error(),
-			}
-		} else {
-			log.Printf("Job %s completed: %v", jobID, result)
-			w.results[jobID] = &JobResult{
-				ID:      jobID,
-				Status:  StatusDone,
-				Message: "Success", // Or extract something meaningful from result if it's not empty
-				Data: map[string]string{
-					"payment_request_id": result.PaymentRequestID,
-				},
+// EnqueueWait admits a job whose queued result is already stored, waiting
+// for a free slot instead of shedding it. It only fails when ctx is done.
+func (w *Worker) EnqueueWait(ctx context.Context, job *Job) error {
+	backoff := 50 * time.Millisecond
+	for {
+		err := w.admission.Acquire(job.MerchantID)
+		if err == nil {
+			if err = w.push(job); err == nil {
+				return nil
 			}
 		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// push hands an admitted job to its merchant lane without blocking.
+func (w *Worker) push(job *Job) error {
+	job.EnqueuedAt = time.Now()
+	if err := w.queue.Push(job); err != nil {
+		w.admission.Rejected(job.MerchantID)
+		return err
 	}
+	return nil
+}
+
+func (w *Worker) processQueue() {
+	for {
+		job, ok := w.queue.Pop()
+		if !ok {
+			return
+		}
+		w.process(job)
+	}
+}
+
+// parseLaneWeights reads "merchantA:3,merchantB:2" into lane weights.
+func parseLaneWeights(raw string) map[string]int {
+	weights := make(map[string]int)
+	for _, pair := range strings.Split(raw, ",") {
+		merchantID, weight, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if w, err := strconv.Atoi(weight); err == nil && w > 0 {
+			weights[merchantID] = w
+		}
+	}
+	return weights
+}
+
+func (w *Worker) process(job *Job) {
+	defer w.admission.Release(job.MerchantID)
+	w.admission.ObserveWait(time.Since(job.EnqueuedAt))
+
+	jobID := job.ID
+
+	if !w.start(job) {
+		return
+	}
+
+	// Skip jobs cancelled while they were waiting in the queue
+	if !startProcessing(w.store, jobID, "Job processing") {
+		log.Printf("Job %s was cancelled before processing", jobID)
+		return
+	}
+
+	// Get dependencies
+	uc := dependencies.WireCreatePaymentService()
+
+	// Process the payment as the requester; a cancel request aborts the context
+	ctx, cancel := context.WithCancel(database.WithActor(context.Background(), job.Incoming.Actor, job.Incoming.IP))
+	defer cancel()
+	w.watchCancel(ctx, cancel, jobID)
+
+	_, result, err := uc.Execute(ctx, job.Request, job.Incoming)
+
+	// Handle the result
+	if err != nil && ctx.Err() != nil {
+		log.Printf("Job %s aborted: %v", jobID, err)
+		w.setResult(job, JobResult{
+			Status: StatusCancelled,
+			Error:  err.Error(),
+		})
+	} else if err != nil {
+		log.Printf("Error processing job %s: %v", jobID, err)
+		w.setResult(job, JobResult{
+			Status:    StatusError,
+			Error:     err.Error(),
+			ErrorCode: string(apperror.CodeOf(err)),
+		})
+	} else {
+		log.Printf("Job %s completed: %v", jobID, result)
+		w.setResult(job, JobResult{
+			Status:  StatusDone,
+			Message: "Success", // Or extract something meaningful from result if it's not empty
+			Data: map[string]string{
+				"payment_request_id": result.PaymentRequestID,
+			},
+		})
+	}
+}
+
+// startProcessing moves a job to processing unless it was cancelled, in one
+// conditional update so a concurrent Cancel either wins or sees the job
+// processing. A job without a stored result is processed.
+func startProcessing(store JobStore, jobID string, message string) bool {
+	cancelled := false
+	_, err := store.Update(context.Background(), jobID, func(result *JobResult) bool {
+		cancelled = result.Status == StatusCancelled
+		if cancelled {
+			return false
+		}
+		result.Status = StatusProcessing
+		result.Message = message
+		result.UpdatedAt = time.Now()
+		return true
+	})
+	if err != nil {
+		log.Printf("Failed to save job %s status %s: %v", jobID, StatusProcessing, err)
+	}
+	return !cancelled
+}
+
+// watchCancel cancels a running job when any replica stores a cancel request for it.
+func (w *Worker) watchCancel(ctx context.Context, cancel context.CancelFunc, jobID string) {
+	updates, err := w.store.Subscribe(ctx, jobID)
+	if err != nil {
+		log.Printf("Job %s cannot be cancelled while processing: %v", jobID, err)
+		return
+	}
+	go func() {
+		for result := range updates {
+			if result.CancelRequested {
+				cancel()
+				return
+			}
+		}
+	}()
 }
 
 // PaymentHandler handles payment requests
@@ -105,14 +316,17 @@ func PaymentHandler(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return common.ErrorResponse(c, fiber.StatusBadRequest, "Invalid request payload", err, req, incoming.TransactionID)
 	}
+	if err := req.Validate(); err != nil {
+		return common.AppErrorResponse(c, apperror.InvalidRequest(err), req, incoming.TransactionID)
+	}
 
 	// Execute the payment use case directly
 	uc := dependencies.WireCreatePaymentService()
 
 	// Use context from the request
-	_, result, err := uc.Execute(c.Context(), req, *incoming)
+	_, result, err := uc.Execute(c.UserContext(), req, *incoming)
 	if err != nil {
-		return common.ErrorResponse(c, fiber.StatusBadRequest, err.Error(), err, req, "")
+		return common.AppErrorResponse(c, err, req, incoming.TransactionID)
 	}
 
 	return common.SuccessResponse(c, fiber.StatusOK, "Success", result, "")
@@ -120,7 +334,11 @@ func PaymentHandler(c *fiber.Ctx) error {
 
 // EnqueueHandler handles asynchronous job requests
 func EnqueueHandler(c *fiber.Ctx) error {
-	// Parse payload from request
+	incoming, ok := c.Locals("incoming").(*entities.Incoming)
+	if !ok {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Incoming context missing"})
+	}
+
 	// Parse payload from request
 	var req dto.CreatePaymentRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -128,21 +346,54 @@ func EnqueueHandler(c *fiber.Ctx) error {
 			"error": "Invalid request payload",
 		})
 	}
-	payload := req.ToPayloadMap() // Used for flexibility in queue or could use DTO in queue
+	if err := req.Validate(); err != nil {
+		return common.AppErrorResponse(c, apperror.InvalidRequest(err), req, incoming.TransactionID)
+	}
+
+	runAt, err := scheduleFromRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	// Generate job ID
 	jobID := generateJobID()
-	payload["job_id"] = jobID
+	job := &Job{
+		ID:         jobID,
+		MerchantID: req.MerchantID,
+		Priority:   c.QueryBool("priority"),
+		Request:    req,
+		Incoming:   *incoming,
+	}
 
-	// Create job result entry
-	workerInstance.results[jobID] = &JobResult{
-		ID:      jobID,
-		Status:  StatusQueued,
-		Message: "Job queued",
+	// Delayed jobs are persisted and released by releaseScheduled
+	if !runAt.IsZero() {
+		if err := workerInstance.Schedule(c.UserContext(), job, runAt); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"job_id": jobID,
+			"status": StatusScheduled,
+			"run_at": runAt,
+		})
 	}
 
-	// Queue the job
-	workerInstance.queue <- payload
+	// Queue the job; shed load instead of blocking the handler
+	err = workerInstance.Enqueue(job)
+	if errors.Is(err, ErrQueueFull) || errors.Is(err, ErrLaneFull) || errors.Is(err, ErrMerchantQuotaExceeded) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds()))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	// Return job ID
 	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
@@ -160,17 +411,40 @@ func StatusHandler(c *fiber.Ctx) error {
 		})
 	}
 
-	result, exists := workerInstance.results[jobID]
-	if !exists {
+	result, err := workerInstance.store.Get(c.Context(), jobID)
+	if errors.Is(err, ErrJobNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Job not found",
 		})
 	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	return c.JSON(result)
 }
 
-// Helper to generate a job ID
+// MetricsHandler reports queue depth, in-flight jobs and wait times
+func MetricsHandler(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"queue_depth":    workerInstance.queue.Len(),
+		"queue_capacity": workerInstance.queue.Cap(),
+		"lane_capacity":  workerInstance.queue.LaneCap(),
+		"lanes":          workerInstance.queue.Depths(),
+		"admission":      workerInstance.admission.Metrics(),
+	})
+}
+
+func retryAfterSeconds() int {
+	if configuration.AppConfig.WorkerRetryAfter > 0 {
+		return configuration.AppConfig.WorkerRetryAfter
+	}
+	return 1
+}
+
+// Helper to generate a job ID; job IDs are not guessable
 func generateJobID() string {
-	return "job-" + time.Now().Format("20060102-150405-999999999")
+	return "job-" + uuid.NewString()
 }
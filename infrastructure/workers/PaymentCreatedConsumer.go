@@ -0,0 +1,133 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"worker-nicepay/application/events"
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database"
+	"worker-nicepay/infrastructure/dependencies"
+	"worker-nicepay/infrastructure/queue"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const paymentReadModelIndex = "payments"
+
+// PaymentReadModel is the Elasticsearch document kept per payment.
+type PaymentReadModel struct {
+	events.PaymentCreatedEvent
+	EventID   string    `json:"event_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var paymentCreatedConsumer *queue.Consumer
+var notificationClient = resty.New().SetTimeout(10 * time.Second)
+
+// InitializePaymentCreatedConsumer starts consuming the payment.created queue
+// declared by InitializeRabbitMQ.
+func InitializePaymentCreatedConsumer() {
+	paymentCreatedConsumer = queue.NewConsumer(queue.RabbitConn, queue.ConsumerConfig{
+		Queue:           events.PaymentCreatedEventName,
+		Concurrency:     configuration.AppConfig.ConsumerConcurrency,
+		MaxRetries:      configuration.AppConfig.ConsumerMaxRetries,
+		RetryBackoff:    time.Duration(configuration.AppConfig.ConsumerRetryBackoff) * time.Millisecond,
+		MaxRetryBackoff: time.Duration(configuration.AppConfig.ConsumerMaxRetryBackoff) * time.Millisecond,
+	})
+	paymentCreatedConsumer.Register(events.PaymentCreatedEventName, indexPaymentReadModel)
+	paymentCreatedConsumer.Register(events.PaymentCreatedEventName, broadcastPaymentCreated)
+	paymentCreatedConsumer.Register(events.PaymentCreatedEventName, notifyMerchantPaymentCreated)
+
+	if err := paymentCreatedConsumer.Start(context.Background()); err != nil {
+		log.Fatal("Failed to start payment.created consumer: ", err)
+	}
+}
+
+func StopPaymentCreatedConsumer() {
+	if paymentCreatedConsumer != nil {
+		paymentCreatedConsumer.Stop()
+	}
+}
+
+func decodePaymentCreated(env events.Envelope) (events.PaymentCreatedEvent, error) {
+	var event events.PaymentCreatedEvent
+	if err := env.Decode(&event); err != nil {
+		return event, fmt.Errorf("%w: %v", queue.ErrPoisonMessage, err)
+	}
+	return event, nil
+}
+
+// indexPaymentReadModel upserts the payment document keyed by payment ID, so
+// redelivery is idempotent.
+func indexPaymentReadModel(ctx context.Context, env events.Envelope) error {
+	event, err := decodePaymentCreated(env)
+	if err != nil {
+		return err
+	}
+	if database.ElasticsearchClient == nil {
+		return nil
+	}
+
+	doc := PaymentReadModel{
+		PaymentCreatedEvent: event,
+		EventID:             env.ID,
+		CreatedAt:           env.Time,
+	}
+	_, err = database.ElasticsearchClient.Index(paymentReadModelIndex).
+		Id(event.PaymentID).
+		Request(&doc).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to index payment %s: %w", event.PaymentID, err)
+	}
+	return nil
+}
+
+// broadcastPaymentCreated republishes the envelope through the publisher
+// selected by PUBLISHER_DRIVER. It runs before the merchant is notified, so a
+// failed publish does not notify the merchant twice on retry.
+func broadcastPaymentCreated(ctx context.Context, env events.Envelope) error {
+	if _, err := decodePaymentCreated(env); err != nil {
+		return err
+	}
+	return dependencies.ProvidePublisher().Publish(ctx, env)
+}
+
+// notifyMerchantPaymentCreated posts the envelope to the merchant callback URL.
+// Server errors are retried, client errors are treated as poison.
+func notifyMerchantPaymentCreated(ctx context.Context, env events.Envelope) error {
+	event, err := decodePaymentCreated(env)
+	if err != nil {
+		return err
+	}
+	if event.CallbackURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("%w: %v", queue.ErrPoisonMessage, err)
+	}
+
+	resp, err := notificationClient.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", events.ContentTypeCloudEventsJSON).
+		SetHeader("Ce-Id", env.ID).
+		SetHeader("Ce-Type", env.Type).
+		SetBody(body).
+		Post(event.CallbackURL)
+	if err != nil {
+		return fmt.Errorf("failed to notify merchant %s: %w", event.MerchantID, err)
+	}
+	switch {
+	case resp.StatusCode() >= 500:
+		return fmt.Errorf("merchant %s callback returned %d", event.MerchantID, resp.StatusCode())
+	case resp.StatusCode() >= 400:
+		return fmt.Errorf("%w: merchant %s callback returned %d", queue.ErrPoisonMessage, event.MerchantID, resp.StatusCode())
+	}
+	return nil
+}
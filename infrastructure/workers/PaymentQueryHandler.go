@@ -0,0 +1,73 @@
+package workers
+
+import (
+	"encoding/json"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func paymentResponse(payment models.PaymentsDataModel) dto.PaymentResponse {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	res := dto.PaymentResponse{
+		ID:                payment.ID.String(),
+		TransactionID:     value(payment.TransactionID),
+		ReferenceNo:       value(payment.ReferenceNo),
+		ChannelCode:       value(payment.PaymentGateway),
+		Status:            value(payment.Status),
+		Description:       value(payment.Description),
+		RedirectURL:       value(payment.RedirectURL),
+		FeeCalculatedDate: payment.FeeCalculatedDate,
+		ExpiredAt:         payment.ExpiredPayment,
+		CreatedDate:       payment.CreatedDate,
+		UpdatedDate:       payment.UpdatedDate,
+	}
+	if payment.MerchantID != nil {
+		res.MerchantID = payment.MerchantID.String()
+	}
+	if payment.Merchant != nil {
+		res.Merchant = payment.Merchant.Name
+	}
+	if payment.FeeScheduleID != nil {
+		id := payment.FeeScheduleID.String()
+		res.FeeScheduleID = &id
+	}
+
+	var unit money.Currency
+	if payment.Currency != nil {
+		res.Currency = payment.Currency.Code
+		unit = money.Currency{Code: payment.Currency.Code, Exponent: payment.Currency.MinorUnit}
+	}
+	major := func(minor *int64) json.Number {
+		if minor == nil {
+			return ""
+		}
+		return json.Number(money.New(*minor, unit).String())
+	}
+	res.GrossAmount = major(payment.Amount)
+	res.FeeAmount = major(payment.FeeAmount)
+	res.FeeTaxAmount = major(payment.FeeTaxAmount)
+	res.NetAmount = major(payment.NetAmount)
+	return res
+}
+
+// PaymentQueryHandler handles GET /payments/:transaction_id and shows the
+// payment with its gross, fee and net amounts.
+func PaymentQueryHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	payment, err := dependencies.ProvidePaymentRepository().FindByTransactionID(db, c.Params("transaction_id"))
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	return common.SuccessResponse(c, fiber.StatusOK, "Success", paymentResponse(*payment), "")
+}
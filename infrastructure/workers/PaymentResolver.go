@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/dependencies"
+)
+
+const (
+	defaultResolveInterval = 30 * time.Second
+	defaultResolveAfter    = 5 * time.Minute
+	resolveBatch           = 50
+)
+
+// InitializePaymentResolver periodically settles payments left UNKNOWN, or
+// INITIATED by a crashed process, and PENDING payments the payer completed,
+// by querying Nicepay.
+func InitializePaymentResolver() {
+	interval := time.Duration(configuration.AppConfig.NicepayResolveInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultResolveInterval
+	}
+	// INITIATED rows younger than this may still have a call in flight; PENDING
+	// rows are checked again this long after their last check
+	resolveAfter := time.Duration(configuration.AppConfig.NicepayResolveAfter) * time.Millisecond
+	if resolveAfter <= 0 {
+		resolveAfter = defaultResolveAfter
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			settled, err := dependencies.ProvideTransactionService().Resolve(context.Background(), resolveAfter, resolveBatch)
+			if err != nil {
+				log.Printf("Failed to resolve payments: %v", err)
+			}
+			if settled > 0 {
+				log.Printf("Resolved %d payments", settled)
+			}
+		}
+	}()
+}
@@ -0,0 +1,292 @@
+package workers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/money"
+	"worker-nicepay/domain/settlement"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/dependencies"
+	"worker-nicepay/infrastructure/service"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+var reconciliationExportHeader = []string{
+	"line_no", "transaction_id", "reference_no", "payment_id", "currency", "settlement_amount",
+	"payment_amount", "settlement_status", "payment_status", "result", "mismatches", "detail",
+}
+
+// settlementImportOf reads the multipart form of an import. The mapping and
+// delimiter fields override the configured ones for this file.
+func settlementImportOf(c *fiber.Ctx) (service.SettlementImport, func() error, error) {
+	var in service.SettlementImport
+	header, err := c.FormFile("file")
+	if err != nil {
+		return in, nil, apperror.InvalidRequest(errors.New("file is required"))
+	}
+
+	if in.Mapping, err = settlement.ParseMapping(c.FormValue("mapping", configuration.AppConfig.SettlementCSVMapping)); err != nil {
+		return in, nil, apperror.InvalidRequest(err)
+	}
+	delimiter := c.FormValue("delimiter", configuration.AppConfig.SettlementCSVDelimiter)
+	if delimiter != "" {
+		if utf8.RuneCountInString(delimiter) != 1 {
+			return in, nil, apperror.InvalidRequest(errors.New("delimiter must be a single character"))
+		}
+		in.Comma, _ = utf8.DecodeRuneInString(delimiter)
+	}
+	for field, target := range map[string]**time.Time{"period_from": &in.PeriodFrom, "period_to": &in.PeriodTo} {
+		value := c.FormValue(field)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return in, nil, apperror.InvalidRequest(err)
+		}
+		*target = &t
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return in, nil, err
+	}
+	in.FileName, in.File = header.Filename, file
+	return in, file.Close, nil
+}
+
+// currencyUnits returns the currency of code, looked up once per units.
+func currencyUnits(db *gorm.DB, units map[string]money.Currency, code string) (money.Currency, error) {
+	if unit, ok := units[code]; ok {
+		return unit, nil
+	}
+	currency, err := dependencies.ProvideCurrenciesRepository().FindByCode(db, code)
+	if err != nil {
+		return money.Currency{}, err
+	}
+	units[code] = money.Currency{Code: currency.Code, Exponent: currency.MinorUnit}
+	return units[code], nil
+}
+
+func reconciliationRunResponse(run models.ReconciliationRunsDataModel) dto.ReconciliationRunResponse {
+	res := dto.ReconciliationRunResponse{
+		ID:                run.ID.String(),
+		FileName:          run.FileName,
+		Status:            run.Status,
+		Mapping:           run.Mapping,
+		TotalRows:         run.TotalRows,
+		Matched:           run.Matched,
+		Mismatched:        run.Mismatched,
+		MissingPayment:    run.MissingPayment,
+		MissingSettlement: run.MissingSettlement,
+		Invalid:           run.Invalid,
+		Error:             run.Error,
+		FinishedDate:      run.FinishedDate,
+		CreatedDate:       run.CreatedDate,
+	}
+	if run.PeriodFrom != nil && run.PeriodTo != nil {
+		from, to := time.UnixMilli(*run.PeriodFrom), time.UnixMilli(*run.PeriodTo)
+		res.PeriodFrom, res.PeriodTo = &from, &to
+	}
+	return res
+}
+
+// reconciliationItemResponse shows the amounts of item in major units; they
+// are left out when its currency is unknown.
+func reconciliationItemResponse(db *gorm.DB, units map[string]money.Currency, item models.ReconciliationItemsDataModel) dto.ReconciliationItemResponse {
+	res := dto.ReconciliationItemResponse{
+		LineNo:           item.LineNo,
+		TransactionID:    item.TransactionID,
+		ReferenceNo:      item.ReferenceNo,
+		Currency:         item.Currency,
+		SettlementStatus: item.SettlementStatus,
+		PaymentStatus:    item.PaymentStatus,
+		Result:           item.Result,
+		Detail:           item.Detail,
+	}
+	if item.PaymentID != nil {
+		id := item.PaymentID.String()
+		res.PaymentID = &id
+	}
+	if item.Mismatches != nil {
+		res.Mismatches = strings.Split(*item.Mismatches, ",")
+	}
+	if item.Currency == nil {
+		return res
+	}
+	unit, err := currencyUnits(db, units, *item.Currency)
+	if err != nil {
+		return res
+	}
+	major := func(minor *int64) json.Number {
+		if minor == nil {
+			return ""
+		}
+		return json.Number(money.New(*minor, unit).String())
+	}
+	res.SettlementAmount = major(item.SettlementAmount)
+	res.PaymentAmount = major(item.PaymentAmount)
+	return res
+}
+
+// pageOf returns the ?page= and ?limit= query parameters.
+func pageOf(c *fiber.Ctx) (int, int) {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultJobsPageLimit)))
+	if limit < 1 {
+		limit = defaultJobsPageLimit
+	}
+	if limit > maxJobsPageLimit {
+		limit = maxJobsPageLimit
+	}
+	return page, limit
+}
+
+func pageMeta(page int, limit int, total int64) *common.MetaData {
+	return &common.MetaData{
+		Page:      page,
+		TotalPage: int((total + int64(limit) - 1) / int64(limit)),
+		TotalRows: int(total),
+		Limit:     limit,
+	}
+}
+
+// reconciliationRunOf returns the run of the :id path parameter.
+func reconciliationRunOf(c *fiber.Ctx, db *gorm.DB) (*models.ReconciliationRunsDataModel, error) {
+	id, err := masterDataID(c)
+	if err != nil {
+		return nil, err
+	}
+	return dependencies.ProvideReconciliationRepository().FindByID(db, id)
+}
+
+// ImportSettlementHandler handles POST /admin/reconciliations, a multipart
+// form with the settlement CSV in file. Optional fields: mapping
+// (field:Column,...), delimiter, and period_from/period_to (RFC3339) to also
+// report paid payments of the period missing from the file.
+func ImportSettlementHandler(c *fiber.Ctx) error {
+	in, closeFile, err := settlementImportOf(c)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	defer closeFile()
+
+	run, err := dependencies.ProvideReconciliationService().Import(c.UserContext(), in)
+	if run == nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	if err != nil {
+		log.Printf("Reconciliation %s of %s failed: %v", run.ID, run.FileName, err)
+	}
+	return common.SuccessResponse(c, fiber.StatusCreated, "Created", reconciliationRunResponse(*run), "")
+}
+
+// ListReconciliationsHandler handles GET /admin/reconciliations?page=&limit=,
+// the latest runs first.
+func ListReconciliationsHandler(c *fiber.Ctx) error {
+	page, limit := pageOf(c)
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	runs, total, err := dependencies.ProvideReconciliationRepository().FindRuns(db, (page-1)*limit, limit)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	res := make([]dto.ReconciliationRunResponse, 0, len(runs))
+	for _, run := range runs {
+		res = append(res, reconciliationRunResponse(run))
+	}
+
+	resp := common.BuildSuccessResponse("Success", fiber.StatusOK, res, "")
+	resp.Meta = pageMeta(page, limit, total)
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// ReconciliationReportHandler handles GET
+// /admin/reconciliations/:id?result=&page=&limit=, the run with a page of its
+// items in file order.
+func ReconciliationReportHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	run, err := reconciliationRunOf(c, db)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	page, limit := pageOf(c)
+	items, total, err := dependencies.ProvideReconciliationRepository().FindItems(db, run.ID, strings.ToUpper(c.Query("result")), (page-1)*limit, limit)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+
+	units := map[string]money.Currency{}
+	res := dto.ReconciliationReportResponse{
+		Run:   reconciliationRunResponse(*run),
+		Items: make([]dto.ReconciliationItemResponse, 0, len(items)),
+	}
+	for _, item := range items {
+		res.Items = append(res.Items, reconciliationItemResponse(db, units, item))
+	}
+
+	resp := common.BuildSuccessResponse("Success", fiber.StatusOK, res, "")
+	resp.Meta = pageMeta(page, limit, total)
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
+
+// ExportReconciliationHandler handles GET /admin/reconciliations/:id/export
+// and streams the items of the run, or those of ?result=, as CSV.
+func ExportReconciliationHandler(c *fiber.Ctx) error {
+	// the body is written after the handler returns, past the request context
+	db := dependencies.ProvideYugabyteClient().GetDB()
+	run, err := reconciliationRunOf(c, db.WithContext(c.UserContext()))
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	result := strings.ToUpper(c.Query("result"))
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="reconciliation-`+run.ID.String()+`.csv"`)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		out := csv.NewWriter(w)
+		out.Write(reconciliationExportHeader)
+		units := map[string]money.Currency{}
+		err := dependencies.ProvideReconciliationRepository().EachItem(db, run.ID, result, func(item models.ReconciliationItemsDataModel) error {
+			res := reconciliationItemResponse(db, units, item)
+			lineNo := ""
+			if res.LineNo != nil {
+				lineNo = strconv.Itoa(*res.LineNo)
+			}
+			out.Write([]string{
+				lineNo, text(res.TransactionID), text(res.ReferenceNo), text(res.PaymentID), text(res.Currency),
+				res.SettlementAmount.String(), res.PaymentAmount.String(), text(res.SettlementStatus),
+				text(res.PaymentStatus), res.Result, strings.Join(res.Mismatches, ","), text(res.Detail),
+			})
+			return out.Error()
+		})
+		if err != nil {
+			log.Printf("Failed to export reconciliation %s: %v", run.ID, err)
+		}
+		out.Flush()
+	})
+	return nil
+}
+
+func text(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
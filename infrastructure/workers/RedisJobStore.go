@@ -0,0 +1,242 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisUpdateAttempts = 10
+
+// RedisJobStore shares job results between replicas. Results are stored as
+// JSON under "<prefix><id>" and every Save is published on
+// "<prefix>events:<id>". Job IDs are indexed by creation time in
+// "<prefix>index" and "<prefix>merchant:<merchant id>" for listing.
+type RedisJobStore struct {
+	client redis.UniversalClient
+	prefix string
+	ttl    time.Duration
+}
+
+func NewRedisJobStore(client redis.UniversalClient, prefix string, ttl time.Duration) *RedisJobStore {
+	return &RedisJobStore{client: client, prefix: prefix, ttl: ttl}
+}
+
+func (s *RedisJobStore) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *RedisJobStore) channel(id string) string {
+	return s.prefix + "events:" + id
+}
+
+func (s *RedisJobStore) indexKey(merchantID string) string {
+	if merchantID == "" {
+		return s.prefix + "index"
+	}
+	return s.prefix + "merchant:" + merchantID
+}
+
+func (s *RedisJobStore) batchKey(id string) string {
+	return s.prefix + "batches:" + id
+}
+
+func (s *RedisJobStore) Save(ctx context.Context, result *JobResult) error {
+	payload, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job %s: %w", result.ID, err)
+	}
+
+	pipe := s.client.TxPipeline()
+	s.save(ctx, pipe, result, payload)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save job %s: %w", result.ID, err)
+	}
+	return nil
+}
+
+// save queues the commands storing, indexing and publishing a result.
+func (s *RedisJobStore) save(ctx context.Context, pipe redis.Pipeliner, result *JobResult, payload []byte) {
+	score := float64(result.CreatedAt.UnixMilli())
+	expired := fmt.Sprintf("(%d", time.Now().Add(-s.ttl).UnixMilli())
+
+	pipe.Set(ctx, s.key(result.ID), payload, s.ttl)
+	for _, index := range []string{s.indexKey(""), s.indexKey(result.MerchantID)} {
+		pipe.ZAdd(ctx, index, redis.Z{Score: score, Member: result.ID})
+		pipe.ZRemRangeByScore(ctx, index, "-inf", expired)
+		pipe.Expire(ctx, index, s.ttl)
+	}
+	pipe.Publish(ctx, s.channel(result.ID), payload)
+}
+
+// Update WATCHes the result key, so the write fails and is retried when any
+// replica saves the job between the read and the write.
+func (s *RedisJobStore) Update(ctx context.Context, id string, fn func(result *JobResult) bool) (*JobResult, error) {
+	for attempt := 0; attempt < redisUpdateAttempts; attempt++ {
+		var result *JobResult
+		err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+			payload, err := tx.Get(ctx, s.key(id)).Bytes()
+			if errors.Is(err, redis.Nil) {
+				return ErrJobNotFound
+			}
+			if err != nil {
+				return err
+			}
+			result = &JobResult{}
+			if err := json.Unmarshal(payload, result); err != nil {
+				return fmt.Errorf("failed to unmarshal job %s: %w", id, err)
+			}
+			if !fn(result) {
+				return nil
+			}
+
+			updated, err := json.Marshal(result)
+			if err != nil {
+				return fmt.Errorf("failed to marshal job %s: %w", id, err)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				s.save(ctx, pipe, result, updated)
+				return nil
+			})
+			return err
+		}, s.key(id))
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("failed to update job %s: %w", id, redis.TxFailedErr)
+}
+
+func (s *RedisJobStore) Get(ctx context.Context, id string) (*JobResult, error) {
+	payload, err := s.client.Get(ctx, s.key(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result JobResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal job %s: %w", id, err)
+	}
+	return &result, nil
+}
+
+func (s *RedisJobStore) Subscribe(ctx context.Context, id string) (<-chan *JobResult, error) {
+	sub := s.client.Subscribe(ctx, s.channel(id))
+	// Wait for the subscription to be confirmed so no transition published
+	// after this call returns is missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to job %s: %w", id, err)
+	}
+
+	ch := make(chan *JobResult, 8)
+	go func() {
+		defer close(ch)
+		defer sub.Close()
+
+		messages := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var result JobResult
+				if err := json.Unmarshal([]byte(msg.Payload), &result); err != nil {
+					continue
+				}
+				select {
+				case ch <- &result:
+				case <-ctx.Done():
+					return
+				}
+				if result.Status.IsTerminal() {
+					return
+				}
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// List walks the creation-time index newest first. Status filtering happens
+// after loading, so the cost grows with the number of indexed jobs.
+func (s *RedisJobStore) List(ctx context.Context, filter JobFilter) ([]*JobResult, int, error) {
+	ids, err := s.client.ZRevRange(ctx, s.indexKey(filter.MerchantID), 0, -1).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matched := make([]*JobResult, 0)
+	for start := 0; start < len(ids); start += 100 {
+		end := start + 100
+		if end > len(ids) {
+			end = len(ids)
+		}
+		keys := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			keys = append(keys, s.key(id))
+		}
+
+		payloads, err := s.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, payload := range payloads {
+			str, ok := payload.(string)
+			if !ok {
+				continue // expired
+			}
+			var result JobResult
+			if err := json.Unmarshal([]byte(str), &result); err != nil {
+				continue
+			}
+			if filter.Match(&result) {
+				matched = append(matched, &result)
+			}
+		}
+	}
+
+	return filter.page(matched), len(matched), nil
+}
+
+func (s *RedisJobStore) SaveBatch(ctx context.Context, batch *Batch) error {
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch %s: %w", batch.ID, err)
+	}
+	if err := s.client.Set(ctx, s.batchKey(batch.ID), payload, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save batch %s: %w", batch.ID, err)
+	}
+	return nil
+}
+
+func (s *RedisJobStore) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	payload, err := s.client.Get(ctx, s.batchKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrBatchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var batch Batch
+	if err := json.Unmarshal(payload, &batch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal batch %s: %w", id, err)
+	}
+	return &batch, nil
+}
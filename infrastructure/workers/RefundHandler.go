@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RefundCompletionHandler handles POST /admin/payments/:transaction_id/refunds
+// and journals a refund Nicepay completed for a paid payment. A refund that
+// was reported before is answered with 200 and not journaled again.
+func RefundCompletionHandler(c *fiber.Ctx) error {
+	req, err := parseMasterDataRequest[dto.RefundRequest](c)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	transactionID := c.Params("transaction_id")
+	posted, err := dependencies.ProvideTransactionService().CompleteRefund(c.UserContext(), transactionID, req.RefundID, req.Amount.String(), time.Now())
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+
+	res := dto.RefundResponse{TransactionID: transactionID, RefundID: req.RefundID, Amount: req.Amount}
+	if !posted {
+		return common.SuccessResponse(c, fiber.StatusOK, "Refund already completed", res, "")
+	}
+	return common.SuccessResponse(c, fiber.StatusCreated, "Refund completed", res, "")
+}
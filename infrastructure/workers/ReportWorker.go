@@ -0,0 +1,336 @@
+package workers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/apperror"
+	"worker-nicepay/domain/statement"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/dependencies"
+	"worker-nicepay/infrastructure/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+var ErrReportNotFound = errors.New("report not found")
+
+// ReportJob generates the statement file of a merchant.
+type ReportJob struct {
+	ID        string
+	Request   service.StatementRequest
+	CreatedAt time.Time
+}
+
+// FileName is the download name of the report, e.g.
+// statement-<merchant id>-20260901-20261001.xlsx.
+func (j *ReportJob) FileName() string {
+	return fmt.Sprintf("statement-%s-%s-%s.%s", j.Request.MerchantID,
+		j.Request.From.Format("20060102"), j.Request.To.Format("20060102"), j.Request.Format)
+}
+
+// ReportWorker generates reports in the background. Their status is kept in
+// the job store of the payment worker, so GET /jobs/status and DELETE
+// /jobs/:id work for reports too. Files are written to REPORT_DIR, which
+// replicas must share for downloads to work on every replica, and are only
+// served under /admin for the merchant they belong to.
+type ReportWorker struct {
+	jobs  chan *ReportJob
+	store JobStore
+	dir   string
+}
+
+var reportWorkerInstance *ReportWorker
+
+// InitializeReportWorker must run after InitializePaymentXenditTaskWorker,
+// whose job store it shares.
+func InitializeReportWorker() {
+	dir := configuration.AppConfig.ReportDir
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), configuration.AppConfig.ServiceName+"-reports")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatalf("Failed to create report directory %s: %v", dir, err)
+	}
+	queueSize := configuration.AppConfig.WorkerQueueSize
+	if queueSize <= 0 {
+		queueSize = 100
+	}
+	concurrency := configuration.AppConfig.ReportConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	retention := time.Duration(configuration.AppConfig.ReportRetention) * time.Millisecond
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+
+	reportWorkerInstance = &ReportWorker{
+		jobs:  make(chan *ReportJob, queueSize),
+		store: workerInstance.store,
+		dir:   dir,
+	}
+	for i := 0; i < concurrency; i++ {
+		go reportWorkerInstance.processQueue()
+	}
+	go reportWorkerInstance.expire(retention)
+}
+
+func (w *ReportWorker) setResult(job *ReportJob, result JobResult) {
+	result.ID = job.ID
+	result.MerchantID = job.Request.MerchantID.String()
+	result.CreatedAt = job.CreatedAt
+	result.UpdatedAt = time.Now()
+	if err := w.store.Save(context.Background(), &result); err != nil {
+		log.Printf("Failed to save report %s status %s: %v", result.ID, result.Status, err)
+	}
+}
+
+// Enqueue queues a report without blocking; it fails with ErrQueueFull.
+func (w *ReportWorker) Enqueue(job *ReportJob) error {
+	job.CreatedAt = time.Now()
+	w.setResult(job, JobResult{
+		Status:  StatusQueued,
+		Message: "Report queued",
+	})
+	select {
+	case w.jobs <- job:
+		return nil
+	default:
+		w.setResult(job, JobResult{
+			Status: StatusError,
+			Error:  ErrQueueFull.Error(),
+		})
+		return ErrQueueFull
+	}
+}
+
+func (w *ReportWorker) processQueue() {
+	for job := range w.jobs {
+		w.process(job)
+	}
+}
+
+func (w *ReportWorker) process(job *ReportJob) {
+	if !startProcessing(w.store, job.ID, "Report processing") {
+		log.Printf("Report %s was cancelled before processing", job.ID)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	workerInstance.watchCancel(ctx, cancel, job.ID)
+
+	summary, err := w.generate(ctx, job)
+	switch {
+	case err != nil && ctx.Err() != nil:
+		log.Printf("Report %s aborted: %v", job.ID, err)
+		w.setResult(job, JobResult{
+			Status: StatusCancelled,
+			Error:  err.Error(),
+		})
+	case err != nil:
+		log.Printf("Error generating report %s: %v", job.ID, err)
+		w.setResult(job, JobResult{
+			Status:    StatusError,
+			Error:     err.Error(),
+			ErrorCode: string(apperror.CodeOf(err)),
+		})
+	default:
+		w.setResult(job, JobResult{
+			Status:  StatusDone,
+			Message: "Report ready",
+			Data: map[string]interface{}{
+				"download_url": "/admin/merchants/" + job.Request.MerchantID.String() + "/statements/" + job.ID + "/download",
+				"file_name":    job.FileName(),
+				"format":       job.Request.Format,
+				"lines":        summary.Lines,
+			},
+		})
+	}
+}
+
+// generate writes the report to a temporary file that is renamed into place
+// once complete, so downloads never see a partial report.
+func (w *ReportWorker) generate(ctx context.Context, job *ReportJob) (*statement.Summary, error) {
+	path := filepath.Join(w.dir, job.ID+"-"+job.FileName())
+	f, err := os.Create(path + ".tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	out := bufio.NewWriter(f)
+	summary, err := dependencies.ProvideStatementService().Generate(ctx, job.Request, out)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return summary, os.Rename(f.Name(), path)
+}
+
+// file returns the path and download name of the report of a job.
+func (w *ReportWorker) file(jobID string) (string, string, error) {
+	if jobID == "" || strings.ContainsAny(jobID, `/\*?[`) || strings.Contains(jobID, "..") {
+		return "", "", ErrReportNotFound
+	}
+	matches, err := filepath.Glob(filepath.Join(w.dir, jobID+"-*"))
+	if err != nil {
+		return "", "", err
+	}
+	for _, match := range matches {
+		if !strings.HasSuffix(match, ".tmp") {
+			return match, strings.TrimPrefix(filepath.Base(match), jobID+"-"), nil
+		}
+	}
+	return "", "", ErrReportNotFound
+}
+
+// expire removes the reports older than retention.
+func (w *ReportWorker) expire(retention time.Duration) {
+	interval := retention / 4
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		entries, err := os.ReadDir(w.dir)
+		if err != nil {
+			log.Printf("Failed to list reports in %s: %v", w.dir, err)
+			continue
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || time.Since(info.ModTime()) < retention {
+				continue
+			}
+			if err := os.Remove(filepath.Join(w.dir, entry.Name())); err != nil {
+				log.Printf("Failed to remove expired report %s: %v", entry.Name(), err)
+			}
+		}
+	}
+}
+
+// StatementHandler handles POST /admin/merchants/:merchant_id/statements and
+// queues the statement of the merchant for the period. Its status and
+// download_url are returned by GET /jobs/status?id=<job_id>.
+func StatementHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	req, err := parseMasterDataRequest[dto.StatementRequest](c)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+	format, err := statement.ParseFormat(req.Format)
+	if err != nil {
+		return common.AppErrorResponse(c, apperror.InvalidRequest(err), nil, "")
+	}
+
+	job := &ReportJob{
+		ID: "report-" + uuid.NewString(),
+		Request: service.StatementRequest{
+			MerchantID: merchant.ID,
+			From:       req.From,
+			To:         req.To,
+			Format:     format,
+		},
+	}
+	if err := reportWorkerInstance.Enqueue(job); err != nil {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfterSeconds()))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job_id": job.ID,
+		"status": StatusQueued,
+	})
+}
+
+// ReportDownloadHandler handles GET
+// /admin/merchants/:merchant_id/statements/:id/download and sends the file of
+// a finished report job of the merchant.
+func ReportDownloadHandler(c *fiber.Ctx) error {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.UserContext())
+	merchant, err := merchantOf(c, db)
+	if err != nil {
+		return common.AppErrorResponse(c, err, nil, "")
+	}
+
+	jobID := c.Params("id")
+	result, err := workerInstance.store.Get(c.Context(), jobID)
+	// Reports of other merchants are not found rather than forbidden, so their IDs cannot be probed
+	if errors.Is(err, ErrJobNotFound) || (err == nil && result.MerchantID != merchant.ID.String()) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Job not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if result.Status != StatusDone {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "Report is not ready",
+			"status": result.Status,
+		})
+	}
+
+	path, name, err := reportWorkerInstance.file(jobID)
+	if errors.Is(err, ErrReportNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found or expired",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	// c.Download would take the content type from the file extension
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Report not found or expired",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	c.Attachment(name)
+	c.Set(fiber.HeaderContentType, statement.Format(strings.TrimPrefix(filepath.Ext(name), ".")).ContentType())
+	// the file is closed once it is sent
+	return c.SendStream(file, int(info.Size()))
+}
@@ -0,0 +1,330 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"worker-nicepay/application/dto"
+	"worker-nicepay/domain/entities"
+	"worker-nicepay/infrastructure/common"
+	"worker-nicepay/infrastructure/configuration"
+	constant "worker-nicepay/infrastructure/const"
+	"worker-nicepay/infrastructure/database/models"
+	"worker-nicepay/infrastructure/dependencies"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultScheduleInterval   = time.Second
+	defaultScheduleMaxHorizon = 90 * 24 * time.Hour
+	defaultScheduleLease      = 5 * time.Minute
+	scheduleReleaseTimeout    = 10 * time.Second
+	scheduleClaimBatch        = 50
+)
+
+// ScheduledJob is the API view of a persisted scheduled job.
+type ScheduledJob struct {
+	JobID       string     `json:"job_id"`
+	MerchantID  string     `json:"merchant_id"`
+	ReferenceNo string     `json:"reference_no"`
+	RunAt       time.Time  `json:"run_at"`
+	Status      string     `json:"status"`
+	Priority    bool       `json:"priority"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty"`
+}
+
+func scheduleMaxHorizon() time.Duration {
+	if configuration.AppConfig.ScheduleMaxHorizon > 0 {
+		return time.Duration(configuration.AppConfig.ScheduleMaxHorizon) * time.Hour
+	}
+	return defaultScheduleMaxHorizon
+}
+
+// scheduleLease must cover the time a released job waits in the queue, or
+// another replica may claim it again; only one of them starts it.
+func scheduleLease() time.Duration {
+	if configuration.AppConfig.ScheduleLease > 0 {
+		return time.Duration(configuration.AppConfig.ScheduleLease) * time.Millisecond
+	}
+	return defaultScheduleLease
+}
+
+// Schedule persists a job to be released at runAt. The schedule table is the
+// source of truth, so scheduled jobs survive restarts.
+func (w *Worker) Schedule(ctx context.Context, job *Job, runAt time.Time) error {
+	request, err := json.Marshal(job.Request)
+	if err != nil {
+		return err
+	}
+	incoming, err := json.Marshal(withoutCredentials(job.Incoming))
+	if err != nil {
+		return err
+	}
+
+	job.RunAt = &runAt
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(ctx)
+	err = dependencies.ProvideScheduledPaymentJobsRepository().Insert(db, &models.ScheduledPaymentJobsDataModel{
+		JobID:      job.ID,
+		MerchantID: job.MerchantID,
+		RunAt:      runAt,
+		Status:     constant.SCHEDULED_JOB_STATUS_SCHEDULED,
+		Priority:   job.Priority,
+		Request:    request,
+		Incoming:   incoming,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule job %s: %w", job.ID, err)
+	}
+
+	w.setResult(job, JobResult{
+		Status:  StatusScheduled,
+		Message: "Job scheduled",
+	})
+	return nil
+}
+
+// withoutCredentials drops the credential headers from the request header the
+// Incoming middleware recorded, so they are not stored with scheduled jobs.
+// A header that cannot be read is dropped entirely.
+func withoutCredentials(incoming entities.Incoming) entities.Incoming {
+	if incoming.RequestHeader == "" {
+		return incoming
+	}
+	var headers map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(incoming.RequestHeader), &headers); err != nil {
+		incoming.RequestHeader = ""
+		return incoming
+	}
+	for name := range headers {
+		if isCredentialHeader(name) {
+			delete(headers, name)
+		}
+	}
+	stripped, err := json.Marshal(headers)
+	if err != nil {
+		incoming.RequestHeader = ""
+		return incoming
+	}
+	incoming.RequestHeader = string(stripped)
+	return incoming
+}
+
+func isCredentialHeader(name string) bool {
+	name = strings.ToLower(name)
+	switch name {
+	case "authorization", "proxy-authorization", "cookie":
+		return true
+	}
+	for _, part := range []string{"api-key", "apikey", "token", "secret", "signature"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// releaseScheduled claims due jobs on every tick and hands them to the
+// scheduler. Claiming uses SKIP LOCKED and a lease, so replicas do not claim
+// the same job at once.
+func (w *Worker) releaseScheduled() {
+	interval := time.Duration(configuration.AppConfig.ScheduleInterval) * time.Millisecond
+	if interval <= 0 {
+		interval = defaultScheduleInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), scheduleReleaseTimeout)
+		w.releaseDue(ctx)
+		cancel()
+	}
+}
+
+// releaseDue releases due jobs batch by batch until none is left or the
+// queue rejects one. Rejected jobs are unclaimed, so they stay due and the
+// next pass retries them.
+func (w *Worker) releaseDue(ctx context.Context) {
+	repo := dependencies.ProvideScheduledPaymentJobsRepository()
+	db := dependencies.ProvideYugabyteClient().GetDB()
+	for ctx.Err() == nil {
+		due, err := repo.ClaimDue(db.WithContext(ctx), time.Now(), scheduleLease(), scheduleClaimBatch)
+		if err != nil {
+			log.Printf("Failed to claim scheduled jobs: %v", err)
+			return
+		}
+
+		var rejected []string
+		full := false
+		for _, row := range due {
+			if full || ctx.Err() != nil {
+				rejected = append(rejected, row.JobID)
+				continue
+			}
+			if err := w.release(ctx, row); err != nil {
+				rejected = append(rejected, row.JobID)
+				full = errors.Is(err, ErrQueueFull)
+			}
+		}
+		if len(rejected) > 0 {
+			unclaimCtx, cancel := context.WithTimeout(context.Background(), scheduleReleaseTimeout)
+			if err := repo.Unclaim(db.WithContext(unclaimCtx), rejected); err != nil {
+				log.Printf("Failed to unclaim %d scheduled jobs, retried after their lease: %v", len(rejected), err)
+			}
+			cancel()
+			return
+		}
+		if len(due) < scheduleClaimBatch {
+			return
+		}
+	}
+}
+
+// release hands a claimed job to the scheduler without blocking. It fails
+// with ErrMerchantQuotaExceeded, ErrLaneFull or ErrQueueFull when the job
+// has to wait for a later pass; the job then stays scheduled.
+func (w *Worker) release(ctx context.Context, row models.ScheduledPaymentJobsDataModel) error {
+	runAt := row.RunAt
+	job := &Job{
+		ID:         row.JobID,
+		MerchantID: row.MerchantID,
+		Priority:   row.Priority,
+		RunAt:      &runAt,
+	}
+	if current, err := w.store.Get(ctx, row.JobID); err == nil {
+		job.CreatedAt = current.CreatedAt
+	}
+
+	if err := json.Unmarshal(row.Request, &job.Request); err != nil {
+		w.failRelease(job, err)
+		return nil
+	}
+	if err := json.Unmarshal(row.Incoming, &job.Incoming); err != nil {
+		w.failRelease(job, err)
+		return nil
+	}
+
+	if err := w.admission.Acquire(job.MerchantID); err != nil {
+		return err
+	}
+	w.setResult(job, JobResult{
+		Status:  StatusQueued,
+		Message: "Job queued",
+	})
+	if err := w.push(job); err != nil {
+		w.setResult(job, JobResult{
+			Status:  StatusScheduled,
+			Message: "Job scheduled",
+		})
+		return err
+	}
+	return nil
+}
+
+// start releases a scheduled job as its processing starts. It reports false
+// when the job must be skipped: cancelled, started by another replica that
+// claimed it after the lease expired, or not released because of an error, in
+// which case the job is claimed again after its lease.
+func (w *Worker) start(job *Job) bool {
+	if job.RunAt == nil {
+		return true
+	}
+	db := dependencies.ProvideYugabyteClient().GetDB()
+	started, err := dependencies.ProvideScheduledPaymentJobsRepository().Start(db, job.ID, time.Now())
+	if err != nil {
+		log.Printf("Failed to start scheduled job %s: %v", job.ID, err)
+		return false
+	}
+	if !started {
+		log.Printf("Scheduled job %s was cancelled or started elsewhere", job.ID)
+	}
+	return started
+}
+
+// failRelease fails a job whose row cannot be read, releasing the row so it
+// is not claimed again.
+func (w *Worker) failRelease(job *Job, err error) {
+	log.Printf("Failed to release scheduled job %s: %v", job.ID, err)
+	w.start(job)
+	w.setResult(job, JobResult{
+		Status: StatusError,
+		Error:  err.Error(),
+	})
+}
+
+// cancelScheduled cancels a job still waiting in the schedule table.
+func (w *Worker) cancelScheduled(ctx context.Context, merchantID string, jobID string) (bool, error) {
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(ctx)
+	return dependencies.ProvideScheduledPaymentJobsRepository().Cancel(db, merchantID, jobID)
+}
+
+// scheduleFromRequest reads run_at / delay from the request body.
+func scheduleFromRequest(c *fiber.Ctx) (time.Time, error) {
+	var opts dto.ScheduleOptions
+	if err := c.BodyParser(&opts); err != nil {
+		return time.Time{}, err
+	}
+	return opts.ResolveRunAt(time.Now(), scheduleMaxHorizon())
+}
+
+// ListScheduledJobsHandler handles GET /jobs/scheduled?merchant_id=&status=&page=&limit=
+func ListScheduledJobsHandler(c *fiber.Ctx) error {
+	merchantID := c.Query("merchant_id")
+	if merchantID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "merchant_id is required",
+		})
+	}
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultJobsPageLimit)))
+	if limit < 1 {
+		limit = defaultJobsPageLimit
+	}
+	if limit > maxJobsPageLimit {
+		limit = maxJobsPageLimit
+	}
+
+	status := c.Query("status", constant.SCHEDULED_JOB_STATUS_SCHEDULED)
+	db := dependencies.ProvideYugabyteClient().GetDB().WithContext(c.Context())
+	rows, total, err := dependencies.ProvideScheduledPaymentJobsRepository().FindByMerchant(db, merchantID, status, (page-1)*limit, limit)
+	if err != nil {
+		return common.ErrorResponse(c, fiber.StatusInternalServerError, "Failed to list scheduled jobs", err, nil, "")
+	}
+
+	jobs := make([]ScheduledJob, 0, len(rows))
+	for _, row := range rows {
+		var req dto.CreatePaymentRequest
+		if err := json.Unmarshal(row.Request, &req); err != nil {
+			log.Printf("Failed to read the request of scheduled job %s: %v", row.JobID, err)
+		}
+		jobs = append(jobs, ScheduledJob{
+			JobID:       row.JobID,
+			MerchantID:  row.MerchantID,
+			ReferenceNo: req.ReferenceNo,
+			RunAt:       row.RunAt,
+			Status:      row.Status,
+			Priority:    row.Priority,
+			ReleasedAt:  row.ReleasedAt,
+		})
+	}
+
+	resp := common.BuildSuccessResponse("Success", fiber.StatusOK, jobs, "")
+	resp.Meta = &common.MetaData{
+		Page:      page,
+		TotalPage: int((total + int64(limit) - 1) / int64(limit)),
+		TotalRows: int(total),
+		Limit:     limit,
+	}
+	return c.Status(fiber.StatusOK).JSON(resp)
+}
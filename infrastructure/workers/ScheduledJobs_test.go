@@ -0,0 +1,59 @@
+package workers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"worker-nicepay/domain/entities"
+)
+
+func TestWithoutCredentials(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string][]string
+	}{
+		{
+			name: "credentials dropped",
+			header: `{"Authorization":["Bearer secret"],"Proxy-Authorization":["Basic x"],"Cookie":["session=1"],` +
+				`"X-Api-Key":["key"],"X-Access-Token":["token"],"X-Client-Secret":["s"],"X-Signature":["sig"],` +
+				`"Content-Type":["application/json"],"X-Actor":["ops@example.com"]}`,
+			want: map[string][]string{"Content-Type": {"application/json"}, "X-Actor": {"ops@example.com"}},
+		},
+		{
+			name:   "names in any case",
+			header: `{"authorization":["Bearer secret"],"x-apikey":["key"],"user-agent":["curl"]}`,
+			want:   map[string][]string{"user-agent": {"curl"}},
+		},
+		{
+			name:   "no credentials",
+			header: `{"Content-Type":["application/json"]}`,
+			want:   map[string][]string{"Content-Type": {"application/json"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incoming := withoutCredentials(entities.Incoming{IP: "127.0.0.1", RequestHeader: tt.header})
+			var got map[string][]string
+			if err := json.Unmarshal([]byte(incoming.RequestHeader), &got); err != nil {
+				t.Fatalf("stripped header %q: %v", incoming.RequestHeader, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("header = %v, want %v", got, tt.want)
+			}
+			if incoming.IP != "127.0.0.1" {
+				t.Errorf("IP = %q, want it kept", incoming.IP)
+			}
+		})
+	}
+}
+
+// A header that cannot be read may hold credentials anywhere, so none of it
+// is kept.
+func TestWithoutCredentialsDropsUnreadableHeader(t *testing.T) {
+	incoming := withoutCredentials(entities.Incoming{RequestHeader: `Authorization: Bearer secret`})
+	if incoming.RequestHeader != "" {
+		t.Errorf("header = %q, want it dropped", incoming.RequestHeader)
+	}
+}
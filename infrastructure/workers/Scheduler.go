@@ -0,0 +1,226 @@
+package workers
+
+import (
+	"sync"
+)
+
+// Scheduler replaces the single FIFO job channel with one lane per merchant.
+// Lanes are served by deficit round-robin, so a merchant flooding the queue
+// only gets its weighted share, and each lane holds at most laneCapacity
+// jobs, so one merchant cannot fill the queue for the others. Priority jobs
+// form a separate tier that is served first, except that every priorityBurst
+// priority jobs one normal job is let through to avoid starvation.
+type Scheduler struct {
+	mu            sync.Mutex
+	notEmpty      *sync.Cond
+	capacity      int
+	laneCapacity  int
+	size          int
+	closed        bool
+	weights       map[string]int
+	priority      *drr
+	normal        *drr
+	priorityBurst int
+	servedBurst   int
+}
+
+// LaneDepth is the monitoring view of one merchant lane.
+type LaneDepth struct {
+	MerchantID string `json:"merchant_id"`
+	Weight     int    `json:"weight"`
+	Normal     int    `json:"normal"`
+	Priority   int    `json:"priority"`
+}
+
+// NewScheduler holds up to capacity jobs, at most laneCapacity of them per
+// merchant. A laneCapacity of zero or less only bounds the whole queue.
+func NewScheduler(capacity int, laneCapacity int, weights map[string]int, priorityBurst int) *Scheduler {
+	if priorityBurst <= 0 {
+		priorityBurst = 4
+	}
+	s := &Scheduler{
+		capacity:      capacity,
+		laneCapacity:  laneCapacity,
+		weights:       weights,
+		priority:      newDRR(),
+		normal:        newDRR(),
+		priorityBurst: priorityBurst,
+	}
+	s.notEmpty = sync.NewCond(&s.mu)
+	return s
+}
+
+// Push adds a job to its merchant lane without blocking.
+func (s *Scheduler) Push(job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.size >= s.capacity {
+		return ErrQueueFull
+	}
+	if s.laneCapacity > 0 && s.pending(job.MerchantID) >= s.laneCapacity {
+		return ErrLaneFull
+	}
+
+	tier := s.normal
+	if job.Priority {
+		tier = s.priority
+	}
+	tier.push(job, s.weight(job.MerchantID))
+	s.size++
+	s.notEmpty.Signal()
+	return nil
+}
+
+// Pop blocks until a job is available. It returns false once the scheduler
+// is closed and drained.
+func (s *Scheduler) Pop() (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.size == 0 {
+		if s.closed {
+			return nil, false
+		}
+		s.notEmpty.Wait()
+	}
+
+	var job *Job
+	if s.priority.len > 0 && (s.servedBurst < s.priorityBurst || s.normal.len == 0) {
+		job = s.priority.pop()
+		s.servedBurst++
+	} else {
+		job = s.normal.pop()
+		s.servedBurst = 0
+	}
+	s.size--
+	return job, true
+}
+
+func (s *Scheduler) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.notEmpty.Broadcast()
+}
+
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.size
+}
+
+func (s *Scheduler) Cap() int {
+	return s.capacity
+}
+
+func (s *Scheduler) LaneCap() int {
+	return s.laneCapacity
+}
+
+// Depths returns the pending jobs per merchant lane.
+func (s *Scheduler) Depths() []LaneDepth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byMerchant := make(map[string]*LaneDepth)
+	lane := func(merchantID string) *LaneDepth {
+		d, ok := byMerchant[merchantID]
+		if !ok {
+			d = &LaneDepth{MerchantID: merchantID, Weight: s.weight(merchantID)}
+			byMerchant[merchantID] = d
+		}
+		return d
+	}
+	for _, l := range s.normal.lanes {
+		lane(l.merchantID).Normal = len(l.jobs)
+	}
+	for _, l := range s.priority.lanes {
+		lane(l.merchantID).Priority = len(l.jobs)
+	}
+
+	depths := make([]LaneDepth, 0, len(byMerchant))
+	for _, d := range byMerchant {
+		depths = append(depths, *d)
+	}
+	return depths
+}
+
+// pending counts the jobs of a merchant in both tiers.
+func (s *Scheduler) pending(merchantID string) int {
+	n := 0
+	if l, ok := s.normal.lanes[merchantID]; ok {
+		n += len(l.jobs)
+	}
+	if l, ok := s.priority.lanes[merchantID]; ok {
+		n += len(l.jobs)
+	}
+	return n
+}
+
+func (s *Scheduler) weight(merchantID string) int {
+	if w, ok := s.weights[merchantID]; ok && w > 0 {
+		return w
+	}
+	return 1
+}
+
+// drr is one tier of deficit round-robin lanes. Every job costs one unit and
+// a lane earns its weight in units each time the round reaches it.
+type drr struct {
+	lanes  map[string]*lane
+	active []*lane
+	next   int
+	len    int
+}
+
+type lane struct {
+	merchantID string
+	weight     int
+	deficit    int
+	jobs       []*Job
+}
+
+func newDRR() *drr {
+	return &drr{lanes: make(map[string]*lane)}
+}
+
+func (d *drr) push(job *Job, weight int) {
+	l, ok := d.lanes[job.MerchantID]
+	if !ok {
+		l = &lane{merchantID: job.MerchantID}
+		d.lanes[job.MerchantID] = l
+	}
+	l.weight = weight
+	if len(l.jobs) == 0 {
+		d.active = append(d.active, l)
+	}
+	l.jobs = append(l.jobs, job)
+	d.len++
+}
+
+// pop must only be called when len > 0.
+func (d *drr) pop() *Job {
+	if d.next >= len(d.active) {
+		d.next = 0
+	}
+	l := d.active[d.next]
+	if l.deficit <= 0 {
+		l.deficit += l.weight
+	}
+
+	job := l.jobs[0]
+	l.jobs[0] = nil
+	l.jobs = l.jobs[1:]
+	l.deficit--
+	d.len--
+
+	if len(l.jobs) == 0 {
+		// An idle lane keeps no credit and leaves the round.
+		d.active = append(d.active[:d.next], d.active[d.next+1:]...)
+		delete(d.lanes, l.merchantID)
+	} else if l.deficit <= 0 {
+		d.next++
+	}
+	return job
+}
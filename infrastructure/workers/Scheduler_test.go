@@ -0,0 +1,142 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSchedulerLaneCapacity(t *testing.T) {
+	s := NewScheduler(10, 2, nil, 0)
+	push := func(merchantID string, priority bool) error {
+		return s.Push(&Job{MerchantID: merchantID, Priority: priority})
+	}
+
+	if err := push("a", false); err != nil {
+		t.Fatalf("first job of a: %v", err)
+	}
+	if err := push("a", true); err != nil {
+		t.Fatalf("second job of a: %v", err)
+	}
+	// priority jobs count towards the lane too
+	if err := push("a", false); !errors.Is(err, ErrLaneFull) {
+		t.Fatalf("third job of a = %v, want %v", err, ErrLaneFull)
+	}
+	if err := push("b", false); err != nil {
+		t.Fatalf("a full lane of a must not reject b: %v", err)
+	}
+
+	if _, ok := s.Pop(); !ok {
+		t.Fatal("Pop returned no job")
+	}
+	if err := push("a", false); err != nil {
+		t.Fatalf("job of a after a pop: %v", err)
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	type push struct {
+		merchantID string
+		priority   bool
+		n          int
+	}
+	tests := []struct {
+		name    string
+		weights map[string]int
+		burst   int
+		pushes  []push
+		// want lists the popped jobs as merchant ID and number within the merchant
+		want []string
+	}{
+		{
+			name:   "one lane in order",
+			pushes: []push{{"a", false, 3}},
+			want:   []string{"a1", "a2", "a3"},
+		},
+		{
+			name:   "equal weights take turns",
+			pushes: []push{{"a", false, 3}, {"b", false, 2}},
+			want:   []string{"a1", "b1", "a2", "b2", "a3"},
+		},
+		{
+			name:    "weighted share",
+			weights: map[string]int{"a": 2},
+			pushes:  []push{{"a", false, 4}, {"b", false, 2}},
+			want:    []string{"a1", "a2", "b1", "a3", "a4", "b2"},
+		},
+		{
+			name:    "weight of zero counts as one",
+			weights: map[string]int{"a": 0},
+			pushes:  []push{{"a", false, 2}, {"b", false, 2}},
+			want:    []string{"a1", "b1", "a2", "b2"},
+		},
+		{
+			name:   "priority tier first",
+			pushes: []push{{"a", false, 2}, {"b", true, 2}},
+			want:   []string{"b1", "b2", "a1", "a2"},
+		},
+		{
+			name:   "normal job after a priority burst",
+			burst:  2,
+			pushes: []push{{"a", false, 2}, {"b", true, 5}},
+			want:   []string{"b1", "b2", "a1", "b3", "b4", "a2", "b5"},
+		},
+		{
+			name:    "priority tier is weighted too",
+			weights: map[string]int{"b": 2},
+			burst:   10,
+			pushes:  []push{{"a", true, 2}, {"b", true, 3}},
+			want:    []string{"a1", "b1", "b2", "a2", "b3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(100, 0, tt.weights, tt.burst)
+			for _, p := range tt.pushes {
+				for i := 1; i <= p.n; i++ {
+					if err := s.Push(&Job{ID: fmt.Sprintf("%s%d", p.merchantID, i), MerchantID: p.merchantID, Priority: p.priority}); err != nil {
+						t.Fatalf("Push: %v", err)
+					}
+				}
+			}
+			var got []string
+			for s.Len() > 0 {
+				job, _ := s.Pop()
+				got = append(got, job.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("popped %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerCapacity(t *testing.T) {
+	s := NewScheduler(2, 0, nil, 0)
+	for _, merchantID := range []string{"a", "b"} {
+		if err := s.Push(&Job{MerchantID: merchantID}); err != nil {
+			t.Fatalf("Push %s: %v", merchantID, err)
+		}
+	}
+	if err := s.Push(&Job{MerchantID: "c"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Push to a full queue = %v, want %v", err, ErrQueueFull)
+	}
+	if len(s.Depths()) != 2 {
+		t.Errorf("depths = %v, want a lane each for a and b", s.Depths())
+	}
+
+	// a closed scheduler takes no jobs and drains the ones it has
+	s.Close()
+	if err := s.Push(&Job{MerchantID: "c"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Push after Close = %v, want %v", err, ErrQueueFull)
+	}
+	for i := 0; i < 2; i++ {
+		if _, ok := s.Pop(); !ok {
+			t.Fatalf("Pop %d after Close returned no job", i)
+		}
+	}
+	if _, ok := s.Pop(); ok {
+		t.Fatal("Pop of a closed, drained scheduler returned a job")
+	}
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"log"
+	"os"
 	"strconv"
 
+	"worker-nicepay/application/events"
 	"worker-nicepay/infrastructure/configuration"
 	"worker-nicepay/infrastructure/database"
 	"worker-nicepay/infrastructure/middleware"
@@ -15,8 +17,21 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "seed":
+			runSeed()
+			return
+		}
+	}
+
 	defer func() {
+		workers.StopPaymentCreatedConsumer()
 		queue.CloseRabbitMQ()
+		publishers.CloseRedis()
 	}()
 
 	log.Println("Xendit Worker is starting...")
@@ -26,11 +41,20 @@ func main() {
 	configuration.InitializeAppConfig()
 	log.Println("Configuration initialized")
 
+	if configuration.AppConfig.ServiceName != "" {
+		events.DefaultSource = configuration.AppConfig.ServiceName
+	}
+
 	// Initialize YugabyteDB
 	log.Println("Initializing YugabyteDB...")
 	database.InitializeYugabyteDB()
 	log.Println("YugabyteDB initialized")
 
+	// Apply or verify schema migrations
+	log.Println("Initializing schema...")
+	database.InitializeSchema()
+	log.Println("Schema initialized")
+
 	// Initialize Elasticsearch
 	log.Println("Initializing Elasticsearch...")
 	database.InitializeElasticsearch()
@@ -46,11 +70,28 @@ func main() {
 	publishers.InitializeRedis()
 	log.Println("Publishers initialized")
 
+	// Keep the master data cache of this replica in sync with the others
+	workers.InitializeMasterDataCacheListener()
+
 	// Initialize worker
 	log.Println("Initializing worker...")
 	workers.InitializePaymentXenditTaskWorker()
 	log.Println("Worker initialized")
 
+	// Initialize report worker, sharing the job store of the worker
+	workers.InitializeReportWorker()
+
+	// Initialize resolver for payments with an unknown gateway outcome
+	workers.InitializePaymentResolver()
+
+	// Initialize ledger invariant checker
+	workers.InitializeLedgerChecker()
+
+	// Initialize payment.created consumer
+	log.Println("Initializing consumers...")
+	workers.InitializePaymentCreatedConsumer()
+	log.Println("Consumers initialized")
+
 	// Initialize fiber app
 	app := fiber.New()
 	// tambhkan middleware incoming dsini
@@ -63,7 +104,39 @@ func main() {
 	// Register routes
 	app.Post("/payment/nicepay", workers.PaymentHandler)
 	app.Post("/payment/nicepay/async", workers.EnqueueHandler)
+	app.Post("/payment/nicepay/batch", workers.BatchEnqueueHandler)
+	app.Get("/payment/nicepay/batch/:id", workers.BatchStatusHandler)
+	app.Get("/payments/:transaction_id", workers.PaymentQueryHandler)
+	app.Get("/jobs", workers.ListJobsHandler)
 	app.Get("/jobs/status", workers.StatusHandler)
+	app.Get("/jobs/scheduled", workers.ListScheduledJobsHandler)
+	app.Get("/jobs/metrics", workers.MetricsHandler)
+	app.Get("/jobs/:id/events", workers.JobEventsHandler)
+	app.Delete("/jobs/:id", workers.CancelJobHandler)
+	app.Get("/admin/gateway/breakers", workers.GatewayStatusHandler)
+	app.Post("/admin/gateway/breakers/:channel/reset", workers.ResetGatewayBreakerHandler)
+	app.Get("/admin/master-data/:kind", workers.ListMasterDataHandler)
+	app.Post("/admin/master-data/:kind", workers.CreateMasterDataHandler)
+	app.Put("/admin/master-data/:kind/:id", workers.UpdateMasterDataHandler)
+	app.Delete("/admin/master-data/:kind/:id", workers.DeleteMasterDataHandler)
+	app.Get("/admin/merchants/:merchant_id/payment-methods", workers.ListMerchantPaymentMethodsHandler)
+	app.Post("/admin/merchants/:merchant_id/payment-methods", workers.CreateMerchantPaymentMethodHandler)
+	app.Put("/admin/merchants/:merchant_id/payment-methods/:id", workers.UpdateMerchantPaymentMethodHandler)
+	app.Delete("/admin/merchants/:merchant_id/payment-methods/:id", workers.DeleteMerchantPaymentMethodHandler)
+	app.Get("/admin/merchants/:merchant_id/fee-schedules", workers.ListFeeSchedulesHandler)
+	app.Post("/admin/merchants/:merchant_id/fee-schedules", workers.CreateFeeScheduleHandler)
+	app.Put("/admin/merchants/:merchant_id/fee-schedules/:id", workers.UpdateFeeScheduleHandler)
+	app.Delete("/admin/merchants/:merchant_id/fee-schedules/:id", workers.DeleteFeeScheduleHandler)
+	app.Get("/admin/merchants/:merchant_id/balances", workers.MerchantBalancesHandler)
+	app.Post("/admin/merchants/:merchant_id/statements", workers.StatementHandler)
+	app.Get("/admin/merchants/:merchant_id/statements/:id/download", workers.ReportDownloadHandler)
+	app.Post("/admin/payments/:transaction_id/refunds", workers.RefundCompletionHandler)
+	app.Get("/admin/ledger/balances", workers.LedgerBalancesHandler)
+	app.Get("/admin/ledger/check", workers.LedgerCheckHandler)
+	app.Post("/admin/reconciliations", workers.ImportSettlementHandler)
+	app.Get("/admin/reconciliations", workers.ListReconciliationsHandler)
+	app.Get("/admin/reconciliations/:id", workers.ReconciliationReportHandler)
+	app.Get("/admin/reconciliations/:id/export", workers.ExportReconciliationHandler)
 
 	// Start server
 	port := strconv.Itoa(configuration.AppConfig.ApplicationPort)
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// runMigrate handles `migrate up`, `migrate down [steps]` and `migrate status`.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	configuration.InitializeAppConfig()
+	database.InitializeYugabyteDB()
+	migrator := database.NewMigrator()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Applied %d migrations", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("Invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Reverted %d migrations", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			switch {
+			case status.Unknown:
+				state = "applied (unknown to this binary)"
+			case status.Modified:
+				state = "applied (modified since)"
+			case status.Applied:
+				state = "applied"
+			}
+			appliedAt := ""
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-30s %-34s %s\n", status.Version, status.Name, state, appliedAt)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"worker-nicepay/infrastructure/configuration"
+	"worker-nicepay/infrastructure/database"
+	"worker-nicepay/infrastructure/database/seeds"
+)
+
+// runSeed handles `seed`, loading the embedded master data.
+func runSeed() {
+	configuration.InitializeAppConfig()
+	database.InitializeYugabyteDB()
+
+	ctx := database.WithActor(context.Background(), seeds.Actor, "")
+	result, err := seeds.Run(database.YugabyteDBClient.WithContext(ctx))
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Seeded currencies=%d countries=%d payment_methods=%d ewallet_providers=%d va_providers=%d",
+		result.Currencies, result.Countries, result.PaymentMethods, result.EWalletProviders, result.VAProviders)
+}